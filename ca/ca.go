@@ -0,0 +1,387 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File names used inside a CA directory.
+const (
+	CertFile  = "ca.crt"
+	KeyFile   = "ca.key"
+	IndexFile = "index.json"
+	CRLFile   = "crl.pem"
+)
+
+// The kind of certificate that was issued by the CA.
+type Kind string
+
+const (
+	Server Kind = "server"
+	Client Kind = "client"
+)
+
+// A single certificate issued by the CA as recorded in the index.
+type Issued struct {
+	Serial     string    `json:"serial"`
+	CommonName string    `json:"commonName"`
+	Kind       Kind      `json:"kind"`
+	NotAfter   time.Time `json:"notAfter"`
+	Revoked    bool      `json:"revoked"`
+	RevokedAt  time.Time `json:"revokedAt,omitempty"`
+}
+
+// The on-disk record of everything the CA has issued.
+type index struct {
+	CRLNumber int64    `json:"crlNumber"`
+	Issued    []Issued `json:"issued"`
+}
+
+// Authority is a certificate authority backed by a directory on disk.
+type Authority struct {
+	Dir  string
+	Cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+func newSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// EncodeKey returns the PEM encoding of a private key.
+func EncodeKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// EncodeCert returns the PEM encoding of a DER certificate.
+func EncodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// Init creates a new CA in dir with a self-signed certificate valid for the given duration. It refuses to overwrite an
+// existing CA.
+func Init(dir string, commonName string, validity time.Duration) (*Authority, error) {
+	if _, err := os.Stat(filepath.Join(dir, KeyFile)); err == nil {
+		return nil, fmt.Errorf("a CA already exists in %s", dir)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{"BitWarp"}},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := EncodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, KeyFile), keyPEM, 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, CertFile), EncodeCert(der), 0o644); err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Authority{Dir: dir, Cert: cert, key: key}
+	if err := a.saveIndex(&index{}); err != nil {
+		return nil, err
+	}
+
+	// Publish an empty revocation list straight away so servers can be pointed at it from day one.
+	if _, err := a.GenerateCRL(7 * 24 * time.Hour); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Load opens an existing CA directory.
+func Load(dir string) (*Authority, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, CertFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+
+	keyPEM, err := os.ReadFile(filepath.Join(dir, KeyFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA key: %w", err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("CA certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("CA key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("CA key cannot be used for signing")
+	}
+
+	return &Authority{Dir: dir, Cert: cert, key: key}, nil
+}
+
+func (a *Authority) loadIndex() (*index, error) {
+	data, err := os.ReadFile(filepath.Join(a.Dir, IndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &index{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	idx := &index{}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse CA index: %w", err)
+	}
+	return idx, nil
+}
+
+func (a *Authority) saveIndex(idx *index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a crash never leaves a truncated index behind.
+	tmp := filepath.Join(a.Dir, IndexFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(a.Dir, IndexFile))
+}
+
+// Sign a new leaf certificate and record it in the index.
+func (a *Authority) issue(tmpl *x509.Certificate, kind Kind, validity time.Duration) ([]byte, []byte, error) {
+	key, err := newKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = now.Add(-time.Minute)
+	tmpl.NotAfter = now.Add(validity)
+	if tmpl.NotAfter.After(a.Cert.NotAfter) {
+		tmpl.NotAfter = a.Cert.NotAfter
+	}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, a.Cert, key.Public(), a.key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyPEM, err := EncodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	idx.Issued = append(idx.Issued, Issued{
+		Serial:     serial.Text(16),
+		CommonName: tmpl.Subject.CommonName,
+		Kind:       kind,
+		NotAfter:   tmpl.NotAfter,
+	})
+	if err := a.saveIndex(idx); err != nil {
+		return nil, nil, err
+	}
+
+	return EncodeCert(der), keyPEM, nil
+}
+
+// IssueServer signs a certificate for a BitWarp server. Each SAN is added as an IP address if it parses as one and as a DNS
+// name otherwise. The certificate and key are returned PEM encoded.
+func (a *Authority) IssueServer(name string, sans []string, validity time.Duration) ([]byte, []byte, error) {
+	if name == "" {
+		return nil, nil, errors.New("a server name is required")
+	}
+
+	tmpl := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: name, Organization: []string{"BitWarp"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	if len(sans) == 0 {
+		sans = []string{name}
+	}
+	for _, san := range sans {
+		san = strings.TrimSpace(san)
+		if san == "" {
+			continue
+		}
+		if ip := net.ParseIP(san); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, san)
+		}
+	}
+
+	return a.issue(tmpl, Server, validity)
+}
+
+// IssueClient signs a certificate for an operator. The operator identity is carried in the certificate common name. The
+// certificate and key are returned PEM encoded.
+func (a *Authority) IssueClient(operator string, validity time.Duration) ([]byte, []byte, error) {
+	if operator == "" {
+		return nil, nil, errors.New("an operator identity is required")
+	}
+
+	tmpl := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: operator, Organization: []string{"BitWarp"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	return a.issue(tmpl, Client, validity)
+}
+
+// List returns every certificate the CA has issued.
+func (a *Authority) List() ([]Issued, error) {
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	return idx.Issued, nil
+}
+
+// Revoke marks every certificate whose serial (hex) or common name matches id as revoked. The revocation list has to be
+// regenerated with GenerateCRL for servers to pick the change up.
+func (a *Authority) Revoke(id string) (int, error) {
+	idx, err := a.loadIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	id = strings.ToLower(strings.TrimPrefix(id, "0x"))
+	revoked := 0
+	for i := range idx.Issued {
+		if idx.Issued[i].Revoked {
+			continue
+		}
+		if idx.Issued[i].Serial == id || strings.EqualFold(idx.Issued[i].CommonName, id) {
+			idx.Issued[i].Revoked = true
+			idx.Issued[i].RevokedAt = time.Now()
+			revoked++
+		}
+	}
+
+	if revoked == 0 {
+		return 0, fmt.Errorf("no unrevoked certificate matches %s", id)
+	}
+
+	return revoked, a.saveIndex(idx)
+}
+
+// GenerateCRL signs a new revocation list containing every revoked certificate, writes it to the CA directory and returns
+// its PEM encoding. Servers reject a stale list, so it has to be regenerated before validity runs out.
+func (a *Authority) GenerateCRL(validity time.Duration) ([]byte, error) {
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []x509.RevocationListEntry
+	for _, issued := range idx.Issued {
+		if !issued.Revoked {
+			continue
+		}
+		serial, ok := new(big.Int).SetString(issued.Serial, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial %s in CA index", issued.Serial)
+		}
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: issued.RevokedAt})
+	}
+
+	idx.CRLNumber++
+	now := time.Now()
+	tmpl := &x509.RevocationList{
+		Number:                    big.NewInt(idx.CRLNumber),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(validity),
+		RevokedCertificateEntries: entries,
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, a.Cert, a.key)
+	if err != nil {
+		return nil, err
+	}
+
+	crlPEM := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+	if err := os.WriteFile(filepath.Join(a.Dir, CRLFile), crlPEM, 0o644); err != nil {
+		return nil, err
+	}
+
+	return crlPEM, a.saveIndex(idx)
+}
@@ -0,0 +1,3 @@
+module ca
+
+go 1.23.2
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/apoindevster/bitwarp/audit"
+)
+
+func runAudit(args []string) int {
+	if len(args) != 2 || args[0] != "verify" {
+		fmt.Fprintln(os.Stderr, "Usage: bitwarp audit verify <audit log>")
+		return 2
+	}
+
+	last, err := audit.VerifyFile(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		if last.Seq > 0 {
+			fmt.Fprintf(os.Stderr, "Entries up to sequence %d are intact\n", last.Seq)
+		}
+		return 1
+	}
+
+	fmt.Printf("%s: %d entries verified, last hash %s\n", args[1], last.Seq, last.Hash)
+	return 0
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+)
+
+// Read the hosts listed in a file, one per line, skipping blank lines and # comments.
+func readHosts(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var hosts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			hosts = append(hosts, line)
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+// Print how the command went on each host, as a table.
+func printSummary(results []commandclient.HostResult) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tSTATUS\tCODE\tDURATION\tERROR")
+	for _, result := range results {
+		code, reason := "-", ""
+		var failed *commandclient.CommandFailedError
+		if result.Err == nil || errors.As(result.Err, &failed) {
+			code = fmt.Sprint(result.Code)
+		} else {
+			reason = result.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Name, result.Status(), code, result.Duration.Round(time.Millisecond), reason)
+	}
+	w.Flush()
+}
+
+// Run a command on several servers at once with every line of output behind the name of its host, then sum up how it
+// went on each. The exit code is 0 when the command succeeded everywhere, the largest exit code of the command
+// otherwise, and 255 when it could not be run to the end on some host.
+func runBroadcast(args []string) int {
+	cmdSet := flag.NewFlagSet("broadcast", flag.ContinueOnError)
+	run := addRunFlags(cmdSet)
+	hostsFile := cmdSet.String("hosts-file", "", "file listing servers to run on, one per line")
+	parallel := cmdSet.Int("parallel", 32, "most servers to run on at the same time, 0 for all of them")
+	failFast := cmdSet.Bool("fail-fast", false, "stop every server as soon as the command fails on one")
+	quiet := cmdSet.Bool("q", false, "leave out the summary")
+	conn := addConnectionFlags(cmdSet)
+	cmdSet.Lookup("host").Usage = "comma separated servers to run on, host or host:port (default port " + defaultPort + ", $BITWARP_HOST)"
+	if !parseRemote(cmdSet, "[flags] command [arguments]", args, 1, -1) {
+		return 2
+	}
+	options, err := run.options(cmdSet.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "broadcast: %v\n", err)
+		return 2
+	}
+
+	var hosts []string
+	for _, host := range strings.Split(*conn.host, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if *hostsFile != "" {
+		listed, err := readHosts(*hostsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "broadcast: %v\n", err)
+			return 2
+		}
+		hosts = append(hosts, listed...)
+	}
+	if len(hosts) == 0 {
+		fmt.Fprintln(os.Stderr, "broadcast: no servers given, use -host, -hosts-file or set $BITWARP_HOST")
+		return 2
+	}
+
+	targets := make([]commandclient.Target, 0, len(hosts))
+	for _, host := range hosts {
+		client, err := conn.dialHost(host)
+		if err != nil {
+			printError("broadcast", err)
+			return exitRemoteError
+		}
+		defer client.Close()
+		targets = append(targets, commandclient.Target{Name: host, Client: client})
+	}
+	ctx, stop := interruptible()
+	defer stop()
+
+	results := commandclient.FanOut(ctx, targets, options, &commandclient.FanOutOptions{
+		Concurrency: *parallel,
+		Timeout:     *run.timeout,
+		FailFast:    *failFast,
+		Output:      commandclient.PrefixOutput(os.Stdout, os.Stderr),
+	})
+	if !*quiet {
+		printSummary(results)
+	}
+
+	code := 0
+	for _, result := range results {
+		var failed *commandclient.CommandFailedError
+		switch {
+		case result.Err == nil:
+		case errors.As(result.Err, &failed) && failed.Code > 0 && failed.Code < exitRemoteError:
+			code = max(code, int(failed.Code))
+		default:
+			code = exitRemoteError
+		}
+	}
+	return code
+}
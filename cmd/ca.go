@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apoindevster/bitwarp/ca"
+)
+
+// The CA lives in the user's config directory unless -dir says otherwise.
+func defaultCADir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "bitwarp-ca"
+	}
+	return filepath.Join(dir, "bitwarp", "ca")
+}
+
+func caUsage() {
+	fmt.Fprint(os.Stderr, `Usage: bitwarp ca <command> [flags]
+
+Commands:
+  init      Create a new certificate authority
+  server    Issue a server certificate
+  client    Issue a client certificate for an operator
+  list      List issued certificates
+  revoke    Revoke a certificate by serial or common name
+  crl       Regenerate the certificate revocation list
+`)
+}
+
+// Write a freshly issued key pair next to each other in out.
+func writeKeyPair(out string, name string, certPEM []byte, keyPEM []byte) error {
+	if err := os.MkdirAll(out, 0o700); err != nil {
+		return err
+	}
+
+	certPath := filepath.Join(out, name+".crt")
+	keyPath := filepath.Join(out, name+".key")
+	if err := os.WriteFile(certPath, certPEM, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s and %s\n", certPath, keyPath)
+	return nil
+}
+
+func runCA(args []string) int {
+	if len(args) == 0 {
+		caUsage()
+		return 2
+	}
+
+	cmdSet := flag.NewFlagSet("ca "+args[0], flag.ContinueOnError)
+	dir := cmdSet.String("dir", defaultCADir(), "directory holding the CA")
+	name := cmdSet.String("name", "", "common name of the certificate")
+	sans := cmdSet.String("san", "", "comma separated DNS names and IP addresses for a server certificate")
+	out := cmdSet.String("out", ".", "directory to write issued certificates and keys to")
+	validity := cmdSet.Duration("validity", 0, "how long the certificate or revocation list is valid for")
+
+	if err := cmdSet.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	switch args[0] {
+	case "init":
+		if *name == "" {
+			*name = "BitWarp CA"
+		}
+		if *validity == 0 {
+			*validity = 10 * 365 * 24 * time.Hour
+		}
+		a, err := ca.Init(*dir, *name, *validity)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to initialise CA: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Created CA %q in %s\n", a.Cert.Subject.CommonName, a.Dir)
+		fmt.Printf("Distribute %s to servers (-ca) and clients, and point servers at %s (-crl)\n",
+			filepath.Join(a.Dir, ca.CertFile), filepath.Join(a.Dir, ca.CRLFile))
+	case "server", "client":
+		if *validity == 0 {
+			*validity = 365 * 24 * time.Hour
+		}
+		// The name becomes the file names in -out, so it must not be able to point anywhere else.
+		if strings.ContainsAny(*name, `/\`) || strings.Contains(*name, "..") {
+			fmt.Fprintf(os.Stderr, "Invalid name %q: it must not contain path separators or ..\n", *name)
+			return 2
+		}
+		a, err := ca.Load(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load CA: %v\n", err)
+			return 1
+		}
+
+		var certPEM, keyPEM []byte
+		if args[0] == "server" {
+			var list []string
+			if *sans != "" {
+				list = strings.Split(*sans, ",")
+			}
+			certPEM, keyPEM, err = a.IssueServer(*name, list, *validity)
+		} else {
+			certPEM, keyPEM, err = a.IssueClient(*name, *validity)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to issue certificate: %v\n", err)
+			return 1
+		}
+
+		if err := writeKeyPair(*out, *name, certPEM, keyPEM); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write certificate: %v\n", err)
+			return 1
+		}
+	case "list":
+		a, err := ca.Load(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load CA: %v\n", err)
+			return 1
+		}
+		issued, err := a.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read CA index: %v\n", err)
+			return 1
+		}
+		for _, i := range issued {
+			state := "valid"
+			if i.Revoked {
+				state = "revoked"
+			} else if time.Now().After(i.NotAfter) {
+				state = "expired"
+			}
+			fmt.Printf("%-32s %-7s %-8s %s %s\n", i.Serial, i.Kind, state, i.NotAfter.Format(time.RFC3339), i.CommonName)
+		}
+	case "revoke":
+		if cmdSet.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: bitwarp ca revoke [-dir dir] [-validity duration] <serial|name>")
+			return 2
+		}
+		if *validity == 0 {
+			*validity = 7 * 24 * time.Hour
+		}
+		a, err := ca.Load(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load CA: %v\n", err)
+			return 1
+		}
+		n, err := a.Revoke(cmdSet.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to revoke: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Revoked %d certificate(s)\n", n)
+
+		// Revocation is useless until servers see it, so publish a new list right away.
+		if _, err := a.GenerateCRL(*validity); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to regenerate revocation list: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Updated %s\n", filepath.Join(a.Dir, ca.CRLFile))
+	case "crl":
+		if *validity == 0 {
+			*validity = 7 * 24 * time.Hour
+		}
+		a, err := ca.Load(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load CA: %v\n", err)
+			return 1
+		}
+		if _, err := a.GenerateCRL(*validity); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate revocation list: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Wrote %s\n", filepath.Join(a.Dir, ca.CRLFile))
+	default:
+		caUsage()
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/commandclient"
+	proto "github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// A repeatable -env KEY=VALUE flag.
+type envFlag map[string]string
+
+func (e envFlag) String() string {
+	return fmt.Sprint(map[string]string(e))
+}
+
+func (e envFlag) Set(value string) error {
+	name, val, found := strings.Cut(value, "=")
+	if !found || name == "" {
+		return fmt.Errorf("environment variable %q must look like KEY=VALUE", value)
+	}
+	e[name] = val
+	return nil
+}
+
+// Flags describing how to run a command, shared by exec and broadcast.
+type runFlags struct {
+	env      envFlag
+	cwd      *string
+	clearEnv *bool
+	timeout  *time.Duration
+	user     *string
+	compress *string
+}
+
+func addRunFlags(cmdSet *flag.FlagSet) *runFlags {
+	f := &runFlags{env: envFlag{}}
+	f.cwd = cmdSet.String("cwd", "", "working directory of the command")
+	cmdSet.Var(f.env, "env", "set an environment variable, KEY=VALUE (repeatable)")
+	f.clearEnv = cmdSet.Bool("clearenv", false, "start from an empty environment instead of the server's")
+	f.timeout = cmdSet.Duration("timeout", 0, "kill the command after this long")
+	f.user = cmdSet.String("user", "", "run the command as this user")
+	f.compress = cmdSet.String("compress", "", "compress output with zstd or gzip")
+	return f
+}
+
+// The options to run args with, leaving the timeout to the caller.
+func (f *runFlags) options(args []string) (*proto.RunExecutableOptions, error) {
+	compression, err := codec.Parse(*f.compress)
+	if err != nil {
+		return nil, err
+	}
+	options := &proto.RunExecutableOptions{
+		Command:     args[0],
+		Args:        args[1:],
+		Cwd:         *f.cwd,
+		Env:         f.env,
+		User:        *f.user,
+		Compression: compression,
+	}
+	if *f.clearEnv {
+		options.EnvMode = proto.EnvMode_ENV_CLEAR
+	}
+	return options, nil
+}
+
+// Run a command on the server with its output on the local terminal, and exit with its exit code. Failures of BitWarp
+// itself exit with 255 and an interrupted command with 130.
+func runExec(args []string) int {
+	cmdSet := flag.NewFlagSet("exec", flag.ContinueOnError)
+	run := addRunFlags(cmdSet)
+	noStdin := cmdSet.Bool("n", false, "do not send stdin to the command, which reads from an empty stdin instead")
+	conn := addConnectionFlags(cmdSet)
+	if !parseRemote(cmdSet, "[flags] command [arguments]", args, 1, -1) {
+		return 2
+	}
+	options, err := run.options(cmdSet.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "exec: %v\n", err)
+		return 2
+	}
+	if *run.timeout > 0 {
+		options.Timeout = durationpb.New(*run.timeout)
+	}
+
+	client, err := conn.dial()
+	if err != nil {
+		printError("exec", err)
+		return exitRemoteError
+	}
+	defer client.Close()
+	ctx, stop := interruptible()
+	defer stop()
+
+	cmd := client.Command(ctx, options.GetCommand())
+	cmd.Options = options
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if !*noStdin {
+		cmd.Stdin = os.Stdin
+	}
+
+	err = cmd.Run()
+	var failed *commandclient.CommandFailedError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &failed):
+		if failed.Code < 0 || failed.Code > 254 {
+			return exitRemoteError
+		}
+		return int(failed.Code)
+	case ctx.Err() != nil:
+		fmt.Fprintln(os.Stderr, "exec: interrupted")
+		return 130
+	default:
+		printError("exec", err)
+		return exitRemoteError
+	}
+}
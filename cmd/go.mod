@@ -0,0 +1,60 @@
+module bitwarp-cli
+
+go 1.23.2
+
+require (
+	github.com/apoindevster/bitwarp v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/audit v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/ca v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/codec v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/commandclient v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/commandserver v0.0.0-unpublished
+	github.com/google/uuid v1.6.0
+	google.golang.org/grpc v1.73.0
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	github.com/apoindevster/bitwarp/delta v0.0.0-unpublished // indirect
+	github.com/apoindevster/bitwarp/tree v0.0.0-unpublished // indirect
+	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
+	github.com/charmbracelet/colorprofile v0.2.3-0.20250311203215-f60798e515dc // indirect
+	github.com/charmbracelet/lipgloss v1.1.0 // indirect
+	github.com/charmbracelet/log v0.4.2 // indirect
+	github.com/charmbracelet/x/ansi v0.8.0 // indirect
+	github.com/charmbracelet/x/cellbuf v0.0.13-0.20250311204145-2c3ea96c31dd // indirect
+	github.com/charmbracelet/x/term v0.2.1 // indirect
+	github.com/creack/pty v1.1.24 // indirect
+	github.com/go-logfmt/logfmt v0.6.0 // indirect
+	github.com/hashicorp/yamux v0.1.2 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mattn/go-runewidth v0.0.16 // indirect
+	github.com/muesli/termenv v0.16.0 // indirect
+	github.com/rivo/uniseg v0.4.7 // indirect
+	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
+	golang.org/x/crypto v0.36.0 // indirect
+	golang.org/x/exp v0.0.0-20231006140011-7918f672742d // indirect
+	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/apoindevster/bitwarp => ../
+
+replace github.com/apoindevster/bitwarp/ca => ../ca
+
+replace github.com/apoindevster/bitwarp/commandserver => ../commandserver
+
+replace github.com/apoindevster/bitwarp/audit => ../audit
+
+replace github.com/apoindevster/bitwarp/tree => ../tree
+
+replace github.com/apoindevster/bitwarp/codec => ../codec
+
+replace github.com/apoindevster/bitwarp/delta => ../delta
+
+replace github.com/apoindevster/bitwarp/commandclient => ../commandclient
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	proto "github.com/apoindevster/bitwarp/proto"
+	"github.com/google/uuid"
+)
+
+var hashAlgorithms = map[string]proto.HashAlgorithm{
+	"sha256":  proto.HashAlgorithm_HASH_SHA256,
+	"sha1":    proto.HashAlgorithm_HASH_SHA1,
+	"md5":     proto.HashAlgorithm_HASH_MD5,
+	"blake2b": proto.HashAlgorithm_HASH_BLAKE2B,
+}
+
+// Parse flags for a subcommand that talks to a server and takes between min and max arguments, with a max of -1 for no
+// limit.
+func parseRemote(cmdSet *flag.FlagSet, usage string, args []string, min int, max int) bool {
+	cmdSet.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: bitwarp %s %s\n", cmdSet.Name(), usage)
+		cmdSet.PrintDefaults()
+	}
+	if err := cmdSet.Parse(args); err != nil {
+		return false
+	}
+	if cmdSet.NArg() < min || (max >= 0 && cmdSet.NArg() > max) {
+		cmdSet.Usage()
+		return false
+	}
+	return true
+}
+
+// Hash files on the server, printed like sha256sum does for a single algorithm and in the tagged BSD style when there
+// are several.
+func runHash(args []string) int {
+	cmdSet := flag.NewFlagSet("hash", flag.ContinueOnError)
+	names := cmdSet.String("a", "sha256", "comma separated algorithms out of sha256, sha1, md5 and blake2b")
+	conn := addConnectionFlags(cmdSet)
+	if !parseRemote(cmdSet, "[flags] path...", args, 1, -1) {
+		return 2
+	}
+
+	var algorithms []proto.HashAlgorithm
+	for _, name := range strings.Split(*names, ",") {
+		algorithm, ok := hashAlgorithms[strings.ToLower(name)]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "hash: unknown hash algorithm %q\n", name)
+			return 2
+		}
+		algorithms = append(algorithms, algorithm)
+	}
+
+	client, err := conn.dial()
+	if err != nil {
+		printError("hash", err)
+		return 1
+	}
+	defer client.Close()
+	ctx, stop := interruptible()
+	defer stop()
+
+	// Like sha256sum, carry on past a path that cannot be hashed and fail at the end.
+	code := 0
+	for _, path := range cmdSet.Args() {
+		results, err := client.HashFile(ctx, path, algorithms)
+		if err != nil {
+			printError("hash", err)
+			code = 1
+			continue
+		}
+		for _, result := range results {
+			for _, digest := range result.GetDigests() {
+				if len(algorithms) == 1 {
+					fmt.Printf("%x  %s\n", digest.GetSum(), result.GetPath())
+				} else {
+					name := strings.TrimPrefix(digest.GetAlgorithm().String(), "HASH_")
+					fmt.Printf("%s (%s) = %x\n", name, result.GetPath(), digest.GetSum())
+				}
+			}
+		}
+	}
+	return code
+}
+
+// The ls -l style mode of an entry, such as drwxr-xr-x.
+func modeString(info *proto.FileInfo) string {
+	kind := "-"
+	switch info.GetType() {
+	case proto.EntryType_ENTRY_DIR:
+		kind = "d"
+	case proto.EntryType_ENTRY_SYMLINK:
+		kind = "l"
+	case proto.EntryType_ENTRY_OTHER:
+		kind = "?"
+	}
+	return kind + fs.FileMode(info.GetMode()).Perm().String()[1:]
+}
+
+// Print an entry as a row of ls -l, falling back on the uid and gid when there are no names for them.
+func printLong(w *tabwriter.Writer, info *proto.FileInfo) {
+	owner, group := info.GetOwner(), info.GetGroup()
+	if owner == "" {
+		owner = fmt.Sprint(info.GetUid())
+	}
+	if group == "" {
+		group = fmt.Sprint(info.GetGid())
+	}
+	name := info.GetName()
+	if info.GetType() == proto.EntryType_ENTRY_SYMLINK {
+		name += " -> " + info.GetLinkTarget()
+	}
+	modified := info.GetModTime().AsTime().Local().Format(time.DateTime)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", modeString(info), owner, group, info.GetSize(), modified, name)
+}
+
+// List a directory on the server, or describe a single entry that is not one.
+func runLs(args []string) int {
+	cmdSet := flag.NewFlagSet("ls", flag.ContinueOnError)
+	long := cmdSet.Bool("l", false, "show mode, owner, group, size and modification time")
+	conn := addConnectionFlags(cmdSet)
+	if !parseRemote(cmdSet, "[flags] path", args, 1, 1) {
+		return 2
+	}
+	path := cmdSet.Arg(0)
+
+	client, err := conn.dial()
+	if err != nil {
+		printError("ls", err)
+		return 1
+	}
+	defer client.Close()
+	ctx, stop := interruptible()
+	defer stop()
+
+	info, err := client.Stat(ctx, path, true)
+	if err != nil {
+		printError("ls", err)
+		return 1
+	}
+	entries := []*proto.FileInfo{info}
+	if info.GetType() == proto.EntryType_ENTRY_DIR {
+		entries, err = client.ListDir(ctx, path)
+		if err != nil {
+			printError("ls", err)
+			return 1
+		}
+	}
+
+	if !*long {
+		for _, entry := range entries {
+			fmt.Println(entry.GetName())
+		}
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	for _, entry := range entries {
+		printLong(w, entry)
+	}
+	w.Flush()
+	return 0
+}
+
+// Describe the server: who it is, what it runs on and what it supports.
+func runInfo(args []string) int {
+	cmdSet := flag.NewFlagSet("info", flag.ContinueOnError)
+	conn := addConnectionFlags(cmdSet)
+	if !parseRemote(cmdSet, "[flags]", args, 0, 0) {
+		return 2
+	}
+
+	client, err := conn.dial()
+	if err != nil {
+		printError("info", err)
+		return 1
+	}
+	defer client.Close()
+	ctx, stop := interruptible()
+	defer stop()
+
+	params, err := client.ConnectionParams(ctx)
+	if err != nil {
+		printError("info", err)
+		return 1
+	}
+	compressions := make([]string, 0, len(params.GetCompressions()))
+	for _, compression := range params.GetCompressions() {
+		compressions = append(compressions, strings.ToLower(strings.TrimPrefix(compression.String(), "COMPRESSION_")))
+	}
+
+	id, err := uuid.FromBytes(params.GetUuid())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "info: server sent a malformed uuid: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "uuid:\t%s\n", id)
+	fmt.Fprintf(w, "hostname:\t%s\n", params.GetHostname())
+	fmt.Fprintf(w, "os:\t%s\n", params.GetOs())
+	fmt.Fprintf(w, "arch:\t%s\n", params.GetArch())
+	fmt.Fprintf(w, "version:\t%s\n", params.GetVersion())
+	fmt.Fprintf(w, "capabilities:\t%s\n", strings.Join(params.GetCapabilities(), ", "))
+	fmt.Fprintf(w, "compressions:\t%s\n", strings.Join(compressions, ", "))
+	w.Flush()
+	return 0
+}
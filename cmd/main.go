@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// A top level subcommand of the bitwarp command line. run receives the arguments following the subcommand name and
+// returns the process exit code.
+type subcommand struct {
+	summary string
+	run     func(args []string) int
+}
+
+var subcommands = map[string]subcommand{
+	"audit":     {summary: "Verify the hash chain of a server audit log", run: runAudit},
+	"broadcast": {summary: "Run a command on several servers at once", run: runBroadcast},
+	"ca":        {summary: "Manage the certificate authority used for mutual TLS", run: runCA},
+	"download":  {summary: "Copy a file or directory from a server", run: runDownload},
+	"exec":      {summary: "Run a command on a server and exit with its exit code", run: runExec},
+	"hash":      {summary: "Hash files on a server", run: runHash},
+	"info":      {summary: "Describe a server", run: runInfo},
+	"ls":        {summary: "List a directory on a server", run: runLs},
+	"token":     {summary: "Issue bearer tokens for operators", run: runToken},
+	"upload":    {summary: "Copy a file or directory to a server", run: runUpload},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: bitwarp <command> [arguments]\n\nCommands:\n")
+
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, subcommands[name].summary)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := subcommands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unrecognized command %s\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	os.Exit(cmd.run(os.Args[2:]))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+	"google.golang.org/grpc/status"
+)
+
+// Port servers listen on unless told otherwise.
+const defaultPort = "8090"
+
+// Exit code of exec when BitWarp itself fails, rather than the remote command, as ssh does.
+const exitRemoteError = 255
+
+// How to reach the server, shared by every subcommand that talks to one.
+type connectionFlags struct {
+	host  *string
+	token *string
+	cert  *string
+	key   *string
+	ca    *string
+}
+
+// Register the connection flags on cmdSet, each falling back to an environment variable. Taking the token from
+// $BITWARP_TOKEN keeps it out of the process list.
+func addConnectionFlags(cmdSet *flag.FlagSet) *connectionFlags {
+	return &connectionFlags{
+		host:  cmdSet.String("host", os.Getenv("BITWARP_HOST"), "server to connect to, host or host:port (default port "+defaultPort+", $BITWARP_HOST)"),
+		token: cmdSet.String("token", "", "bearer token to authenticate with ($BITWARP_TOKEN)"),
+		cert:  cmdSet.String("cert", os.Getenv("BITWARP_CERT"), "client certificate for mutual TLS ($BITWARP_CERT)"),
+		key:   cmdSet.String("key", os.Getenv("BITWARP_KEY"), "private key of the client certificate ($BITWARP_KEY)"),
+		ca:    cmdSet.String("ca", os.Getenv("BITWARP_CA"), "CA bundle that signed the server certificate ($BITWARP_CA)"),
+	}
+}
+
+// Connect to the server the flags describe. Without any of the TLS flags the connection is made without transport
+// security.
+func (f *connectionFlags) dial() (*commandclient.Client, error) {
+	if *f.host == "" {
+		return nil, errors.New("no server given, use -host or set $BITWARP_HOST")
+	}
+	return f.dialHost(*f.host)
+}
+
+// Connect to host with the credentials the flags describe.
+func (f *connectionFlags) dialHost(host string) (*commandclient.Client, error) {
+	address := host
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultPort)
+	}
+
+	var tlsConfig *tls.Config
+	if *f.cert != "" || *f.key != "" || *f.ca != "" {
+		var err error
+		tlsConfig, err = commandclient.LoadClientTLSConfig(*f.cert, *f.key, *f.ca)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The token is looked up here rather than used as the flag default, which usage would print.
+	token := *f.token
+	if token == "" {
+		token = os.Getenv("BITWARP_TOKEN")
+	}
+	return commandclient.Dial(address, tlsConfig, token)
+}
+
+// A context cancelled by SIGINT or SIGTERM, so an interrupted subcommand stops what it started on the server.
+func interruptible() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// Print why a call failed, leaving out the gRPC framing of errors that came from the server.
+func printError(name string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", name, status.Convert(err).Message())
+}
+
+// A repeatable flag collecting every value it is given.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apoindevster/bitwarp/commandserver"
+)
+
+func tokenUsage() {
+	fmt.Fprint(os.Stderr, `Usage: bitwarp token <command> [flags]
+
+Commands:
+  issue     Sign an expiring token with the server's token secret
+  static    Generate a random token and the entry to add to the server's token file
+  secret    Generate a new token secret
+`)
+}
+
+func splitRoles(roles string) []string {
+	if roles == "" {
+		return nil
+	}
+	return strings.Split(roles, ",")
+}
+
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func runToken(args []string) int {
+	if len(args) == 0 {
+		tokenUsage()
+		return 2
+	}
+
+	cmdSet := flag.NewFlagSet("token "+args[0], flag.ContinueOnError)
+	secretFile := cmdSet.String("secret", "", "path to the token secret given to the server with -token-secret")
+	name := cmdSet.String("name", "", "operator the token identifies")
+	roles := cmdSet.String("roles", "", "comma separated roles granted to the operator")
+	ttl := cmdSet.Duration("ttl", 12*time.Hour, "how long an issued token is valid for")
+
+	if err := cmdSet.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	switch args[0] {
+	case "issue":
+		secret, err := commandserver.LoadHMACSecret(*secretFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		token, err := commandserver.IssueHMACToken(secret, *name, splitRoles(*roles), *ttl)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to issue token: %v\n", err)
+			return 1
+		}
+		fmt.Println(token)
+	case "static":
+		if *name == "" {
+			fmt.Fprintln(os.Stderr, "A token needs a -name")
+			return 2
+		}
+		token, err := randomString(32)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate token: %v\n", err)
+			return 1
+		}
+		entry, err := json.Marshal(commandserver.StaticToken{Name: *name, Roles: splitRoles(*roles), SHA256: commandserver.HashToken(token)})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode token entry: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Token: %s\nToken file entry: %s\n", token, entry)
+	case "secret":
+		secret, err := randomString(48)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate secret: %v\n", err)
+			return 1
+		}
+		fmt.Println(secret)
+	default:
+		tokenUsage()
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/commandclient"
+	proto "github.com/apoindevster/bitwarp/proto"
+)
+
+// Flags shared by upload and download, which only differ in the direction the data goes.
+type transferFlags struct {
+	conn      *connectionFlags
+	recursive *bool
+	compress  *string
+	verbose   *bool
+	include   listFlag
+	exclude   listFlag
+}
+
+// Parse the flags of upload or download, returning the source and destination.
+func parseTransfer(name string, args []string) (*transferFlags, string, string, bool) {
+	cmdSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := &transferFlags{conn: addConnectionFlags(cmdSet)}
+	f.recursive = cmdSet.Bool("r", false, "copy a directory tree")
+	f.compress = cmdSet.String("compress", "", "compress the file on the wire with zstd or gzip")
+	f.verbose = cmdSet.Bool("v", false, "describe what was copied on stderr")
+	cmdSet.Var(&f.include, "include", "with -r, only copy files matching this glob (repeatable)")
+	cmdSet.Var(&f.exclude, "exclude", "with -r, skip files matching this glob (repeatable)")
+	cmdSet.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: bitwarp %s [flags] source destination\n\nA local path of - stands for stdin or stdout.\n\n", name)
+		cmdSet.PrintDefaults()
+	}
+	if err := cmdSet.Parse(args); err != nil {
+		return nil, "", "", false
+	}
+	if cmdSet.NArg() != 2 {
+		cmdSet.Usage()
+		return nil, "", "", false
+	}
+	if !*f.recursive && (len(f.include) > 0 || len(f.exclude) > 0) {
+		fmt.Fprintf(os.Stderr, "%s: -include and -exclude only apply with -r\n", name)
+		return nil, "", "", false
+	}
+	if *f.recursive && *f.compress != "" {
+		fmt.Fprintf(os.Stderr, "%s: -compress does not apply with -r\n", name)
+		return nil, "", "", false
+	}
+	return f, cmdSet.Arg(0), cmdSet.Arg(1), true
+}
+
+// Copies with a connected client and describes what was copied.
+type transferRun func(ctx context.Context, client *commandclient.Client, options *commandclient.TransferOptions) (string, error)
+
+// Dial the server and run transfer, reporting how it went.
+func runTransfer(name string, f *transferFlags, transfer transferRun) int {
+	compression, err := codec.Parse(*f.compress)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 2
+	}
+
+	client, err := f.conn.dial()
+	if err != nil {
+		printError(name, err)
+		return 1
+	}
+	defer client.Close()
+	ctx, stop := interruptible()
+	defer stop()
+
+	summary, err := transfer(ctx, client, &commandclient.TransferOptions{Compression: compression})
+	if err != nil {
+		printError(name, err)
+		return 1
+	}
+	if *f.verbose {
+		fmt.Fprintln(os.Stderr, summary)
+	}
+	return 0
+}
+
+// Describe a copied tree the way the shell does.
+func describeTree(summary *proto.TreeSummary) string {
+	return fmt.Sprintf("%d files, %d directories, %d symlinks, %d bytes", summary.GetFiles(), summary.GetDirs(), summary.GetSymlinks(), summary.GetBytes())
+}
+
+func describeFile(result *commandclient.TransferResult) string {
+	return fmt.Sprintf("%d bytes, %d sent, sha256 %x", result.Size, result.Wire, result.Sha256)
+}
+
+// Copy a file or, with -r, a directory tree to the server. A source of - uploads stdin.
+func runUpload(args []string) int {
+	f, src, dest, ok := parseTransfer("upload", args)
+	if !ok {
+		return 2
+	}
+	if src == "-" && *f.recursive {
+		fmt.Fprintln(os.Stderr, "upload: -r cannot upload stdin")
+		return 2
+	}
+
+	return runTransfer("upload", f, func(ctx context.Context, client *commandclient.Client, options *commandclient.TransferOptions) (string, error) {
+		switch {
+		case *f.recursive:
+			summary, err := client.TreeUpload(ctx, src, dest, f.include, f.exclude)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Uploaded %s to %s: %s", src, dest, describeTree(summary)), nil
+		case src == "-":
+			result, err := client.FileUploadFrom(ctx, os.Stdin, dest, options)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Uploaded stdin to %s: %s", dest, describeFile(result)), nil
+		default:
+			result, err := client.FileUpload(ctx, src, dest, options)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Uploaded %s to %s: %s", src, dest, describeFile(result)), nil
+		}
+	})
+}
+
+// Copy a file or, with -r, a directory tree from the server. A destination of - writes the file to stdout.
+func runDownload(args []string) int {
+	f, src, dest, ok := parseTransfer("download", args)
+	if !ok {
+		return 2
+	}
+	if dest == "-" && *f.recursive {
+		fmt.Fprintln(os.Stderr, "download: -r cannot download to stdout")
+		return 2
+	}
+
+	return runTransfer("download", f, func(ctx context.Context, client *commandclient.Client, options *commandclient.TransferOptions) (string, error) {
+		switch {
+		case *f.recursive:
+			summary, err := client.TreeDownload(ctx, src, dest, f.include, f.exclude)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Downloaded %s to %s: %s", src, dest, describeTree(summary)), nil
+		case dest == "-":
+			result, err := client.FileDownloadTo(ctx, src, os.Stdout, options)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Downloaded %s to stdout: %s", src, describeFile(result)), nil
+		default:
+			result, err := client.FileDownload(ctx, src, dest, options)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Downloaded %s to %s: %s", src, dest, describeFile(result)), nil
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported lists the codecs Encode and Decode handle, most preferred first.
+var Supported = []proto.Compression{proto.Compression_COMPRESSION_ZSTD, proto.Compression_COMPRESSION_GZIP}
+
+// Payloads smaller than this are not worth compressing.
+const minSize = 128
+
+// Largest payload Decode produces, so a small message cannot expand into an arbitrary amount of memory.
+const maxDecoded = 16 << 20
+
+// EncodeAll and DecodeAll are safe for concurrent use, so one of each is shared by every stream.
+var zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+	e, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	return e
+})
+
+var zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+	d, _ := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecoded), zstd.WithDecoderConcurrency(0))
+	return d
+})
+
+var gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// Parse turns the name of a codec, none, gzip or zstd, into a Compression.
+func Parse(name string) (proto.Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return proto.Compression_COMPRESSION_NONE, nil
+	case "gzip":
+		return proto.Compression_COMPRESSION_GZIP, nil
+	case "zstd":
+		return proto.Compression_COMPRESSION_ZSTD, nil
+	}
+	return proto.Compression_COMPRESSION_NONE, fmt.Errorf("unknown compression %q, use none, gzip or zstd", name)
+}
+
+// Negotiate returns want if it is among the codecs offered by the other side, and no compression otherwise.
+func Negotiate(offered []proto.Compression, want proto.Compression) proto.Compression {
+	for _, c := range offered {
+		if c == want {
+			return want
+		}
+	}
+	return proto.Compression_COMPRESSION_NONE
+}
+
+// Encode compresses data with c and returns the result along with how it ended up encoded. Data that is small, or
+// that c would not make any smaller, is returned as is with no compression.
+func Encode(c proto.Compression, data []byte) ([]byte, proto.Compression) {
+	if len(data) < minSize {
+		return data, proto.Compression_COMPRESSION_NONE
+	}
+
+	var out []byte
+	switch c {
+	case proto.Compression_COMPRESSION_ZSTD:
+		out = zstdEncoder().EncodeAll(data, make([]byte, 0, len(data)/2))
+	case proto.Compression_COMPRESSION_GZIP:
+		var buf bytes.Buffer
+		w := gzipWriters.Get().(*gzip.Writer)
+		w.Reset(&buf)
+		w.Write(data)
+		w.Close()
+		gzipWriters.Put(w)
+		out = buf.Bytes()
+	default:
+		return data, proto.Compression_COMPRESSION_NONE
+	}
+
+	if len(out) >= len(data) {
+		return data, proto.Compression_COMPRESSION_NONE
+	}
+	return out, c
+}
+
+// Decode undoes Encode for data encoded with c.
+func Decode(c proto.Compression, data []byte) ([]byte, error) {
+	switch c {
+	case proto.Compression_COMPRESSION_NONE:
+		return data, nil
+	case proto.Compression_COMPRESSION_ZSTD:
+		return zstdDecoder().DecodeAll(data, nil)
+	case proto.Compression_COMPRESSION_GZIP:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		out, err := io.ReadAll(io.LimitReader(r, maxDecoded+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(out) > maxDecoded {
+			return nil, fmt.Errorf("gzip payload decodes to more than %d bytes", maxDecoded)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported compression %v", c)
+}
@@ -0,0 +1,129 @@
+package commandclient
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	proto "github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors a Client returns for the usual ways a call can go wrong, matched with errors.Is. The error returned keeps the
+// message from the server and its gRPC status.
+var (
+	// The server could not be reached, or went away during the call.
+	ErrConnectionLost = errors.New("connection to the server lost")
+	// The token or the policy of the server does not allow the call.
+	ErrPermissionDenied = errors.New("permission denied")
+	// A path or job named in the call does not exist on the server.
+	ErrNotFound = errors.New("not found on the server")
+)
+
+// CommandFailedError is returned when a remote command ran and exited with a code other than 0.
+type CommandFailedError struct {
+	Code int32
+	// The stderr of the command, when it was run by RemoteCmd.Output without a Stderr of its own.
+	Stderr []byte
+}
+
+func (e *CommandFailedError) Error() string {
+	return fmt.Sprintf("command failed with code %d", e.Code)
+}
+
+// A gRPC error sorted into one of the Err values.
+type remoteError struct {
+	kind error
+	err  error
+}
+
+func (e *remoteError) Error() string {
+	return status.Convert(e.err).Message()
+}
+
+func (e *remoteError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+// Sort a gRPC error into one of the Err values. Anything else, local errors included, comes back as it is.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var kind error
+	switch status.Code(err) {
+	case codes.Unavailable:
+		kind = ErrConnectionLost
+	case codes.PermissionDenied, codes.Unauthenticated:
+		kind = ErrPermissionDenied
+	case codes.NotFound:
+		kind = ErrNotFound
+	default:
+		return err
+	}
+	return &remoteError{kind: kind, err: err}
+}
+
+// The error for a command that exited with code after streaming ended with err.
+func exitError(code int32, err error) error {
+	if err != nil {
+		return classify(err)
+	}
+	if code != 0 {
+		return &CommandFailedError{Code: code}
+	}
+	return nil
+}
+
+// Client talks to a single BitWarp server. Its methods are bound to the context they are given, return the errors above
+// rather than exiting, and can be called from several goroutines at once.
+type Client struct {
+	conn       *grpc.ClientConn
+	command    proto.CommandClient
+	jobs       proto.JobsClient
+	filesystem proto.FilesystemClient
+}
+
+// NewClient returns a Client using conn, for example one made by ConnectToServer or AcceptAgent.
+func NewClient(conn *grpc.ClientConn) *Client {
+	return &Client{
+		conn:       conn,
+		command:    proto.NewCommandClient(conn),
+		jobs:       proto.NewJobsClient(conn),
+		filesystem: proto.NewFilesystemClient(conn),
+	}
+}
+
+// Dial returns a Client for the server at address, connecting as described by ConnectToServer. The connection is made
+// lazily, so an unreachable server shows up as ErrConnectionLost from the first call.
+func Dial(address string, tlsConfig *tls.Config, token string) (*Client, error) {
+	conn, err := ConnectToServer(address, tlsConfig, token)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// Close closes the connection of the Client.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Clients for the package level functions, which take a single service client.
+func commandOnly(client *proto.CommandClient) *Client {
+	return &Client{command: *client}
+}
+
+func jobsOnly(client *proto.JobsClient) *Client {
+	return &Client{jobs: *client}
+}
+
+func filesystemOnly(client *proto.FilesystemClient) *Client {
+	return &Client{filesystem: *client}
+}
@@ -1,17 +1,81 @@
 package commandclient
 
 import (
-	"log"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
 
+	proto "github.com/apoindevster/bitwarp/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
-func ConnectToServer(address string) (*grpc.ClientConn, error) {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// LoadClientTLSConfig builds a mutual TLS configuration for talking to a BitWarp server. The client presents the certificate
+// and key found at certFile and keyFile, and only trusts servers whose certificate is signed by a CA in the caFile bundle.
+func LoadClientTLSConfig(certFile string, keyFile string, caFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("a certificate, key and CA bundle are all required for mutual TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(os.ExpandEnv(certFile), os.ExpandEnv(keyFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client key pair: %w", err)
+	}
+
+	pem, err := os.ReadFile(os.ExpandEnv(caFile))
 	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in CA bundle")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ConnectToServer creates a client connection to the BitWarp server at address. When tlsConfig is nil the connection is made
+// without transport security, otherwise the server certificate is verified against tlsConfig. A non-empty token is sent as
+// a bearer token on every RPC.
+func ConnectToServer(address string, tlsConfig *tls.Config, token string) (*grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(address, dialOptions(tlsConfig, token)...)
+	if err != nil {
+		return nil, fmt.Errorf("did not connect: %w", err)
 	}
 	return conn, nil
 }
+
+// The transport and per-RPC credentials for talking to a server.
+func dialOptions(tlsConfig *tls.Config, token string) []grpc.DialOption {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{token: token, requireTLS: tlsConfig != nil}))
+	}
+	return opts
+}
+
+// ConnectionParams asks the server to identify itself.
+func (c *Client) ConnectionParams(ctx context.Context) (*proto.ConnectionParams, error) {
+	params, err := c.command.GetConnectionParams(ctx, &emptypb.Empty{})
+	return params, classify(err)
+}
+
+// GetConnectionParams calls Client.ConnectionParams without a deadline.
+func GetConnectionParams(client *proto.CommandClient) (*proto.ConnectionParams, error) {
+	return commandOnly(client).ConnectionParams(context.Background())
+}
@@ -0,0 +1,19 @@
+package commandclient
+
+import (
+	"context"
+)
+
+// tokenCredentials attaches a bearer token to every RPC made on a connection.
+type tokenCredentials struct {
+	token      string
+	requireTLS bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.requireTLS
+}
@@ -0,0 +1,220 @@
+package commandclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	proto "github.com/apoindevster/bitwarp/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// ErrStopped is the error of a host that was skipped or killed because another host failed with FanOutOptions.FailFast.
+var ErrStopped = errors.New("stopped after another host failed")
+
+// Target is a host for FanOut to run the command on.
+type Target struct {
+	// Name identifies the host in its output and result, such as the address it was reached through.
+	Name   string
+	Client *Client
+}
+
+// FanOutOptions control how FanOut spreads a command over its targets. The zero value runs on every target at once
+// with no time limit and discards the output.
+type FanOutOptions struct {
+	// The most hosts to run the command on at the same time, or 0 for all of them.
+	Concurrency int
+	// How long the command may run on each host before it is killed, or 0 for no limit.
+	Timeout time.Duration
+	// Stop as soon as a host fails, killing the command on the hosts it is running on and skipping the rest.
+	FailFast bool
+	// Returns where the stdout and stderr of a host go, or nil for either to discard it. Writers with a Flush() error
+	// method are flushed once the host is done. See PrefixOutput.
+	Output func(name string) (stdout io.Writer, stderr io.Writer)
+	// Called from the goroutine of each host once it is done, in the order they finish.
+	Done func(result HostResult)
+}
+
+// HostResult is how the command went on one host.
+type HostResult struct {
+	Name string
+	// The exit code of the command, or -1 when it did not run to the end.
+	Code     int32
+	Duration time.Duration
+	// Nil when the command exited with code 0. Otherwise a *CommandFailedError, ErrStopped, an error wrapping
+	// context.DeadlineExceeded when the command timed out, or why it could not run, such as ErrConnectionLost.
+	Err error
+}
+
+// Status sums up the result in a word or two: ok, failed, timed out, stopped, cancelled or error.
+func (r HostResult) Status() string {
+	var failed *CommandFailedError
+	switch {
+	case r.Err == nil:
+		return "ok"
+	case errors.As(r.Err, &failed):
+		return "failed"
+	case errors.Is(r.Err, context.DeadlineExceeded):
+		return "timed out"
+	case errors.Is(r.Err, ErrStopped):
+		return "stopped"
+	case errors.Is(r.Err, context.Canceled):
+		return "cancelled"
+	default:
+		return "error"
+	}
+}
+
+// FanOut runs the command described by options on every target and returns how it went on each, in the order of
+// targets. Nothing is sent to the stdin of the commands. Cancelling ctx kills the command everywhere it is running.
+// fanOptions can be nil.
+func FanOut(ctx context.Context, targets []Target, options *proto.RunExecutableOptions, fanOptions *FanOutOptions) []HostResult {
+	if fanOptions == nil {
+		fanOptions = &FanOutOptions{}
+	}
+	concurrency := fanOptions.Concurrency
+	if concurrency <= 0 || concurrency > len(targets) {
+		concurrency = len(targets)
+	}
+
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	results := make([]HostResult, len(targets))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			// Hosts that never started get whatever stopped the fan-out, ErrStopped or the error of the caller's ctx.
+			results[i] = HostResult{Name: target.Name, Code: -1, Err: context.Cause(ctx)}
+			if fanOptions.Done != nil {
+				fanOptions.Done(results[i])
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = runTarget(ctx, target, options, fanOptions)
+			if results[i].Err != nil && fanOptions.FailFast {
+				stop(ErrStopped)
+			}
+			if fanOptions.Done != nil {
+				fanOptions.Done(results[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Run the command on a single target.
+func runTarget(parent context.Context, target Target, options *proto.RunExecutableOptions, fanOptions *FanOutOptions) HostResult {
+	ctx, cancel := parent, context.CancelFunc(func() {})
+	if fanOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, fanOptions.Timeout)
+	}
+	defer cancel()
+
+	cmd := target.Client.Command(ctx, options.GetCommand())
+	// Each host gets its own copy, since Start fills in Stdin.
+	cmd.Options = protobuf.Clone(options).(*proto.RunExecutableOptions)
+	if fanOptions.Output != nil {
+		cmd.Stdout, cmd.Stderr = fanOptions.Output(target.Name)
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	result := HostResult{Name: target.Name, Duration: time.Since(start), Err: err}
+	flush(cmd.Stdout)
+	flush(cmd.Stderr)
+
+	var failed *CommandFailedError
+	switch {
+	case err == nil:
+	case errors.As(err, &failed):
+		result.Code = failed.Code
+	case errors.Is(context.Cause(parent), ErrStopped):
+		result.Code, result.Err = -1, ErrStopped
+	case parent.Err() != nil:
+		result.Code, result.Err = -1, context.Cause(parent)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Code, result.Err = -1, fmt.Errorf("timed out after %s: %w", fanOptions.Timeout, context.DeadlineExceeded)
+	default:
+		result.Code = -1
+	}
+	return result
+}
+
+func flush(w io.Writer) {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+}
+
+// PrefixOutput returns a FanOutOptions.Output writing each line of every host to stdout or stderr behind the name of
+// the host, the way pdsh does. Lines are written whole, so lines from different hosts never run into each other.
+func PrefixOutput(stdout io.Writer, stderr io.Writer) func(name string) (io.Writer, io.Writer) {
+	var mu sync.Mutex
+	return func(name string) (io.Writer, io.Writer) {
+		prefix := []byte(name + ": ")
+		return &prefixWriter{mu: &mu, w: stdout, prefix: prefix}, &prefixWriter{mu: &mu, w: stderr, prefix: prefix}
+	}
+}
+
+// Holds on to a partial line until the rest of it arrives, or until Flush.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+	end := bytes.LastIndexByte(p.buf, '\n')
+	if end < 0 {
+		return len(data), nil
+	}
+	err := p.writeLines(p.buf[:end+1])
+	p.buf = append(p.buf[:0], p.buf[end+1:]...)
+	return len(data), err
+}
+
+// Flush writes what is left of the last line, ending it with a newline.
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	err := p.writeLines(append(p.buf, '\n'))
+	p.buf = p.buf[:0]
+	return err
+}
+
+func (p *prefixWriter) writeLines(lines []byte) error {
+	if p.w == nil {
+		return nil
+	}
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(lines, []byte("\n")) {
+		if len(line) > 0 {
+			out.Write(p.prefix)
+			out.Write(line)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,153 @@
+package commandclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FileUploadFrom copies everything read from r until io.EOF to destPath on the server, where it is checked against
+// the SHA-256 of what was read. Unlike FileUpload, an interrupted upload is not retried, since r cannot be read a
+// second time. Progress is called with a total of 0, as the size is not known until r runs out. options can be nil.
+func (c *Client) FileUploadFrom(ctx context.Context, r io.Reader, destPath string, options *TransferOptions) (*TransferResult, error) {
+	if options == nil {
+		options = &TransferOptions{}
+	}
+	compression := options.Compression
+	if compression != proto.Compression_COMPRESSION_NONE {
+		params, _ := c.ConnectionParams(ctx)
+		compression = codec.Negotiate(params.GetCompressions(), compression)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.command.FileUpload(ctx)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	h := sha256.New()
+	result := &TransferResult{}
+	buf := make([]byte, chunkSize)
+	var pos int64
+	for {
+		n, rerr := io.ReadFull(r, buf)
+		if rerr != nil && !errors.Is(rerr, io.EOF) && !errors.Is(rerr, io.ErrUnexpectedEOF) {
+			return nil, rerr
+		}
+
+		// The last chunk carries the checksum, and is sent even when it is empty.
+		h.Write(buf[:n])
+		data, used := codec.Encode(compression, buf[:n])
+		chunk := &proto.FileChunk{Path: destPath, Chunk: data, Offset: pos, Compression: used}
+		pos += int64(n)
+		last := rerr != nil
+		if last {
+			chunk.Size, chunk.Sha256 = pos, h.Sum(nil)
+		}
+
+		if err := stream.Send(chunk); err != nil {
+			// The real reason the stream broke comes back from CloseAndRecv.
+			break
+		}
+		result.Transferred += int64(n)
+		result.Wire += int64(len(data))
+		if options.Progress != nil {
+			options.Progress(pos, 0)
+		}
+		if last {
+			break
+		}
+	}
+
+	stored, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, classify(err)
+	}
+	if stored.GetSize() != pos || !bytes.Equal(stored.GetSha256(), h.Sum(nil)) {
+		return nil, classify(status.Errorf(codes.DataLoss, "server stored %d bytes with checksum %x, expected %d bytes with %x", stored.GetSize(), stored.GetSha256(), pos, h.Sum(nil)))
+	}
+	result.Size, result.Sha256 = pos, stored.GetSha256()
+	return result, nil
+}
+
+// FileDownloadTo writes the file srcPath on the server to w. Transient failures are retried, picking up after what was
+// already written as long as the file has not changed on the server. The SHA-256 of the file can only be checked once
+// all of it has been written, so w may already hold bad data when a DataLoss error comes back. options can be nil.
+func (c *Client) FileDownloadTo(ctx context.Context, srcPath string, w io.Writer, options *TransferOptions) (*TransferResult, error) {
+	if options == nil {
+		options = &TransferOptions{}
+	}
+	h := sha256.New()
+	result := &TransferResult{}
+	var expected []byte
+	err := retry(ctx, func() error {
+		var err error
+		expected, err = c.downloadToAttempt(ctx, srcPath, w, h, options, result)
+		return err
+	})
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	if sum := h.Sum(nil); !bytes.Equal(sum, expected) {
+		return nil, status.Errorf(codes.DataLoss, "checksum mismatch downloading %s: got %x, expected %x", srcPath, sum, expected)
+	}
+	result.Size, result.Sha256 = result.Transferred, expected
+	return result, nil
+}
+
+// Stream srcPath into w from where earlier attempts left off, which h and result keep track of. It returns the SHA-256
+// the server sent with the last chunk.
+func (c *Client) downloadToAttempt(parent context.Context, srcPath string, w io.Writer, h hash.Hash, options *TransferOptions, result *TransferResult) ([]byte, error) {
+	pos := result.Transferred
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	stream, err := c.command.FileDownload(ctx, &proto.FileChunk{Path: srcPath, Offset: pos, Sha256: h.Sum(nil), Compression: options.Compression})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		m, err := stream.Recv()
+		if err == io.EOF {
+			// The last chunk carries the checksum, so the stream ending without it means it was cut short.
+			return nil, status.Errorf(codes.Unavailable, "download of %s ended early", srcPath)
+		} else if err != nil {
+			return nil, err
+		}
+
+		if m.GetOffset() != pos {
+			// What was written cannot be taken back, so there is no starting over when the server cannot resume.
+			return nil, status.Errorf(codes.FailedPrecondition, "%s changed on the server during the download", srcPath)
+		}
+		data, err := codec.Decode(m.GetCompression(), m.GetChunk())
+		if err != nil {
+			return nil, status.Errorf(codes.DataLoss, "failed to decode chunk at offset %d: %v", m.GetOffset(), err)
+		}
+		n, err := w.Write(data)
+		h.Write(data[:n])
+		pos += int64(n)
+		result.Transferred += int64(n)
+		result.Wire += int64(len(m.GetChunk()))
+		if err != nil {
+			return nil, err
+		}
+		if options.Progress != nil {
+			options.Progress(pos, m.GetSize())
+		}
+
+		if m.GetSha256() != nil {
+			return m.GetSha256(), nil
+		}
+	}
+}
@@ -1,109 +1,332 @@
 package commandclient
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
-	"log"
 	"os"
+	"time"
 
+	"github.com/apoindevster/bitwarp/codec"
 	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// Size of the chunks files are sent in.
+const chunkSize = 1024 * 1000
+
+// How many times a transfer is attempted before giving up on transient errors.
+const transferAttempts = 5
+
+// Downloads are written next to their destination under this suffix, and only renamed into place once the whole file
+// has arrived and its checksum matches. An interrupted download leaves the part file behind to be resumed.
+const partSuffix = ".bitwarp-part"
+
+// Errors worth retrying a transfer for. Everything else, like a denied or missing path, fails straight away.
+func transient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// Run op until it succeeds, fails for good, runs out of attempts or ctx is cancelled, backing off between attempts.
+func retry(ctx context.Context, op func() error) error {
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || !transient(err) || attempt == transferAttempts || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		backoff *= 2
+	}
+}
+
+// Hash the first n bytes of f.
+func hashPrefix(f *os.File, n int64) (hash.Hash, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, n)); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// TransferOptions tune a single file transfer.
+type TransferOptions struct {
+	// Codec to compress the file with on the wire. Uploads fall back to no compression when the server does not offer
+	// it, and so does the server for downloads.
+	Compression proto.Compression
+	// Called as the transfer goes with how many bytes of the file are in place, counting whatever was resumed, out of
+	// its whole size. It runs on the goroutine doing the transfer, so it should return quickly.
+	Progress func(done int64, total int64)
+	// Cancelling Context stops a transfer started by one of the package level WithOptions functions, leaving a partial
+	// file download to be resumed. Nil lets it run to the end. The Client methods take their context as an argument instead.
+	Context context.Context
+}
+
+func (o *TransferOptions) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// TransferResult describes a finished file transfer.
+type TransferResult struct {
+	// Size and SHA-256 of the whole file.
+	Size   int64
+	Sha256 []byte
+	// Bytes of the file sent by this transfer, which leaves out whatever was resumed from an earlier one, and how many
+	// bytes they took up on the wire.
+	Transferred int64
+	Wire        int64
+}
+
+// Ratio is how many times smaller compression made the transfer, 1 when nothing was compressed.
+func (r *TransferResult) Ratio() float64 {
+	if r.Wire == 0 {
+		return 1
+	}
+	return float64(r.Transferred) / float64(r.Wire)
+}
+
+// FileDownload copies srcPath on the server to destPath. Transient failures are retried, picking up from whatever
+// already arrived, and the result is checked against the server's SHA-256 of the file.
 func FileDownload(srcPath string, destPath string, client *proto.CommandClient) error {
-	var f *os.File = nil
-	var w *bufio.Writer = nil
+	_, err := FileDownloadWithOptions(srcPath, destPath, &TransferOptions{}, client)
+	return err
+}
+
+// FileDownloadWithOptions is FileDownload tuned by options, reporting what was transferred.
+func FileDownloadWithOptions(srcPath string, destPath string, options *TransferOptions, client *proto.CommandClient) (*TransferResult, error) {
+	return commandOnly(client).FileDownload(options.context(), srcPath, destPath, options)
+}
+
+// FileDownload copies srcPath on the server to destPath as the package level FileDownloadWithOptions does, for as long
+// as ctx allows. options can be nil.
+func (c *Client) FileDownload(ctx context.Context, srcPath string, destPath string, options *TransferOptions) (*TransferResult, error) {
+	if options == nil {
+		options = &TransferOptions{}
+	}
+	f, err := os.OpenFile(destPath+partSuffix, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := &TransferResult{}
+	err = retry(ctx, func() error {
+		return c.downloadAttempt(ctx, srcPath, f, options.Compression, options.Progress, result)
+	})
+	if err != nil {
+		// Keep a partial download around to resume, but not an empty one.
+		if info, serr := f.Stat(); serr == nil && info.Size() == 0 {
+			os.Remove(destPath + partSuffix)
+		}
+		return nil, classify(err)
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return result, os.Rename(destPath+partSuffix, destPath)
+}
 
-	ctx := context.Background()
-	stream, err := (*client).FileDownload(ctx, &proto.FileChunk{Path: srcPath})
+func (c *Client) downloadAttempt(parent context.Context, srcPath string, f *os.File, compression proto.Compression, progress func(int64, int64), result *TransferResult) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	pos := info.Size()
+	h, err := hashPrefix(f, pos)
 	if err != nil {
-		fmt.Printf("File Upload failed to start with error: %v\n", err)
 		return err
 	}
 
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	stream, err := c.command.FileDownload(ctx, &proto.FileChunk{Path: srcPath, Offset: pos, Sha256: h.Sum(nil), Compression: compression})
+	if err != nil {
+		return err
+	}
+
+	first := true
 	for {
 		m, err := stream.Recv()
-
 		if err == io.EOF {
-			return nil
+			// The last chunk carries the checksum, so the stream ending without it means it was cut short.
+			return status.Errorf(codes.Unavailable, "download of %s ended early", srcPath)
 		} else if err != nil {
-			fmt.Printf("Failed file upload with err: %v\n", err)
 			return err
 		}
 
-		// We have a message
-		if f == nil {
-			f, err = os.Create(destPath)
-			if err != nil {
-				fmt.Printf("Failed to create file with error %v", err)
+		if first && m.GetOffset() != pos {
+			// The server could not resume from what we have, so start over.
+			if err := f.Truncate(0); err != nil {
 				return err
 			}
-			w = bufio.NewWriter(f)
-			defer w.Flush()
+			pos = 0
+			h.Reset()
 		}
+		first = false
 
-		_, err = w.Write(m.GetChunk())
+		if m.GetOffset() != pos {
+			return status.Errorf(codes.DataLoss, "chunk for offset %d arrived at offset %d", m.GetOffset(), pos)
+		}
+		data, err := codec.Decode(m.GetCompression(), m.GetChunk())
 		if err != nil {
-			fmt.Printf("Failed to write data to file upload: %v", err)
+			return status.Errorf(codes.DataLoss, "failed to decode chunk at offset %d: %v", m.GetOffset(), err)
+		}
+		if _, err := f.WriteAt(data, pos); err != nil {
 			return err
 		}
+		h.Write(data)
+		pos += int64(len(data))
+		result.Transferred += int64(len(data))
+		result.Wire += int64(len(m.GetChunk()))
+		if progress != nil {
+			progress(pos, m.GetSize())
+		}
+
+		if m.GetSha256() != nil {
+			if sum := h.Sum(nil); !bytes.Equal(sum, m.GetSha256()) {
+				// Start the next attempt from scratch rather than resuming on top of corrupt data.
+				f.Truncate(0)
+				return status.Errorf(codes.DataLoss, "checksum mismatch downloading %s: got %x, expected %x", srcPath, sum, m.GetSha256())
+			}
+			result.Size, result.Sha256 = pos, m.GetSha256()
+			return f.Truncate(pos)
+		}
 	}
 }
 
+// FileUpload copies srcPath to destPath on the server. Transient failures are retried, picking up from whatever the
+// server already received, and the server checks the result against the SHA-256 of the local file.
 func FileUpload(srcPath string, destPath string, client *proto.CommandClient) error {
+	_, err := FileUploadWithOptions(srcPath, destPath, &TransferOptions{}, client)
+	return err
+}
+
+// FileUploadWithOptions is FileUpload tuned by options, reporting what was transferred.
+func FileUploadWithOptions(srcPath string, destPath string, options *TransferOptions, client *proto.CommandClient) (*TransferResult, error) {
+	return commandOnly(client).FileUpload(options.context(), srcPath, destPath, options)
+}
+
+// FileUpload copies srcPath to destPath on the server as the package level FileUploadWithOptions does, for as long as
+// ctx allows. options can be nil.
+func (c *Client) FileUpload(ctx context.Context, srcPath string, destPath string, options *TransferOptions) (*TransferResult, error) {
+	if options == nil {
+		options = &TransferOptions{}
+	}
 	expPath := os.ExpandEnv(srcPath)
 
 	info, err := os.Stat(expPath)
 	if err != nil {
-		fmt.Printf("Failed to stat file with error: %v\n", err)
-		return err
+		return nil, err
 	}
-
 	if info.IsDir() {
-		fmt.Printf("Path provided is a directory... Please provide a file path\n")
-		return os.ErrNotExist
+		return nil, fmt.Errorf("%s is a directory, not a file", expPath)
 	}
 
-	ctx := context.Background()
-	stream, err := (*client).FileUpload(ctx)
+	f, err := os.Open(expPath)
 	if err != nil {
-		fmt.Printf("File Upload failed to start with error: %v\n", err)
-		return err
+		return nil, err
 	}
-	defer stream.CloseSend()
+	defer f.Close()
 
-	var size int32 = 1024 * 1000
-	rem := info.Size()
+	// The server has to be able to decode what we send, so only compress with a codec it offers. Servers that predate
+	// compression, or that fail to answer, offer none.
+	compression := options.Compression
+	if compression != proto.Compression_COMPRESSION_NONE {
+		params, _ := c.ConnectionParams(ctx)
+		compression = codec.Negotiate(params.GetCompressions(), compression)
+	}
 
-	f, err := os.Open(expPath)
+	result := &TransferResult{}
+	err = retry(ctx, func() error {
+		return c.uploadAttempt(ctx, f, info.Size(), destPath, compression, options.Progress, result)
+	})
 	if err != nil {
-		log.Fatalf("Failed to open file with error: %v", err)
-		return err
+		return nil, classify(err)
 	}
+	return result, nil
+}
 
-	for rem > 0 {
-		if rem < int64(size) {
-			size = int32(rem)
-		}
+func (c *Client) uploadAttempt(parent context.Context, f *os.File, size int64, destPath string, compression proto.Compression, progress func(int64, int64), result *TransferResult) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
 
-		fbytes := make([]byte, size)
+	// Resume from what the server already has, as long as it is the start of this file.
+	remote, err := c.command.FileUploadStatus(ctx, &proto.FileChunk{Path: destPath})
+	if err != nil {
+		return err
+	}
+	pos := remote.GetSize()
+	if pos > size {
+		pos = 0
+	}
+	h, err := hashPrefix(f, pos)
+	if err != nil {
+		return err
+	}
+	if pos > 0 && !bytes.Equal(h.Sum(nil), remote.GetSha256()) {
+		pos = 0
+		h.Reset()
+	}
 
-		_, err := io.ReadAtLeast(f, fbytes, int(size))
+	stream, err := c.command.FileUpload(ctx)
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
-			fmt.Printf("Failed to read at least %d bytes: %v\n", size, err)
+	// Always send at least one chunk so an empty file still carries its checksum.
+	buf := make([]byte, chunkSize)
+	for first := true; pos < size || first; first = false {
+		n, err := f.ReadAt(buf[:min(chunkSize, size-pos)], pos)
+		if errors.Is(err, io.EOF) {
+			// The file shrank while it was being read.
+			size = pos + int64(n)
+		} else if err != nil {
 			return err
 		}
 
-		err = stream.Send(&proto.FileChunk{Path: destPath, Chunk: fbytes})
-		if err != nil {
-			fmt.Printf("Failed to send file chunk with error: %v\n", err)
-			return err
+		h.Write(buf[:n])
+		data, used := codec.Encode(compression, buf[:n])
+		chunk := &proto.FileChunk{Path: destPath, Chunk: data, Offset: pos, Size: size, Compression: used}
+		pos += int64(n)
+		if pos == size {
+			chunk.Sha256 = h.Sum(nil)
 		}
 
-		rem = rem - int64(size)
+		if err := stream.Send(chunk); err != nil {
+			// The real reason the stream broke comes back from CloseAndRecv.
+			break
+		}
+		result.Transferred += int64(n)
+		result.Wire += int64(len(data))
+		if progress != nil {
+			progress(pos, size)
+		}
+	}
+
+	stored, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if stored.GetSize() != size || !bytes.Equal(stored.GetSha256(), h.Sum(nil)) {
+		return status.Errorf(codes.DataLoss, "server stored %d bytes with checksum %x, expected %d bytes with %x", stored.GetSize(), stored.GetSha256(), size, h.Sum(nil))
 	}
-	stream.CloseAndRecv()
+	result.Size, result.Sha256 = size, stored.GetSha256()
 	return nil
 }
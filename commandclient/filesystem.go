@@ -0,0 +1,135 @@
+package commandclient
+
+import (
+	"context"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// Stat describes path on the server. A symlink is described as a link unless follow is set, in which case what it
+// points to is described instead.
+func (c *Client) Stat(ctx context.Context, path string, follow bool) (*proto.FileInfo, error) {
+	info, err := c.filesystem.Stat(ctx, &proto.StatRequest{Path: path, Follow: follow})
+	return info, classify(err)
+}
+
+// Stat calls Client.Stat without a deadline.
+func Stat(path string, follow bool, client *proto.FilesystemClient) (*proto.FileInfo, error) {
+	return filesystemOnly(client).Stat(context.Background(), path, follow)
+}
+
+// ListDir returns every entry of the directory path on the server, sorted by name, fetching as many pages as it takes.
+func (c *Client) ListDir(ctx context.Context, path string) ([]*proto.FileInfo, error) {
+	var entries []*proto.FileInfo
+	token := ""
+	for {
+		page, err := c.ListDirPage(ctx, path, 0, token)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page.GetEntries()...)
+		token = page.GetNextPageToken()
+		if token == "" {
+			return entries, nil
+		}
+	}
+}
+
+// ListDir calls Client.ListDir without a deadline.
+func ListDir(path string, client *proto.FilesystemClient) ([]*proto.FileInfo, error) {
+	return filesystemOnly(client).ListDir(context.Background(), path)
+}
+
+// ListDirPage returns up to pageSize entries of the directory path on the server, starting after the page that
+// returned pageToken. pageSize 0 lets the server pick.
+func (c *Client) ListDirPage(ctx context.Context, path string, pageSize int32, pageToken string) (*proto.ListDirResponse, error) {
+	page, err := c.filesystem.ListDir(ctx, &proto.ListDirRequest{Path: path, PageSize: pageSize, PageToken: pageToken})
+	return page, classify(err)
+}
+
+// ListDirPage calls Client.ListDirPage without a deadline.
+func ListDirPage(path string, pageSize int32, pageToken string, client *proto.FilesystemClient) (*proto.ListDirResponse, error) {
+	return filesystemOnly(client).ListDirPage(context.Background(), path, pageSize, pageToken)
+}
+
+// Mkdir creates the directory path on the server with mode, 0755 when mode is 0. With parents missing parents are
+// created too and an existing directory is not an error.
+func (c *Client) Mkdir(ctx context.Context, path string, mode uint32, parents bool) (*proto.FileInfo, error) {
+	info, err := c.filesystem.Mkdir(ctx, &proto.MkdirRequest{Path: path, Mode: mode, Parents: parents})
+	return info, classify(err)
+}
+
+// Mkdir calls Client.Mkdir without a deadline.
+func Mkdir(path string, mode uint32, parents bool, client *proto.FilesystemClient) (*proto.FileInfo, error) {
+	return filesystemOnly(client).Mkdir(context.Background(), path, mode, parents)
+}
+
+// Remove deletes path on the server. A directory has to be empty unless recursive is set.
+func (c *Client) Remove(ctx context.Context, path string, recursive bool) error {
+	_, err := c.filesystem.Remove(ctx, &proto.RemoveRequest{Path: path, Recursive: recursive})
+	return classify(err)
+}
+
+// Remove calls Client.Remove without a deadline.
+func Remove(path string, recursive bool, client *proto.FilesystemClient) error {
+	return filesystemOnly(client).Remove(context.Background(), path, recursive)
+}
+
+// Rename moves oldPath to newPath on the server and returns the entry at its new path.
+func (c *Client) Rename(ctx context.Context, oldPath string, newPath string) (*proto.FileInfo, error) {
+	info, err := c.filesystem.Rename(ctx, &proto.RenameRequest{OldPath: oldPath, NewPath: newPath})
+	return info, classify(err)
+}
+
+// Rename calls Client.Rename without a deadline.
+func Rename(oldPath string, newPath string, client *proto.FilesystemClient) (*proto.FileInfo, error) {
+	return filesystemOnly(client).Rename(context.Background(), oldPath, newPath)
+}
+
+// Chmod sets the mode of path on the server, as chmod does with an octal mode.
+func (c *Client) Chmod(ctx context.Context, path string, mode uint32) (*proto.FileInfo, error) {
+	info, err := c.filesystem.Chmod(ctx, &proto.ChmodRequest{Path: path, Mode: mode})
+	return info, classify(err)
+}
+
+// Chmod calls Client.Chmod without a deadline.
+func Chmod(path string, mode uint32, client *proto.FilesystemClient) (*proto.FileInfo, error) {
+	return filesystemOnly(client).Chmod(context.Background(), path, mode)
+}
+
+// Chown sets the owner and group of path on the server. Either can be a name or a numeric id, or empty to leave it as
+// it is.
+func (c *Client) Chown(ctx context.Context, path string, owner string, group string) (*proto.FileInfo, error) {
+	info, err := c.filesystem.Chown(ctx, &proto.ChownRequest{Path: path, Owner: owner, Group: group})
+	return info, classify(err)
+}
+
+// Chown calls Client.Chown without a deadline.
+func Chown(path string, owner string, group string, client *proto.FilesystemClient) (*proto.FileInfo, error) {
+	return filesystemOnly(client).Chown(context.Background(), path, owner, group)
+}
+
+// Symlink creates a symlink at path on the server pointing to target.
+func (c *Client) Symlink(ctx context.Context, target string, path string) (*proto.FileInfo, error) {
+	info, err := c.filesystem.Symlink(ctx, &proto.SymlinkRequest{Target: target, Path: path})
+	return info, classify(err)
+}
+
+// Symlink calls Client.Symlink without a deadline.
+func Symlink(target string, path string, client *proto.FilesystemClient) (*proto.FileInfo, error) {
+	return filesystemOnly(client).Symlink(context.Background(), target, path)
+}
+
+// Readlink returns what the symlink path on the server points to.
+func (c *Client) Readlink(ctx context.Context, path string) (string, error) {
+	info, err := c.filesystem.Readlink(ctx, &proto.PathRequest{Path: path})
+	if err != nil {
+		return "", classify(err)
+	}
+	return info.GetLinkTarget(), nil
+}
+
+// Readlink calls Client.Readlink without a deadline.
+func Readlink(path string, client *proto.FilesystemClient) (string, error) {
+	return filesystemOnly(client).Readlink(context.Background(), path)
+}
@@ -3,16 +3,27 @@ module commandclient
 go 1.23.2
 
 require (
-	google.golang.org/grpc v1.73.0
 	github.com/apoindevster/bitwarp v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/codec v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/delta v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/tree v0.0.0-unpublished
+	github.com/hashicorp/yamux v0.1.2
+	google.golang.org/grpc v1.73.0
+	google.golang.org/protobuf v1.36.6
 )
 
 require (
+	github.com/klauspost/compress v1.18.0 // indirect
 	golang.org/x/net v0.38.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )
 
 replace github.com/apoindevster/bitwarp => ../
+
+replace github.com/apoindevster/bitwarp/tree => ../tree
+
+replace github.com/apoindevster/bitwarp/codec => ../codec
+
+replace github.com/apoindevster/bitwarp/delta => ../delta
@@ -0,0 +1,33 @@
+package commandclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// HashFile hashes path on the server with each of algorithms, SHA-256 when there are none, without transferring it. A
+// file gives back a single result. A directory gives back one result per regular file under it with its relative path.
+func (c *Client) HashFile(ctx context.Context, path string, algorithms []proto.HashAlgorithm) ([]*proto.FileHash, error) {
+	stream, err := c.command.HashFile(ctx, &proto.HashRequest{Path: path, Algorithms: algorithms})
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	var results []*proto.FileHash
+	for {
+		m, err := stream.Recv()
+		if err == io.EOF {
+			return results, nil
+		} else if err != nil {
+			return nil, classify(err)
+		}
+		results = append(results, m)
+	}
+}
+
+// HashFile calls Client.HashFile without a deadline.
+func HashFile(path string, algorithms []proto.HashAlgorithm, client *proto.CommandClient) ([]*proto.FileHash, error) {
+	return commandOnly(client).HashFile(context.Background(), path, algorithms)
+}
@@ -0,0 +1,58 @@
+package commandclient
+
+import (
+	"context"
+
+	proto "github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// ListJobs calls Client.ListJobs without a deadline.
+func ListJobs(client *proto.JobsClient) ([]*proto.JobInfo, error) {
+	return jobsOnly(client).ListJobs(context.Background())
+}
+
+// ListJobs returns the caller's jobs on the server, running and recently finished.
+func (c *Client) ListJobs(ctx context.Context) ([]*proto.JobInfo, error) {
+	list, err := c.jobs.ListJobs(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, classify(err)
+	}
+	return list.GetJobs(), nil
+}
+
+// AttachJob replays the buffered output of a job into dataChan and keeps streaming it until the job finishes or dataChan
+// detaches. It returns the exit code of the job.
+func AttachJob(jobId string, dataChan *ExecutableDataChan, client *proto.JobsClient) int32 {
+	return AttachJobContext(context.Background(), jobId, dataChan, client)
+}
+
+// AttachJobContext is AttachJob bound to ctx. Cancelling ctx stops streaming without affecting the job.
+func AttachJobContext(ctx context.Context, jobId string, dataChan *ExecutableDataChan, client *proto.JobsClient) int32 {
+	return exitCode(jobsOnly(client).AttachJob(ctx, jobId, dataChan), dataChan)
+}
+
+// AttachJob replays the buffered output of a job into dataChan and keeps streaming it until the job finishes or dataChan
+// detaches. Its exit code comes back as for Run. Cancelling ctx stops streaming without affecting the job.
+func (c *Client) AttachJob(ctx context.Context, jobId string, dataChan *ExecutableDataChan) error {
+	stream, err := c.jobs.AttachJob(ctx)
+	if err != nil {
+		return classify(err)
+	}
+
+	return exitError(streamExecutable(stream, &proto.RunExecutableInput{JobId: jobId}, dataChan))
+}
+
+// SignalJob calls Client.SignalJob without a deadline.
+func SignalJob(jobId string, signal proto.Signal, processGroup bool, client *proto.JobsClient) error {
+	return jobsOnly(client).SignalJob(context.Background(), jobId, signal, processGroup)
+}
+
+// SignalJob sends a signal to a job, or to its whole process group when processGroup is set.
+func (c *Client) SignalJob(ctx context.Context, jobId string, signal proto.Signal, processGroup bool) error {
+	_, err := c.jobs.SignalJob(ctx, &proto.JobSignal{
+		JobId:  jobId,
+		Signal: &proto.SendSignal{Signal: signal, ProcessGroup: processGroup},
+	})
+	return classify(err)
+}
@@ -0,0 +1,221 @@
+package commandclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	proto "github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc"
+)
+
+// RemoteCmd is a command run on the server, set up and run the way an exec.Cmd is.
+type RemoteCmd struct {
+	// Options describe the command. Client.Command fills in Command and Args, and anything else, like Cwd, Env or
+	// Timeout, can be set before Start. Start sets Options.Stdin according to Stdin.
+	Options *proto.RunExecutableOptions
+
+	// Stdin is sent to the command until it returns io.EOF, at which point the command sees the end of its input. When
+	// Stdin is nil the command reads from an empty stdin. Wait does not wait for a read from Stdin that is still blocked
+	// once the command has exited.
+	Stdin io.Reader
+	// Stdout and Stderr receive the output of the command, or it is discarded when they are nil. They are written from a
+	// single goroutine, so they can be the same writer.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// JobId is the ID of the server job running the command, set by Start.
+	JobId string
+
+	client *Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	stream grpc.BidiStreamingClient[proto.RunExecutableInput, proto.RunExecutableResult]
+
+	// Closed once the output has been streamed, after which code and err hold how the command ended.
+	done chan struct{}
+	code int32
+	err  error
+
+	// The first error reading Stdin, reported by Wait when the command itself succeeded.
+	stdinMu  sync.Mutex
+	stdinErr error
+
+	waited bool
+}
+
+// Command returns a RemoteCmd to run name with args on the server. Cancelling ctx before the command finishes kills it
+// along with every process it started.
+func (c *Client) Command(ctx context.Context, name string, args ...string) *RemoteCmd {
+	return &RemoteCmd{
+		Options: &proto.RunExecutableOptions{Command: name, Args: args},
+		client:  c,
+		ctx:     ctx,
+	}
+}
+
+// Start starts the command without waiting for it to finish. It fails if the server refuses to run it.
+func (r *RemoteCmd) Start() error {
+	if r.stream != nil {
+		return errors.New("commandclient: command already started")
+	}
+	r.Options.Stdin = r.Stdin != nil
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	stream, err := r.client.command.RunExecutable(ctx)
+	if err != nil {
+		cancel()
+		return classify(err)
+	}
+	if err := stream.Send(&proto.RunExecutableInput{Options: r.Options}); err != nil && !errors.Is(err, io.EOF) {
+		cancel()
+		return classify(err)
+	}
+
+	// The server answers with the job ID first, or with why the command was refused.
+	first, err := stream.Recv()
+	if err != nil {
+		cancel()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("commandclient: %s ended before it started", r.Options.GetCommand())
+		}
+		return classify(err)
+	}
+
+	r.stream, r.cancel = stream, cancel
+	r.JobId = first.GetJobId()
+	r.done = make(chan struct{})
+	if r.Stdin != nil {
+		go r.sendStdin()
+	} else {
+		stream.CloseSend()
+	}
+	go r.receive(first)
+	return nil
+}
+
+// Send Stdin to the command until it runs out, then close the sending side so the command sees the end of its input.
+func (r *RemoteCmd) sendStdin() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Stdin.Read(buf)
+		if n > 0 {
+			input := make([]byte, n)
+			copy(input, buf[:n])
+			if serr := r.stream.Send(&proto.RunExecutableInput{Stdin: input}); serr != nil {
+				// The command is gone, so whatever is left of Stdin has nowhere to go.
+				return
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				r.stdinMu.Lock()
+				r.stdinErr = err
+				r.stdinMu.Unlock()
+			}
+			r.stream.CloseSend()
+			return
+		}
+	}
+}
+
+// Write the output of the command to Stdout and Stderr until it finishes.
+func (r *RemoteCmd) receive(m *proto.RunExecutableResult) {
+	defer close(r.done)
+	for {
+		r.code = m.GetReturnCode()
+		stdout, stderr, err := decodeOutput(m)
+		if err == nil && stdout != nil && r.Stdout != nil {
+			_, err = r.Stdout.Write(stdout)
+		}
+		if err == nil && stderr != nil && r.Stderr != nil {
+			_, err = r.Stderr.Write(stderr)
+		}
+		if err != nil {
+			// Like a command writing to a closed pipe, stop the command rather than lose its output silently.
+			r.err = err
+			r.cancel()
+			return
+		}
+
+		m, err = r.stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return
+		} else if err != nil {
+			r.err = classify(err)
+			return
+		}
+	}
+}
+
+// Wait waits for a started command to finish and for its output to be written. It returns nil when the command exits
+// with code 0, a *CommandFailedError when it exits with any other code, and otherwise why the command could not be
+// followed to the end, such as ErrConnectionLost, a failure writing its output or an error reading Stdin.
+func (r *RemoteCmd) Wait() error {
+	if r.stream == nil {
+		return errors.New("commandclient: command not started")
+	}
+	if r.waited {
+		return errors.New("commandclient: Wait was already called")
+	}
+	r.waited = true
+
+	<-r.done
+	r.cancel()
+	if r.err != nil {
+		return r.err
+	}
+	if err := exitError(r.code, nil); err != nil {
+		return err
+	}
+	r.stdinMu.Lock()
+	defer r.stdinMu.Unlock()
+	return r.stdinErr
+}
+
+// Run starts the command and waits for it to finish.
+func (r *RemoteCmd) Run() error {
+	if err := r.Start(); err != nil {
+		return err
+	}
+	return r.Wait()
+}
+
+// Output runs the command and returns its stdout. When the command fails and Stderr was not set, the returned
+// *CommandFailedError holds its stderr.
+func (r *RemoteCmd) Output() ([]byte, error) {
+	if r.Stdout != nil {
+		return nil, errors.New("commandclient: Stdout already set")
+	}
+	var stdout, stderr bytes.Buffer
+	r.Stdout = &stdout
+	captureErr := r.Stderr == nil
+	if captureErr {
+		r.Stderr = &stderr
+	}
+
+	err := r.Run()
+	var failed *CommandFailedError
+	if captureErr && errors.As(err, &failed) {
+		failed.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its stdout and stderr together, in the order they arrived.
+func (r *RemoteCmd) CombinedOutput() ([]byte, error) {
+	if r.Stdout != nil {
+		return nil, errors.New("commandclient: Stdout already set")
+	}
+	if r.Stderr != nil {
+		return nil, errors.New("commandclient: Stderr already set")
+	}
+	var out bytes.Buffer
+	r.Stdout = &out
+	r.Stderr = &out
+	err := r.Run()
+	return out.Bytes(), err
+}
@@ -0,0 +1,80 @@
+package commandclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+
+	"github.com/hashicorp/yamux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// An agent dials in from wherever it happens to be, so there is no host name to check its certificate against. It is
+// trusted if the certificate chains to the CA bundle and is meant for serving.
+func agentTLSConfig(tlsConfig *tls.Config) *tls.Config {
+	if tlsConfig == nil {
+		return nil
+	}
+
+	config := tlsConfig.Clone()
+	config.InsecureSkipVerify = true
+	config.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("agent presented no certificate")
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range cs.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         tlsConfig.RootCAs,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		})
+		return err
+	}
+	return config
+}
+
+// AcceptAgent waits for a server running in reverse mode to dial in on lis and returns a client connection whose RPCs run
+// over it. The agent's end of the TCP connection accepts yamux streams and serves gRPC on them, so this side opens a stream
+// whenever gRPC dials. The returned channel is closed when the agent disconnects.
+func AcceptAgent(lis net.Listener, tlsConfig *tls.Config, token string) (*grpc.ClientConn, <-chan struct{}, error) {
+	conn, err := lis.Accept()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := yamux.Client(conn, nil)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	opts := append(dialOptions(agentTLSConfig(tlsConfig), token), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return session.Open()
+	}))
+	client, err := grpc.NewClient("passthrough:///"+conn.RemoteAddr().String(), opts...)
+	if err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+
+	// Tie the two together, so closing the client connection hangs up on the agent and the agent going away shuts down the
+	// client connection.
+	go func() {
+		<-session.CloseChan()
+		client.Close()
+	}()
+	go func() {
+		for state := client.GetState(); state != connectivity.Shutdown; state = client.GetState() {
+			client.WaitForStateChange(context.Background(), state)
+		}
+		session.Close()
+	}()
+	return client, session.CloseChan(), nil
+}
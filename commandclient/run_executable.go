@@ -2,16 +2,31 @@ package commandclient
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
-	"log"
 
+	"github.com/apoindevster/bitwarp/codec"
 	proto "github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
+// ExecutableDataChan carries the input and output of a command run by RunExecutable. Stdout and Stderr are unbuffered
+// and have to be read until the call returns. RemoteCmd takes an io.Reader and io.Writers instead.
 type ExecutableDataChan struct {
 	Stdout chan []byte
 	Stderr chan []byte
 	Stdin  chan []byte
+	// Resize changes the size of the pseudo-terminal of a command started with the pty option.
+	Resize chan *proto.WindowSize
+	// Signal delivers a signal to the command or its process group.
+	Signal chan *proto.SendSignal
+	// Detach stops streaming and leaves the command running as a job on the server.
+	Detach chan struct{}
+	// Job receives the ID of the server job running the command. It is buffered and never blocks the stream, so it can be
+	// ignored.
+	Job chan string
 }
 
 func MakeExecutableDataChan() ExecutableDataChan {
@@ -19,17 +34,74 @@ func MakeExecutableDataChan() ExecutableDataChan {
 		Stdout: make(chan []byte),
 		Stderr: make(chan []byte),
 		Stdin:  make(chan []byte),
+		Resize: make(chan *proto.WindowSize),
+		Signal: make(chan *proto.SendSignal),
+		Detach: make(chan struct{}),
+		Job:    make(chan string, 1),
 	}
 }
 
 func RunExecutable(command string, args []string, dataChan *ExecutableDataChan, client *proto.CommandClient) int32 {
-	ctx := context.Background()
-	stream, err := (*client).RunExecutable(ctx)
+	return RunExecutableWithOptions(&proto.RunExecutableOptions{Command: command, Args: args}, dataChan, client)
+}
+
+// RunExecutableWithOptions runs a command described by options, for example on a pseudo-terminal, and returns its exit code.
+func RunExecutableWithOptions(options *proto.RunExecutableOptions, dataChan *ExecutableDataChan, client *proto.CommandClient) int32 {
+	return RunExecutableContext(context.Background(), options, dataChan, client)
+}
+
+// RunExecutableContext is RunExecutableWithOptions bound to ctx. Cancelling ctx makes the server kill the command along with
+// every process it started.
+func RunExecutableContext(ctx context.Context, options *proto.RunExecutableOptions, dataChan *ExecutableDataChan, client *proto.CommandClient) int32 {
+	return exitCode(commandOnly(client).Run(ctx, options, dataChan), dataChan)
+}
+
+// Run runs the command described by options, streaming it through dataChan. It returns nil once the command exits with
+// code 0 or is detached, and a *CommandFailedError when it exits with any other code. Cancelling ctx makes the server
+// kill the command along with every process it started.
+func (c *Client) Run(ctx context.Context, options *proto.RunExecutableOptions, dataChan *ExecutableDataChan) error {
+	stream, err := c.command.RunExecutable(ctx)
 	if err != nil {
-		log.Fatalf("RunCommand failed with error: %v", err)
+		return classify(err)
 	}
 
-	waitc := make(chan int32)
+	return exitError(streamExecutable(stream, &proto.RunExecutableInput{Options: options}, dataChan))
+}
+
+// The exit code the package level functions return for err, telling the caller through dataChan why there is none.
+func exitCode(err error, dataChan *ExecutableDataChan) int32 {
+	var failed *CommandFailedError
+	if err == nil {
+		return 0
+	} else if errors.As(err, &failed) {
+		return failed.Code
+	}
+	dataChan.Stderr <- []byte(status.Convert(err).Message() + "\n")
+	return -1
+}
+
+// Undo the compression of whichever of stdout and stderr a result carries.
+func decodeOutput(r *proto.RunExecutableResult) ([]byte, []byte, error) {
+	stdout, stderr := r.GetStdout(), r.GetStderr()
+	var err error
+	if stdout != nil {
+		stdout, err = codec.Decode(r.GetCompression(), stdout)
+	}
+	if stderr != nil && err == nil {
+		stderr, err = codec.Decode(r.GetCompression(), stderr)
+	}
+	return stdout, stderr, err
+}
+
+// The exit code of a command, or why its stream broke.
+type exit struct {
+	code int32
+	err  error
+}
+
+// Send first and then pump dataChan through a RunExecutable style stream until the command finishes or is detached.
+func streamExecutable(stream grpc.BidiStreamingClient[proto.RunExecutableInput, proto.RunExecutableResult], first *proto.RunExecutableInput, dataChan *ExecutableDataChan) (int32, error) {
+	waitc := make(chan exit)
 
 	go func() {
 		var returnCode int32
@@ -37,16 +109,25 @@ func RunExecutable(command string, args []string, dataChan *ExecutableDataChan,
 			r, err := stream.Recv()
 			if err == io.EOF {
 				// Finished Reading
-				waitc <- returnCode
+				waitc <- exit{code: returnCode}
 				return
 			} else if err != nil {
-				waitc <- -1
+				waitc <- exit{code: -1, err: err}
 				return
 			}
 
+			if r.GetJobId() != "" && !r.GetDetached() {
+				select {
+				case dataChan.Job <- r.GetJobId():
+				default:
+				}
+			}
 			returnCode = r.GetReturnCode()
-			stdout := r.GetStdout()
-			stderr := r.GetStderr()
+			stdout, stderr, err := decodeOutput(r)
+			if err != nil {
+				dataChan.Stderr <- []byte(fmt.Sprintf("Failed to decode output: %v\n", err))
+				continue
+			}
 			if stdout != nil {
 				dataChan.Stdout <- stdout
 			}
@@ -56,16 +137,21 @@ func RunExecutable(command string, args []string, dataChan *ExecutableDataChan,
 		}
 	}()
 
-	options := &proto.RunExecutableOptions{Command: command, Args: args}
-	stream.Send(&proto.RunExecutableInput{Options: options})
+	stream.Send(first)
 
 	for {
 		select {
 		case input := <-dataChan.Stdin:
 			stream.Send(&proto.RunExecutableInput{Stdin: input})
-		case retCode := <-waitc:
+		case size := <-dataChan.Resize:
+			stream.Send(&proto.RunExecutableInput{Resize: size})
+		case sig := <-dataChan.Signal:
+			stream.Send(&proto.RunExecutableInput{Signal: sig})
+		case <-dataChan.Detach:
+			stream.Send(&proto.RunExecutableInput{Detach: true})
+		case e := <-waitc:
 			stream.CloseSend()
-			return retCode
+			return e.code, e.err
 		}
 	}
 }
@@ -0,0 +1,102 @@
+package commandclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/delta"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/tree"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SyncOptions tune a Sync.
+type SyncOptions struct {
+	// Push brings remotePath up to date with localPath, pull the other way around.
+	Direction proto.SyncDirection
+	// Only report what would change.
+	DryRun bool
+	// Globs picking what is synced, as described by tree.Filter.
+	Include []string
+	Exclude []string
+	// Codec to compress literal data with, when the sending side offers it.
+	Compression proto.Compression
+}
+
+// Sync brings one copy of a file or directory tree up to date with the other, in the direction given by options. Files
+// whose size and modification time already match are left alone, and of the rest only the blocks the other side does
+// not already have are sent. Nothing is deleted.
+func (c *Client) Sync(ctx context.Context, localPath string, remotePath string, options *SyncOptions) (*proto.SyncResult, error) {
+	expPath := os.ExpandEnv(localPath)
+	filter := tree.Filter{Include: options.Include, Exclude: options.Exclude}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	request := &proto.SyncRequest{
+		Path:        remotePath,
+		Direction:   options.Direction,
+		DryRun:      options.DryRun,
+		Include:     options.Include,
+		Exclude:     options.Exclude,
+		Compression: options.Compression,
+	}
+	push := options.Direction == proto.SyncDirection_SYNC_PUSH
+	if push {
+		if _, err := os.Stat(expPath); err != nil {
+			return nil, err
+		}
+		// As with uploads, only compress with a codec the server can decode.
+		if request.Compression != proto.Compression_COMPRESSION_NONE {
+			params, _ := c.ConnectionParams(ctx)
+			request.Compression = codec.Negotiate(params.GetCompressions(), request.Compression)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.command.Sync(ctx)
+	if err != nil {
+		return nil, classify(err)
+	}
+	if err := stream.Send(&proto.SyncMessage{Request: request}); err != nil && !errors.Is(err, io.EOF) {
+		return nil, classify(err)
+	}
+
+	if !push {
+		result, err := delta.Receive(stream, expPath, options.DryRun)
+		if err != nil {
+			return nil, classify(err)
+		}
+		stream.CloseSend()
+		return result, nil
+	}
+
+	err = delta.Send(stream, expPath, filter, request.Compression)
+	// A send only fails with io.EOF, the real reason the server stopped comes back from Recv.
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, classify(err)
+	}
+	stream.CloseSend()
+
+	for {
+		m, err := stream.Recv()
+		if err == io.EOF {
+			return nil, status.Error(codes.Unavailable, "sync ended without a result")
+		} else if err != nil {
+			return nil, classify(err)
+		}
+		if m.GetResult() != nil {
+			return m.GetResult(), nil
+		}
+	}
+}
+
+// Sync calls Client.Sync without a deadline.
+func Sync(localPath string, remotePath string, options *SyncOptions, client *proto.CommandClient) (*proto.SyncResult, error) {
+	return commandOnly(client).Sync(context.Background(), localPath, remotePath, options)
+}
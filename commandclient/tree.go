@@ -0,0 +1,141 @@
+package commandclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/tree"
+)
+
+// TreeUpload copies the directory tree under srcPath into destPath on the server, keeping relative paths, permissions,
+// modification times and symlinks. include and exclude are globs as described by tree.Filter.
+func (c *Client) TreeUpload(ctx context.Context, srcPath string, destPath string, include []string, exclude []string) (*proto.TreeSummary, error) {
+	return c.treeUpload(ctx, srcPath, destPath, include, exclude, nil)
+}
+
+// Count the file data in chunks towards progress, which is told the total is 0 as the size of a tree is not known up
+// front. progress can be nil.
+func countData(progress func(int64, int64)) func(*proto.TreeChunk) {
+	var done int64
+	return func(m *proto.TreeChunk) {
+		if progress != nil && len(m.GetData()) > 0 {
+			done += int64(len(m.GetData()))
+			progress(done, 0)
+		}
+	}
+}
+
+func (c *Client) treeUpload(ctx context.Context, srcPath string, destPath string, include []string, exclude []string, progress func(int64, int64)) (*proto.TreeSummary, error) {
+	expPath := os.ExpandEnv(srcPath)
+
+	info, err := os.Stat(expPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", expPath)
+	}
+
+	filter := tree.Filter{Include: include, Exclude: exclude}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.command.TreeUpload(ctx)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	err = stream.Send(&proto.TreeChunk{Request: &proto.TreeRequest{Path: destPath}})
+	if err == nil {
+		count := countData(progress)
+		_, err = tree.Walk(expPath, filter, func(m *proto.TreeChunk) error {
+			count(m)
+			return stream.Send(m)
+		})
+	}
+	// A send only fails with io.EOF, the real reason the server stopped comes back from CloseAndRecv.
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, classify(err)
+	}
+
+	summary, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, classify(err)
+	}
+	return summary, nil
+}
+
+// TreeUpload calls Client.TreeUpload without a deadline.
+func TreeUpload(srcPath string, destPath string, include []string, exclude []string, client *proto.CommandClient) (*proto.TreeSummary, error) {
+	return commandOnly(client).TreeUpload(context.Background(), srcPath, destPath, include, exclude)
+}
+
+// TreeUploadWithOptions is TreeUpload stopped by cancelling options.Context, reporting the bytes of file data sent so
+// far to options.Progress with a total of 0. Compression does not apply to trees.
+func TreeUploadWithOptions(srcPath string, destPath string, include []string, exclude []string, options *TransferOptions, client *proto.CommandClient) (*proto.TreeSummary, error) {
+	return commandOnly(client).treeUpload(options.context(), srcPath, destPath, include, exclude, options.Progress)
+}
+
+// TreeDownload copies the directory tree under srcPath on the server into destPath, keeping relative paths,
+// permissions, modification times and symlinks. include and exclude are globs as described by tree.Filter.
+func (c *Client) TreeDownload(ctx context.Context, srcPath string, destPath string, include []string, exclude []string) (*proto.TreeSummary, error) {
+	return c.treeDownload(ctx, srcPath, destPath, include, exclude, nil)
+}
+
+func (c *Client) treeDownload(ctx context.Context, srcPath string, destPath string, include []string, exclude []string, progress func(int64, int64)) (*proto.TreeSummary, error) {
+	if err := (tree.Filter{Include: include, Exclude: exclude}).Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.command.TreeDownload(ctx, &proto.TreeRequest{Path: srcPath, Include: include, Exclude: exclude})
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	// Wait for the first message so a refused request does not leave an empty directory behind.
+	m, err := stream.Recv()
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	w, err := tree.NewWriter(destPath)
+	if err != nil {
+		return nil, err
+	}
+	count := countData(progress)
+	for {
+		count(m)
+		if err := w.Write(m); err != nil {
+			w.Close()
+			return nil, err
+		}
+
+		m, err = stream.Recv()
+		if err == io.EOF {
+			return w.Close()
+		} else if err != nil {
+			w.Close()
+			return nil, classify(err)
+		}
+	}
+}
+
+// TreeDownload calls Client.TreeDownload without a deadline.
+func TreeDownload(srcPath string, destPath string, include []string, exclude []string, client *proto.CommandClient) (*proto.TreeSummary, error) {
+	return commandOnly(client).TreeDownload(context.Background(), srcPath, destPath, include, exclude)
+}
+
+// TreeDownloadWithOptions is TreeDownload stopped by cancelling options.Context, reporting the bytes of file data
+// received so far to options.Progress with a total of 0. Compression does not apply to trees.
+func TreeDownloadWithOptions(srcPath string, destPath string, include []string, exclude []string, options *TransferOptions, client *proto.CommandClient) (*proto.TreeSummary, error) {
+	return commandOnly(client).treeDownload(options.context(), srcPath, destPath, include, exclude, options.Progress)
+}
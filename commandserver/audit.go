@@ -0,0 +1,48 @@
+package commandserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/apoindevster/bitwarp/audit"
+	"google.golang.org/grpc/peer"
+)
+
+// Fill in who made the request and append it to the audit log. Failing to audit is logged but does not fail the RPC, since the
+// action has already happened by the time it is recorded.
+func (s *Server) recordAudit(ctx context.Context, start time.Time, entry audit.Entry) {
+	if s.Audit == nil {
+		return
+	}
+
+	entry.Time = start
+	entry.DurationMs = time.Since(start).Milliseconds()
+	entry.Identity = callerName(ctx)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		entry.Peer = p.Addr.String()
+	}
+	if id, ok := ConnectionIDFromContext(ctx); ok {
+		entry.ConnectionID = id.String()
+	}
+
+	if err := s.Audit.Record(entry); err != nil {
+		Logger.Errorf("Failed to write audit entry for %s: %v", entry.RPC, err)
+	}
+}
+
+// Run op and audit it as entry, along with the error it returns.
+func (s *Server) audited(ctx context.Context, entry audit.Entry, op func() error) error {
+	start := time.Now()
+	err := op()
+	entry.Error = auditError(err)
+	s.recordAudit(ctx, start, entry)
+	return err
+}
+
+// Describe an RPC failure for the audit log.
+func auditError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,282 @@
+package commandserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/apoindevster/bitwarp/audit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Identity is the authenticated operator behind an RPC.
+type Identity struct {
+	Name  string
+	Roles []string
+}
+
+// Authenticator validates a bearer token and returns the identity it belongs to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+type identityKey struct{}
+
+// ContextWithIdentity returns a copy of ctx carrying id.
+func ContextWithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the identity the auth interceptors attached to an RPC context.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// The name to use for the caller in logs.
+func callerName(ctx context.Context) string {
+	if id, ok := IdentityFromContext(ctx); ok {
+		return id.Name
+	}
+	return "anonymous"
+}
+
+// Pull the bearer token out of the authorization metadata.
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", errors.New("missing metadata")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", errors.New("missing authorization token")
+	}
+
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", errors.New("authorization must be a bearer token")
+	}
+
+	return token, nil
+}
+
+func authenticate(ctx context.Context, auth Authenticator) (context.Context, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	id, err := auth.Authenticate(ctx, token)
+	if err != nil {
+		Logger.Warnf("Rejected token: %v", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return ContextWithIdentity(ctx, id), nil
+}
+
+// Record a call the auth interceptors turned away in the audit log, under the name of the method it was for.
+func (s *Server) recordDenied(ctx context.Context, fullMethod string, err error) {
+	s.recordAudit(ctx, time.Now(), audit.Entry{RPC: path.Base(fullMethod), Error: auditError(err)})
+}
+
+// UnaryAuthInterceptor rejects unary RPCs without a valid bearer token and attaches the caller identity to the context.
+// Rejected calls are recorded in the audit log of s.
+func (s *Server) UnaryAuthInterceptor(auth Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		authCtx, err := authenticate(ctx, auth)
+		if err != nil {
+			s.recordDenied(ctx, info.FullMethod, err)
+			return nil, err
+		}
+		ctx = authCtx
+		return handler(ctx, req)
+	}
+}
+
+// A server stream with the context replaced by one carrying the caller identity.
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamAuthInterceptor rejects streaming RPCs without a valid bearer token and attaches the caller identity to the
+// stream context. Rejected calls are recorded in the audit log of s.
+func (s *Server) StreamAuthInterceptor(auth Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), auth)
+		if err != nil {
+			s.recordDenied(ss.Context(), info.FullMethod, err)
+			return err
+		}
+		return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// ChainAuthenticator tries each authenticator in turn and accepts the first identity returned.
+type ChainAuthenticator []Authenticator
+
+func (c ChainAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	var errs []string
+	for _, auth := range c {
+		id, err := auth.Authenticate(ctx, token)
+		if err == nil {
+			return id, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, errors.New(strings.Join(errs, "; "))
+}
+
+// An entry in a static token file. Only the SHA-256 of the token is stored so the file does not leak credentials.
+type StaticToken struct {
+	Name   string   `json:"name"`
+	Roles  []string `json:"roles"`
+	SHA256 string   `json:"sha256"`
+}
+
+// StaticTokenAuthenticator accepts a fixed set of tokens loaded from a JSON file.
+type StaticTokenAuthenticator struct {
+	tokens []StaticToken
+}
+
+// HashToken returns the hex SHA-256 of a token as stored in a static token file.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadStaticTokens reads a JSON array of StaticToken entries.
+func LoadStaticTokens(path string) (*StaticTokenAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	var tokens []StaticToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+
+	for _, t := range tokens {
+		if t.Name == "" || len(t.SHA256) != sha256.Size*2 {
+			return nil, fmt.Errorf("token file entry %q needs a name and a hex sha256", t.Name)
+		}
+	}
+
+	return &StaticTokenAuthenticator{tokens: tokens}, nil
+}
+
+func (a *StaticTokenAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	sum := HashToken(token)
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(sum), []byte(strings.ToLower(t.SHA256))) == 1 {
+			return &Identity{Name: t.Name, Roles: t.Roles}, nil
+		}
+	}
+	return nil, errors.New("unknown static token")
+}
+
+// The claims carried inside an HMAC signed token.
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Roles     []string `json:"roles,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// HMACAuthenticator accepts tokens of the form payload.signature where the payload is base64url encoded JSON claims and the
+// signature is the base64url HMAC-SHA256 of the encoded payload.
+type HMACAuthenticator struct {
+	Secret []byte
+}
+
+// LoadHMACSecret reads the shared secret used to sign and verify HMAC tokens.
+func LoadHMACSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token secret: %w", err)
+	}
+
+	secret := []byte(strings.TrimSpace(string(data)))
+	if len(secret) < 32 {
+		return nil, errors.New("token secret must be at least 32 bytes")
+	}
+	return secret, nil
+}
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// IssueHMACToken signs a token for name with the given roles that expires after ttl.
+func IssueHMACToken(secret []byte, name string, roles []string, ttl time.Duration) (string, error) {
+	if name == "" {
+		return "", errors.New("a token needs a subject")
+	}
+
+	now := time.Now()
+	claims, err := json.Marshal(tokenClaims{
+		Subject:   name,
+		Roles:     roles,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	return payload + "." + sign(secret, payload), nil
+}
+
+func (a *HMACAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, errors.New("malformed token")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(sign(a.Secret, payload))) {
+		return nil, errors.New("bad token signature")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+
+	var claims tokenClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("token for %s expired", claims.Subject)
+	}
+
+	return &Identity{Name: claims.Subject, Roles: claims.Roles}, nil
+}
@@ -0,0 +1,32 @@
+package commandserver
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/stats"
+)
+
+type connectionIDKey struct{}
+
+// ConnectionTagger is a gRPC stats handler that gives every transport connection a random UUID. Every RPC made over the
+// connection can look it up with ConnectionIDFromContext. Install it with grpc.StatsHandler.
+type ConnectionTagger struct{}
+
+func (ConnectionTagger) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return context.WithValue(ctx, connectionIDKey{}, uuid.New())
+}
+
+func (ConnectionTagger) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+func (ConnectionTagger) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (ConnectionTagger) HandleRPC(ctx context.Context, s stats.RPCStats) {}
+
+// ConnectionIDFromContext returns the UUID ConnectionTagger assigned to the connection an RPC arrived on.
+func ConnectionIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(connectionIDKey{}).(uuid.UUID)
+	return id, ok
+}
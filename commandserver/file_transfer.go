@@ -2,90 +2,354 @@ package commandserver
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"hash"
 	"io"
 	"os"
+	"time"
 
+	"github.com/apoindevster/bitwarp/audit"
+	"github.com/apoindevster/bitwarp/codec"
 	"github.com/apoindevster/bitwarp/proto"
 	"google.golang.org/grpc"
-	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// Size of the chunks files are sent in.
+const chunkSize = 1024 * 1000
+
+// Uploads are written next to their destination under this suffix, and only renamed into place once the whole file has
+// arrived and its checksum matches. An interrupted upload leaves the part file behind to be resumed.
+const partSuffix = ".bitwarp-part"
+
+// Hash the first n bytes of f.
+func hashPrefix(f *os.File, n int64) (hash.Hash, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, n)); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
 func (s *Server) FileDownload(pathChunk *proto.FileChunk, stream grpc.ServerStreamingServer[proto.FileChunk]) error {
+	start := time.Now()
+	entry := audit.Entry{RPC: "FileDownload"}
+	err := s.fileDownload(pathChunk, stream, &entry)
+	entry.Error = auditError(err)
+	s.recordAudit(stream.Context(), start, entry)
+	return err
+}
+
+func (s *Server) fileDownload(pathChunk *proto.FileChunk, stream grpc.ServerStreamingServer[proto.FileChunk], entry *audit.Entry) error {
 	expPath := os.ExpandEnv(pathChunk.GetPath())
+	entry.Paths = []string{expPath}
+	id, _ := IdentityFromContext(stream.Context())
+	if err := s.Policy.AuthorizeDownload(id, expPath); err != nil {
+		Logger.Warnf("Denied: %v", status.Convert(err).Message())
+		return err
+	}
 
 	info, err := os.Stat(expPath)
 	if err != nil {
 		Logger.Warnf("Failed to stat file with error: %v\n", err)
-		return err
+		return fsError(err)
 	}
 
 	if info.IsDir() {
 		Logger.Warnf("Path provided is a directory... Please provide a file path\n")
-		return os.ErrNotExist
+		return status.Errorf(codes.InvalidArgument, "%s is a directory", expPath)
 	}
 
-	var size int32 = 1024 * 1000
-	rem := info.Size()
-
 	f, err := os.Open(expPath)
 	if err != nil {
 		Logger.Warnf("Failed to open file with error: %v", err)
 		return err
 	}
+	defer f.Close()
 
-	for rem > 0 {
-		if rem < int64(size) {
-			size = int32(rem)
-		}
-
-		fbytes := make([]byte, size)
+	// Resume where the client left off, unless what it has is not the start of this file.
+	size := info.Size()
+	offset := pathChunk.GetOffset()
+	if offset < 0 || offset > size {
+		offset = 0
+	}
+	h, err := hashPrefix(f, offset)
+	if err != nil {
+		Logger.Warnf("Failed to hash file with error: %v", err)
+		return err
+	}
+	if offset > 0 && !bytes.Equal(h.Sum(nil), pathChunk.GetSha256()) {
+		offset = 0
+		h.Reset()
+	}
 
-		_, err := io.ReadAtLeast(f, fbytes, int(size))
+	if offset > 0 {
+		Logger.Infof("%s resuming download of %s at %d bytes", callerName(stream.Context()), expPath, offset)
+	} else {
+		Logger.Infof("%s downloading %s", callerName(stream.Context()), expPath)
+	}
 
-		if err != nil {
-			Logger.Warnf("Failed to read at least %d bytes: %v\n", size, err)
+	// Always send at least one chunk so an empty file still carries its checksum.
+	compression := codec.Negotiate(codec.Supported, pathChunk.GetCompression())
+	var wire int64
+	buf := make([]byte, chunkSize)
+	for pos, first := offset, true; pos < size || first; first = false {
+		n, err := f.ReadAt(buf[:min(chunkSize, size-pos)], pos)
+		if errors.Is(err, io.EOF) {
+			// The file shrank while it was being read.
+			size = pos + int64(n)
+		} else if err != nil {
+			Logger.Warnf("Failed to read file with error: %v\n", err)
 			return err
 		}
 
-		err = stream.Send(&proto.FileChunk{Path: expPath, Chunk: fbytes})
-		if err != nil {
+		h.Write(buf[:n])
+		data, used := codec.Encode(compression, buf[:n])
+		chunk := &proto.FileChunk{Path: expPath, Chunk: data, Offset: pos, Size: size, Compression: used}
+		pos += int64(n)
+		if pos == size {
+			chunk.Sha256 = h.Sum(nil)
+		}
+
+		if err := stream.Send(chunk); err != nil {
 			Logger.Warnf("Failed to send file chunk with error: %v\n", err)
 			return err
 		}
+		entry.BytesOut += int64(n)
+		wire += int64(len(data))
+	}
 
-		rem = rem - int64(size)
+	if compression != proto.Compression_COMPRESSION_NONE {
+		Logger.Infof("Sent %d bytes of %s as %d with %v", entry.BytesOut, expPath, wire, compression)
 	}
 	return nil
 }
 
-func (s *Server) FileUpload(stream grpc.ClientStreamingServer[proto.FileChunk, emptypb.Empty]) error {
+func (s *Server) FileUploadStatus(ctx context.Context, pathChunk *proto.FileChunk) (*proto.FileStatus, error) {
+	var result *proto.FileStatus
+	err := s.audited(ctx, audit.Entry{RPC: "FileUploadStatus", Paths: []string{pathChunk.GetPath()}}, func() (err error) {
+		result, err = s.fileUploadStatus(ctx, pathChunk)
+		return err
+	})
+	return result, err
+}
+
+func (s *Server) fileUploadStatus(ctx context.Context, pathChunk *proto.FileChunk) (*proto.FileStatus, error) {
+	id, _ := IdentityFromContext(ctx)
+	if err := s.authorizeUpload(id, pathChunk.GetPath()); err != nil {
+		return nil, err
+	}
+
+	result := &proto.FileStatus{Path: pathChunk.GetPath()}
+	f, err := openRegular(pathChunk.GetPath()+partSuffix, os.O_RDONLY)
+	if errors.Is(err, os.ErrNotExist) {
+		result.Sha256 = sha256.New().Sum(nil)
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	h, err := hashPrefix(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	result.Size = info.Size()
+	result.Sha256 = h.Sum(nil)
+	return result, nil
+}
+
+func (s *Server) FileUpload(stream grpc.ClientStreamingServer[proto.FileChunk, proto.FileStatus]) error {
+	start := time.Now()
+	entry := audit.Entry{RPC: "FileUpload"}
+	err := s.fileUpload(stream, &entry)
+	entry.Error = auditError(err)
+	s.recordAudit(stream.Context(), start, entry)
+	return err
+}
+
+func (s *Server) fileUpload(stream grpc.ClientStreamingServer[proto.FileChunk, proto.FileStatus], entry *audit.Entry) error {
 	var f *os.File = nil
 	var w *bufio.Writer = nil
+	var h hash.Hash = nil
+	var path string
+	var written int64
+	var expected []byte
+	var wire int64
 	for {
 		m, err := stream.Recv()
 
 		if err == io.EOF {
-			return err
+			if f == nil {
+				return stream.SendAndClose(&proto.FileStatus{})
+			}
+
+			// The client is done sending, make sure everything hit the disk before checking and acknowledging.
+			if err := w.Flush(); err != nil {
+				Logger.Warnf("Failed to flush file upload: %v", err)
+				return err
+			}
+			if err := f.Close(); err != nil {
+				Logger.Warnf("Failed to close file upload: %v", err)
+				return err
+			}
+
+			if expected == nil {
+				// Keep what arrived, a client that stopped early can still resume.
+				Logger.Warnf("Upload of %s ended without a checksum", path)
+				return status.Errorf(codes.InvalidArgument, "upload of %s ended without the SHA-256 of the file", path)
+			}
+			sum := h.Sum(nil)
+			if !bytes.Equal(sum, expected) {
+				// Start the next attempt from scratch rather than resuming on top of corrupt data.
+				os.Remove(path + partSuffix)
+				Logger.Warnf("Checksum mismatch uploading %s", path)
+				return status.Errorf(codes.DataLoss, "checksum mismatch uploading %s: got %x, expected %x", path, sum, expected)
+			}
+			if err := os.Rename(path+partSuffix, path); err != nil {
+				Logger.Warnf("Failed to move file upload into place: %v", err)
+				return err
+			}
+			if wire != entry.BytesIn {
+				Logger.Infof("Received %d bytes of %s as %d", entry.BytesIn, path, wire)
+			}
+			return stream.SendAndClose(&proto.FileStatus{Path: path, Size: written, Sha256: sum})
 		} else if err != nil {
+			// Keep what arrived so the upload can be resumed.
+			if f != nil {
+				w.Flush()
+			}
 			Logger.Warnf("Failed file upload with err: %v\n", err)
 			return err
 		}
 
 		// We have a message
 		if f == nil {
-			f, err = os.Create(m.GetPath())
+			path = m.GetPath()
+			id, _ := IdentityFromContext(stream.Context())
+			if err := s.authorizeUpload(id, path); err != nil {
+				return err
+			}
+			entry.Paths = []string{path}
+
+			f, h, err = openPart(path+partSuffix, m.GetOffset())
 			if err != nil {
-				Logger.Warnf("Failed to create file with error %v", err)
+				Logger.Warnf("Failed to open file upload: %v", err)
 				return err
 			}
+			defer f.Close()
 			w = bufio.NewWriter(f)
-			defer w.Flush()
+			written = m.GetOffset()
+
+			if written > 0 {
+				Logger.Infof("%s resuming upload of %s at %d bytes", callerName(stream.Context()), path, written)
+			} else {
+				Logger.Infof("%s uploading %s", callerName(stream.Context()), path)
+			}
+		}
+
+		if m.GetOffset() != written {
+			return status.Errorf(codes.InvalidArgument, "chunk for offset %d arrived at offset %d", m.GetOffset(), written)
 		}
 
-		_, err = w.Write(m.GetChunk())
+		data, err := codec.Decode(m.GetCompression(), m.GetChunk())
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to decode chunk at offset %d: %v", m.GetOffset(), err)
+		}
+		_, err = w.Write(data)
 		if err != nil {
 			Logger.Warnf("Failed to write data to file upload: %v", err)
 			return err
 		}
+		h.Write(data)
+		written += int64(len(data))
+		entry.BytesIn += int64(len(data))
+		wire += int64(len(m.GetChunk()))
+		if m.GetSha256() != nil {
+			expected = m.GetSha256()
+		} else if m.GetSize() > 0 && written == m.GetSize() {
+			return status.Errorf(codes.InvalidArgument, "last chunk of %s carries no SHA-256 of the file", path)
+		}
+	}
+}
+
+// Check the caller may write both path and the part file it is uploaded through.
+func (s *Server) authorizeUpload(id *Identity, path string) error {
+	for _, p := range []string{path, path + partSuffix} {
+		if err := s.Policy.AuthorizeUpload(id, p); err != nil {
+			Logger.Warnf("Denied: %v", status.Convert(err).Message())
+			return err
+		}
+	}
+	return nil
+}
+
+// Open the regular file p with flag, refusing a symlink or anything else put in its place, which could lead to a file
+// outside the paths the caller is allowed.
+func openRegular(p string, flag int) (*os.File, error) {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s is not a regular file", p)
+	}
+
+	f, err := os.OpenFile(p, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+	// The path could have been swapped for a symlink since the Lstat.
+	opened, err := f.Stat()
+	if err != nil || !os.SameFile(info, opened) {
+		f.Close()
+		return nil, status.Errorf(codes.FailedPrecondition, "%s changed while it was being opened", p)
+	}
+	return f, nil
+}
+
+// Open the part file of an upload to continue writing at offset, returning it along with the hash of what it already holds.
+func openPart(part string, offset int64) (*os.File, hash.Hash, error) {
+	f, err := openRegular(part, os.O_RDWR)
+	if errors.Is(err, os.ErrNotExist) {
+		// O_EXCL fails on a symlink created since, instead of following it.
+		f, err = os.OpenFile(part, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if offset < 0 || offset > info.Size() {
+		f.Close()
+		return nil, nil, status.Errorf(codes.InvalidArgument, "cannot resume at %d bytes, only %d have been received", offset, info.Size())
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	h, err := hashPrefix(f, offset)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, err
 	}
+	return f, h, nil
 }
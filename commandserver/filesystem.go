@@ -0,0 +1,304 @@
+package commandserver
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/apoindevster/bitwarp/audit"
+	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Default and largest number of entries in a page of ListDir.
+const (
+	defaultPageSize = 1000
+	maxPageSize     = 10000
+)
+
+// FilesystemServer serves the Filesystem service. Like JobsServer it is a separate type because a Server cannot embed
+// the unimplemented stubs of more than one service.
+type FilesystemServer struct {
+	proto.UnimplementedFilesystemServer
+
+	server *Server
+}
+
+// FilesystemServer returns the Filesystem service, checked against the policy and recorded in the audit log of s.
+func (s *Server) FilesystemServer() *FilesystemServer {
+	return &FilesystemServer{server: s}
+}
+
+// Give errors from the os package the status code that matches them, so clients can tell a missing path from a denied
+// one.
+func fsError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, syscall.ENOTEMPTY):
+		// Counts as fs.ErrExist, but nothing is in the way of the call, it is the directory that has to be emptied first.
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, fs.ErrNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, fs.ErrExist):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, fs.ErrPermission):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
+}
+
+// Convert between the chmod style mode on the wire and an fs.FileMode.
+func wireMode(m fs.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		mode |= 0o4000
+	}
+	if m&fs.ModeSetgid != 0 {
+		mode |= 0o2000
+	}
+	if m&fs.ModeSticky != 0 {
+		mode |= 0o1000
+	}
+	return mode
+}
+
+func fileMode(mode uint32) fs.FileMode {
+	m := fs.FileMode(mode).Perm()
+	if mode&0o4000 != 0 {
+		m |= fs.ModeSetuid
+	}
+	if mode&0o2000 != 0 {
+		m |= fs.ModeSetgid
+	}
+	if mode&0o1000 != 0 {
+		m |= fs.ModeSticky
+	}
+	return m
+}
+
+// Describe the entry at p, found under name.
+func fileInfo(name string, p string, info fs.FileInfo, names *ownerNames) *proto.FileInfo {
+	fi := &proto.FileInfo{
+		Name:    name,
+		Size:    info.Size(),
+		Mode:    wireMode(info.Mode()),
+		ModTime: timestamppb.New(info.ModTime()),
+	}
+	switch {
+	case info.IsDir():
+		fi.Type = proto.EntryType_ENTRY_DIR
+	case info.Mode()&fs.ModeSymlink != 0:
+		fi.Type = proto.EntryType_ENTRY_SYMLINK
+		fi.LinkTarget, _ = os.Readlink(p)
+	case info.Mode().IsRegular():
+		fi.Type = proto.EntryType_ENTRY_FILE
+	default:
+		fi.Type = proto.EntryType_ENTRY_OTHER
+	}
+	setOwner(fi, info, names)
+	return fi
+}
+
+// Describe the entry at p without following it if it is a symlink.
+func lstatInfo(p string) (*proto.FileInfo, error) {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	return fileInfo(p, p, info, newOwnerNames()), nil
+}
+
+// Check the caller may read, or with change write to, every one of paths.
+func (fsrv *FilesystemServer) authorize(ctx context.Context, change bool, paths ...string) error {
+	id, _ := IdentityFromContext(ctx)
+	for _, p := range paths {
+		authorize := fsrv.server.Policy.AuthorizeDownload
+		if change {
+			authorize = fsrv.server.Policy.AuthorizeUpload
+		}
+		if err := authorize(id, p); err != nil {
+			Logger.Warnf("Denied: %v", status.Convert(err).Message())
+			return err
+		}
+	}
+	return nil
+}
+
+// Run op on paths once the caller is allowed to read them, or with write to change them, and audit it as rpc. Denied
+// calls are audited too.
+func (fsrv *FilesystemServer) audited(ctx context.Context, rpc string, write bool, paths []string, op func() error) error {
+	return fsrv.server.audited(ctx, audit.Entry{RPC: rpc, Paths: paths}, func() error {
+		if err := fsrv.authorize(ctx, write, paths...); err != nil {
+			return err
+		}
+		return op()
+	})
+}
+
+// Make a change to paths with op and audit it as rpc. When result is set, the entry there is returned once op succeeds.
+func (fsrv *FilesystemServer) change(ctx context.Context, rpc string, paths []string, result string, op func() error) (*proto.FileInfo, error) {
+	var info *proto.FileInfo
+	err := fsrv.audited(ctx, rpc, true, paths, func() error {
+		Logger.Infof("%s calling %s on %s", callerName(ctx), rpc, strings.Join(paths, " and "))
+		if err := op(); err != nil {
+			Logger.Warnf("%s failed with error: %v", rpc, err)
+			return fsError(err)
+		}
+		if result == "" {
+			return nil
+		}
+		var err error
+		info, err = lstatInfo(result)
+		return err
+	})
+	return info, err
+}
+
+func (fsrv *FilesystemServer) Stat(ctx context.Context, in *proto.StatRequest) (*proto.FileInfo, error) {
+	expPath := os.ExpandEnv(in.GetPath())
+	var fi *proto.FileInfo
+	err := fsrv.audited(ctx, "Stat", false, []string{expPath}, func() error {
+		stat := os.Lstat
+		if in.GetFollow() {
+			stat = os.Stat
+		}
+		info, err := stat(expPath)
+		if err != nil {
+			return fsError(err)
+		}
+		fi = fileInfo(expPath, expPath, info, newOwnerNames())
+		return nil
+	})
+	return fi, err
+}
+
+func (fsrv *FilesystemServer) ListDir(ctx context.Context, in *proto.ListDirRequest) (*proto.ListDirResponse, error) {
+	expPath := os.ExpandEnv(in.GetPath())
+	pageSize := int(in.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	response := &proto.ListDirResponse{}
+	err := fsrv.audited(ctx, "ListDir", false, []string{expPath}, func() error {
+		// Entries come sorted by name, so a page picks up after the last name of the previous one without the server
+		// having to remember anything between calls.
+		entries, err := os.ReadDir(expPath)
+		if err != nil {
+			return fsError(err)
+		}
+		names := newOwnerNames()
+		for _, entry := range entries {
+			if entry.Name() <= in.GetPageToken() {
+				continue
+			}
+			if len(response.Entries) == pageSize {
+				response.NextPageToken = response.Entries[pageSize-1].GetName()
+				break
+			}
+			info, err := entry.Info()
+			if err != nil {
+				// Removed since the directory was read.
+				continue
+			}
+			response.Entries = append(response.Entries, fileInfo(entry.Name(), expPath+string(os.PathSeparator)+entry.Name(), info, names))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (fsrv *FilesystemServer) Mkdir(ctx context.Context, in *proto.MkdirRequest) (*proto.FileInfo, error) {
+	expPath := os.ExpandEnv(in.GetPath())
+	mode := fs.FileMode(0o755)
+	if in.GetMode() != 0 {
+		mode = fileMode(in.GetMode())
+	}
+	return fsrv.change(ctx, "Mkdir", []string{expPath}, expPath, func() error {
+		if in.GetParents() {
+			return os.MkdirAll(expPath, mode)
+		}
+		return os.Mkdir(expPath, mode)
+	})
+}
+
+func (fsrv *FilesystemServer) Remove(ctx context.Context, in *proto.RemoveRequest) (*emptypb.Empty, error) {
+	expPath := os.ExpandEnv(in.GetPath())
+	_, err := fsrv.change(ctx, "Remove", []string{expPath}, "", func() error {
+		if !in.GetRecursive() {
+			return os.Remove(expPath)
+		}
+		// RemoveAll succeeds on a missing path, which would hide a typo.
+		if _, err := os.Lstat(expPath); err != nil {
+			return err
+		}
+		return os.RemoveAll(expPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (fsrv *FilesystemServer) Rename(ctx context.Context, in *proto.RenameRequest) (*proto.FileInfo, error) {
+	oldPath, newPath := os.ExpandEnv(in.GetOldPath()), os.ExpandEnv(in.GetNewPath())
+	return fsrv.change(ctx, "Rename", []string{oldPath, newPath}, newPath, func() error {
+		return os.Rename(oldPath, newPath)
+	})
+}
+
+func (fsrv *FilesystemServer) Chmod(ctx context.Context, in *proto.ChmodRequest) (*proto.FileInfo, error) {
+	expPath := os.ExpandEnv(in.GetPath())
+	return fsrv.change(ctx, "Chmod", []string{expPath}, expPath, func() error {
+		return os.Chmod(expPath, fileMode(in.GetMode()))
+	})
+}
+
+func (fsrv *FilesystemServer) Chown(ctx context.Context, in *proto.ChownRequest) (*proto.FileInfo, error) {
+	expPath := os.ExpandEnv(in.GetPath())
+	return fsrv.change(ctx, "Chown", []string{expPath}, expPath, func() error {
+		uid, gid, err := lookupOwner(in.GetOwner(), in.GetGroup())
+		if err != nil {
+			return err
+		}
+		return os.Chown(expPath, uid, gid)
+	})
+}
+
+func (fsrv *FilesystemServer) Symlink(ctx context.Context, in *proto.SymlinkRequest) (*proto.FileInfo, error) {
+	expPath := os.ExpandEnv(in.GetPath())
+	return fsrv.change(ctx, "Symlink", []string{expPath}, expPath, func() error {
+		return os.Symlink(in.GetTarget(), expPath)
+	})
+}
+
+func (fsrv *FilesystemServer) Readlink(ctx context.Context, in *proto.PathRequest) (*proto.FileInfo, error) {
+	expPath := os.ExpandEnv(in.GetPath())
+	var info *proto.FileInfo
+	err := fsrv.audited(ctx, "Readlink", false, []string{expPath}, func() error {
+		var err error
+		info, err = lstatInfo(expPath)
+		if err != nil {
+			return err
+		}
+		if info.GetType() != proto.EntryType_ENTRY_SYMLINK {
+			return status.Errorf(codes.InvalidArgument, "%s is not a symlink", expPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
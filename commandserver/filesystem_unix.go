@@ -0,0 +1,83 @@
+//go:build !windows
+
+package commandserver
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Names of the users and groups looked up so far, so a listing does not look up the same owner for every entry.
+type ownerNames struct {
+	users  map[uint32]string
+	groups map[uint32]string
+}
+
+func newOwnerNames() *ownerNames {
+	return &ownerNames{users: make(map[uint32]string), groups: make(map[uint32]string)}
+}
+
+func (n *ownerNames) user(uid uint32) string {
+	name, ok := n.users[uid]
+	if !ok {
+		if u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10)); err == nil {
+			name = u.Username
+		}
+		n.users[uid] = name
+	}
+	return name
+}
+
+func (n *ownerNames) group(gid uint32) string {
+	name, ok := n.groups[gid]
+	if !ok {
+		if g, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10)); err == nil {
+			name = g.Name
+		}
+		n.groups[gid] = name
+	}
+	return name
+}
+
+func setOwner(fi *proto.FileInfo, info fs.FileInfo, names *ownerNames) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	fi.Uid, fi.Gid = st.Uid, st.Gid
+	fi.Owner, fi.Group = names.user(st.Uid), names.group(st.Gid)
+}
+
+// Turn the owner and group of a Chown into ids, -1 leaving that one unchanged.
+func lookupOwner(owner string, group string) (int, int, error) {
+	uid, gid := -1, -1
+	if owner != "" {
+		id, err := strconv.Atoi(owner)
+		if err != nil {
+			u, lerr := user.Lookup(owner)
+			if lerr != nil {
+				return 0, 0, status.Errorf(codes.InvalidArgument, "unknown user %s", owner)
+			}
+			id, _ = strconv.Atoi(u.Uid)
+		}
+		uid = id
+	}
+	if group != "" {
+		id, err := strconv.Atoi(group)
+		if err != nil {
+			g, lerr := user.LookupGroup(group)
+			if lerr != nil {
+				return 0, 0, status.Errorf(codes.InvalidArgument, "unknown group %s", group)
+			}
+			id, _ = strconv.Atoi(g.Gid)
+		}
+		gid = id
+	}
+	return uid, gid, nil
+}
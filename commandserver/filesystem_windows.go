@@ -0,0 +1,22 @@
+package commandserver
+
+import (
+	"io/fs"
+
+	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Windows files have no uid or gid, so there are no owners to report.
+type ownerNames struct{}
+
+func newOwnerNames() *ownerNames {
+	return &ownerNames{}
+}
+
+func setOwner(fi *proto.FileInfo, info fs.FileInfo, names *ownerNames) {}
+
+func lookupOwner(owner string, group string) (int, int, error) {
+	return 0, 0, status.Error(codes.Unimplemented, "chown is not supported on Windows")
+}
@@ -3,10 +3,19 @@ module commandserver
 go 1.23.2
 
 require (
+	github.com/apoindevster/bitwarp v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/audit v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/codec v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/delta v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/tree v0.0.0-unpublished
 	github.com/charmbracelet/log v0.4.2
+	github.com/creack/pty v1.1.24
+	github.com/google/uuid v1.6.0
+	github.com/hashicorp/yamux v0.1.2
+	golang.org/x/crypto v0.36.0
 	google.golang.org/grpc v1.73.0
 	google.golang.org/protobuf v1.36.6
-	github.com/apoindevster/bitwarp v0.0.0-unpublished
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -17,6 +26,7 @@ require (
 	github.com/charmbracelet/x/cellbuf v0.0.13-0.20250311204145-2c3ea96c31dd // indirect
 	github.com/charmbracelet/x/term v0.2.1 // indirect
 	github.com/go-logfmt/logfmt v0.6.0 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
@@ -31,3 +41,11 @@ require (
 )
 
 replace github.com/apoindevster/bitwarp => ../
+
+replace github.com/apoindevster/bitwarp/audit => ../audit
+
+replace github.com/apoindevster/bitwarp/tree => ../tree
+
+replace github.com/apoindevster/bitwarp/codec => ../codec
+
+replace github.com/apoindevster/bitwarp/delta => ../delta
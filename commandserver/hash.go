@@ -0,0 +1,129 @@
+package commandserver
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apoindevster/bitwarp/audit"
+	"github.com/apoindevster/bitwarp/proto"
+	"golang.org/x/crypto/blake2b"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newHash(algorithm proto.HashAlgorithm) (hash.Hash, error) {
+	switch algorithm {
+	case proto.HashAlgorithm_HASH_SHA256:
+		return sha256.New(), nil
+	case proto.HashAlgorithm_HASH_SHA1:
+		return sha1.New(), nil
+	case proto.HashAlgorithm_HASH_MD5:
+		return md5.New(), nil
+	case proto.HashAlgorithm_HASH_BLAKE2B:
+		return blake2b.New512(nil)
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "unknown hash algorithm %v", algorithm)
+}
+
+// Hash the file at path with every algorithm in one pass over it.
+func hashFile(path string, algorithms []proto.HashAlgorithm) (*proto.FileHash, error) {
+	hashes := make([]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, len(algorithms))
+	for i, algorithm := range algorithms {
+		h, err := newHash(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = h
+		writers[i] = h
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	size, err := io.Copy(io.MultiWriter(writers...), f)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &proto.FileHash{Path: path, Size: size}
+	for i, h := range hashes {
+		result.Digests = append(result.Digests, &proto.Digest{Algorithm: algorithms[i], Sum: h.Sum(nil)})
+	}
+	return result, nil
+}
+
+func (s *Server) HashFile(in *proto.HashRequest, stream grpc.ServerStreamingServer[proto.FileHash]) error {
+	start := time.Now()
+	entry := audit.Entry{RPC: "HashFile"}
+	err := s.hashFile(in, stream, &entry)
+	entry.Error = auditError(err)
+	s.recordAudit(stream.Context(), start, entry)
+	return err
+}
+
+func (s *Server) hashFile(in *proto.HashRequest, stream grpc.ServerStreamingServer[proto.FileHash], entry *audit.Entry) error {
+	expPath := os.ExpandEnv(in.GetPath())
+	entry.Paths = []string{expPath}
+	id, _ := IdentityFromContext(stream.Context())
+	// Hashes give away what is in a file, so they need the same permission as downloading it.
+	if err := s.Policy.AuthorizeDownload(id, expPath); err != nil {
+		Logger.Warnf("Denied: %v", status.Convert(err).Message())
+		return err
+	}
+
+	algorithms := in.GetAlgorithms()
+	if len(algorithms) == 0 {
+		algorithms = []proto.HashAlgorithm{proto.HashAlgorithm_HASH_SHA256}
+	}
+
+	info, err := os.Stat(expPath)
+	if err != nil {
+		Logger.Warnf("Failed to stat file with error: %v\n", err)
+		return fsError(err)
+	}
+
+	Logger.Infof("%s hashing %s", callerName(stream.Context()), expPath)
+	if !info.IsDir() {
+		result, err := hashFile(expPath, algorithms)
+		if err != nil {
+			Logger.Warnf("Failed to hash file with error: %v", err)
+			return err
+		}
+		return stream.Send(result)
+	}
+
+	// WalkDir visits entries in lexical order and does not follow symlinks, so the manifest only covers what is
+	// actually under the directory.
+	return filepath.WalkDir(expPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		result, err := hashFile(path, algorithms)
+		if err != nil {
+			Logger.Warnf("Failed to hash file with error: %v", err)
+			return err
+		}
+		result.Path, err = filepath.Rel(expPath, path)
+		if err != nil {
+			return err
+		}
+		result.Path = filepath.ToSlash(result.Path)
+		return stream.Send(result)
+	})
+}
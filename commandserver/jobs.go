@@ -0,0 +1,479 @@
+package commandserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// How much output each job keeps for clients that attach later. Older output is dropped once the limit is reached.
+const jobOutputLimit = 1 << 20
+
+// How many finished jobs are remembered so their exit code and output can still be looked at.
+const maxFinishedJobs = 64
+
+// Output is batched into messages of up to about this size.
+const maxOutputMessage = 256 << 10
+
+type outputChunk struct {
+	stderr bool
+	data   []byte
+}
+
+// A command started by RunExecutable. Its output is collected into a bounded buffer rather than sent straight to the client,
+// so the command can outlive the stream that started it and be attached to again. While clients are attached the command
+// is held up instead of dropping output they have not been sent yet.
+type job struct {
+	id      string
+	owner   string
+	options *proto.RunExecutableOptions
+	command *exec.Cmd
+	cancel  context.CancelFunc
+	stdin   io.WriteCloser
+	ptmx    *os.File
+	started time.Time
+
+	mu          sync.Mutex
+	chunks      []outputChunk
+	first       uint64 // sequence number of chunks[0]
+	buffered    int
+	outputBytes int64
+	changed     chan struct{}
+	finished    bool
+	ended       time.Time
+	returnCode  int32
+	failure     string
+	detached    bool
+	attached    int32
+	// Where each attached client is in the output, keyed by the ID attach handed out.
+	cursors    map[int]uint64
+	nextReader int
+}
+
+// Start the command described by options as a job owned by owner. The client starting it is already attached as the
+// returned reader.
+func startJob(owner string, options *proto.RunExecutableOptions) (*job, int, error) {
+	command, ctx, cancel, err := buildCommand(context.Background(), options)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to prepare command: %w", err)
+	}
+
+	j := &job{
+		owner:   owner,
+		options: options,
+		command: command,
+		cancel:  cancel,
+		changed: make(chan struct{}),
+	}
+	// Attach the client starting the job before there is any output, so none of it is dropped before it is sent.
+	reader := j.attach()
+
+	var output sync.WaitGroup
+	if options.GetPty() {
+		j.ptmx, err = startPty(command, options.GetWindowSize())
+		if err != nil {
+			cancel()
+			return nil, 0, fmt.Errorf("failed to start command on a pseudo-terminal: %w", err)
+		}
+
+		// The terminal carries both output streams and stdin.
+		j.stdin = j.ptmx
+		output.Add(1)
+		go j.collect(j.ptmx, false, &output)
+	} else {
+		stdout, err := command.StdoutPipe()
+		if err != nil {
+			cancel()
+			return nil, 0, fmt.Errorf("failed to create stdout pipe: %w", err)
+		}
+
+		stderr, err := command.StderrPipe()
+		if err != nil {
+			cancel()
+			return nil, 0, fmt.Errorf("failed to create stderr pipe: %w", err)
+		}
+
+		if options.GetStdin() {
+			j.stdin, err = command.StdinPipe()
+			if err != nil {
+				cancel()
+				return nil, 0, fmt.Errorf("failed to create stdin pipe: %w", err)
+			}
+		}
+
+		if err := command.Start(); err != nil {
+			cancel()
+			return nil, 0, fmt.Errorf("failed to start command: %w", err)
+		}
+
+		output.Add(2)
+		go j.collect(stdout, false, &output)
+		go j.collect(stderr, true, &output)
+	}
+
+	j.started = time.Now()
+	go j.wait(ctx, &output)
+	return j, reader, nil
+}
+
+// Wake everything waiting for the job to change. Must be called with mu held.
+func (j *job) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// Report whether an attached client has yet to be sent the oldest buffered chunk. Must be called with mu held.
+func (j *job) oldestUnread() bool {
+	for _, cursor := range j.cursors {
+		if cursor <= j.first {
+			return true
+		}
+	}
+	return false
+}
+
+// Add output to the buffer, dropping the oldest chunks once it is over the limit. Nothing an attached client still needs
+// is dropped. Instead this waits for the client to catch up, which in turn holds up the command.
+func (j *job) append(stderr bool, data []byte) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.chunks = append(j.chunks, outputChunk{stderr: stderr, data: data})
+	j.buffered += len(data)
+	j.outputBytes += int64(len(data))
+	j.notify()
+	for j.buffered > jobOutputLimit && len(j.chunks) > 1 {
+		if j.oldestUnread() {
+			changed := j.changed
+			j.mu.Unlock()
+			<-changed
+			j.mu.Lock()
+			continue
+		}
+		j.buffered -= len(j.chunks[0].data)
+		j.chunks[0] = outputChunk{}
+		j.chunks = j.chunks[1:]
+		j.first++
+	}
+}
+
+// Copy everything read from pipe into the output buffer.
+func (j *job) collect(pipe io.Reader, stderr bool, done *sync.WaitGroup) {
+	defer done.Done()
+	for {
+		buf := make([]byte, 4096)
+		read, err := pipe.Read(buf)
+		if read > 0 {
+			j.append(stderr, buf[:read])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Wait for the command to exit once its output has been drained and record how it finished.
+func (j *job) wait(ctx context.Context, output *sync.WaitGroup) {
+	output.Wait()
+	err := j.command.Wait()
+	if j.ptmx != nil {
+		j.ptmx.Close()
+	}
+
+	var returnCode int32
+	var failure string
+	if err != nil {
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			if ws, ok := exitError.Sys().(syscall.WaitStatus); ok {
+				returnCode = int32(ws.ExitStatus())
+			} else {
+				j.append(true, []byte(fmt.Sprintf("Failed to get exit status from the command with error: %v", err)))
+				returnCode, failure = -1, err.Error()
+			}
+		} else {
+			j.append(true, []byte(fmt.Sprintf("Failed to wait for the command with error: %v", err)))
+			returnCode, failure = -1, err.Error()
+		}
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		timeout := j.options.GetTimeout().AsDuration()
+		j.append(true, []byte(fmt.Sprintf("Command timed out after %v and was killed\n", timeout)))
+		failure = fmt.Sprintf("timed out after %v", timeout)
+		Logger.Warnf("Command %s timed out after %v", j.options.GetCommand(), timeout)
+	}
+	j.cancel()
+
+	j.mu.Lock()
+	j.finished = true
+	j.ended = time.Now()
+	j.returnCode = returnCode
+	j.failure = failure
+	j.notify()
+	j.mu.Unlock()
+}
+
+// Return the buffered output from sequence number cursor onwards for the attached client reader. Output dropped before
+// the client attached is skipped, carrying on from the oldest output still buffered.
+func (j *job) read(reader int, cursor uint64) (chunks []outputChunk, next uint64, finished bool, changed chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if cursor < j.first {
+		cursor = j.first
+	}
+	chunks = append(chunks, j.chunks[cursor-j.first:]...)
+	next = j.first + uint64(len(j.chunks))
+	if j.cursors[reader] != next {
+		// The client holds its own copy of everything up to next now, so output held back for it can go.
+		j.cursors[reader] = next
+		j.notify()
+	}
+	return chunks, next, j.finished, j.changed
+}
+
+// Report the exit code and failure of a finished job.
+func (j *job) result() (bool, int32, string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.finished, j.returnCode, j.failure
+}
+
+func (j *job) isDetached() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.detached
+}
+
+// Register a client streaming the job, returning its ID for read and release.
+func (j *job) attach() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.cursors == nil {
+		j.cursors = make(map[int]uint64)
+	}
+	j.nextReader++
+	j.cursors[j.nextReader] = j.first
+	j.attached++
+	return j.nextReader
+}
+
+// Forget a client that stopped streaming the job, so its output no longer waits for it.
+func (j *job) release(reader int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delete(j.cursors, reader)
+	j.attached--
+	j.notify()
+}
+
+// Deliver a signal to the job's command or its process group.
+func (j *job) signal(sig *proto.SendSignal) error {
+	if finished, _, _ := j.result(); finished {
+		return errors.New("the job has already finished")
+	}
+	return signalProcess(j.command.Process, sig.GetSignal(), sig.GetProcessGroup())
+}
+
+// Kill the job along with every process it started.
+func (j *job) kill() {
+	j.cancel()
+}
+
+func (j *job) info() *proto.JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := &proto.JobInfo{
+		Id:          j.id,
+		Owner:       j.owner,
+		Command:     j.options.GetCommand(),
+		Args:        j.options.GetArgs(),
+		Pty:         j.options.GetPty(),
+		StartTime:   timestamppb.New(j.started),
+		OutputBytes: j.outputBytes,
+		Attached:    j.attached,
+		Detached:    j.detached,
+	}
+	if j.finished {
+		info.State = proto.JobState_JOB_EXITED
+		info.ReturnCode = j.returnCode
+		info.EndTime = timestamppb.New(j.ended)
+	}
+	return info
+}
+
+// Forward stdin, window size changes, signals and detach requests from the client to the job until the client stops
+// sending. Closing detach tells the output side that the client asked to detach.
+func (j *job) forwardInput(stream grpc.BidiStreamingServer[proto.RunExecutableInput, proto.RunExecutableResult], detach chan struct{}, bytesIn *atomic.Int64) {
+	for {
+		in, err := stream.Recv()
+		if err != nil {
+			// The client closed its side. A pipe gets closed so the command sees EOF, while a terminal belongs to the
+			// command until it exits. A detached job keeps its stdin for whoever attaches next.
+			if errors.Is(err, io.EOF) && j.stdin != nil && j.ptmx == nil && !j.isDetached() {
+				j.stdin.Close()
+			}
+			return
+		}
+
+		if len(in.GetStdin()) > 0 && j.stdin != nil {
+			n, err := j.stdin.Write(in.GetStdin())
+			bytesIn.Add(int64(n))
+			if err != nil {
+				Logger.Warnf("Failed to write to command stdin: %v", err)
+			}
+		}
+
+		if in.GetResize() != nil && j.ptmx != nil {
+			if err := resizePty(j.ptmx, in.GetResize()); err != nil {
+				Logger.Warnf("Failed to resize pseudo-terminal: %v", err)
+			}
+		}
+
+		if sig := in.GetSignal(); sig.GetSignal() != proto.Signal_SIGNAL_NONE {
+			Logger.Infof("%s sending %v to job %s", callerName(stream.Context()), sig.GetSignal(), j.id)
+			if err := j.signal(sig); err != nil {
+				Logger.Warnf("Failed to send %v to job %s: %v", sig.GetSignal(), j.id, err)
+			}
+		}
+
+		if in.GetDetach() {
+			j.mu.Lock()
+			j.detached = true
+			j.mu.Unlock()
+			close(detach)
+			return
+		}
+	}
+}
+
+// Stream the job to a client, replaying everything still buffered, and forward the client's input until the job finishes or
+// the client detaches. A client that goes away without detaching gets a non-nil error. The bytes sent and received are
+// added to bytesOut and bytesIn. reader comes from attach and is released once the client stops streaming.
+func (j *job) serve(stream grpc.BidiStreamingServer[proto.RunExecutableInput, proto.RunExecutableResult], reader int, compression proto.Compression, bytesIn *atomic.Int64, bytesOut *int64) (detached bool, err error) {
+	defer j.release(reader)
+
+	if err := stream.Send(&proto.RunExecutableResult{JobId: j.id}); err != nil {
+		return false, err
+	}
+
+	detach := make(chan struct{})
+	go j.forwardInput(stream, detach, bytesIn)
+
+	var cursor uint64
+	for {
+		chunks, next, finished, changed := j.read(reader, cursor)
+		cursor = next
+		for len(chunks) > 0 {
+			// Send runs of output from the same stream together, which compresses a lot better than lots of small reads.
+			stderr := chunks[0].stderr
+			var data []byte
+			for len(chunks) > 0 && chunks[0].stderr == stderr && len(data) < maxOutputMessage {
+				data = append(data, chunks[0].data...)
+				chunks = chunks[1:]
+			}
+
+			*bytesOut += int64(len(data))
+			encoded, used := codec.Encode(compression, data)
+			if stderr {
+				err = stream.Send(&proto.RunExecutableResult{Stderr: encoded, Compression: used})
+			} else {
+				err = stream.Send(&proto.RunExecutableResult{Stdout: encoded, Compression: used})
+			}
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if finished {
+			_, returnCode, _ := j.result()
+			return false, stream.Send(&proto.RunExecutableResult{ReturnCode: returnCode})
+		}
+
+		select {
+		case <-changed:
+		case <-detach:
+			return true, stream.Send(&proto.RunExecutableResult{JobId: j.id, Detached: true})
+		case <-stream.Context().Done():
+			return false, stream.Context().Err()
+		}
+	}
+}
+
+// The jobs a server knows about, running or recently finished.
+type jobRegistry struct {
+	mu     sync.Mutex
+	nextID uint64
+	jobs   map[string]*job
+}
+
+// Add a job, giving it an ID, and forget the oldest finished jobs beyond maxFinishedJobs.
+func (r *jobRegistry) add(j *job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.jobs == nil {
+		r.jobs = make(map[string]*job)
+	}
+	r.nextID++
+	j.id = strconv.FormatUint(r.nextID, 10)
+	r.jobs[j.id] = j
+
+	var finished []*job
+	for _, other := range r.jobs {
+		if done, _, _ := other.result(); done {
+			finished = append(finished, other)
+		}
+	}
+	if len(finished) > maxFinishedJobs {
+		sort.Slice(finished, func(a, b int) bool { return finished[a].started.Before(finished[b].started) })
+		for _, old := range finished[:len(finished)-maxFinishedJobs] {
+			delete(r.jobs, old.id)
+		}
+	}
+}
+
+// Look up a job by ID. Callers only see their own jobs.
+func (r *jobRegistry) get(owner string, id string) (*job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[id]
+	if !ok || j.owner != owner {
+		return nil, false
+	}
+	return j, true
+}
+
+// List the jobs belonging to owner, oldest first.
+func (r *jobRegistry) list(owner string) []*job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var jobs []*job
+	for _, j := range r.jobs {
+		if j.owner == owner {
+			jobs = append(jobs, j)
+		}
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].started.Before(jobs[b].started) })
+	return jobs
+}
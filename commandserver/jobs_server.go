@@ -0,0 +1,108 @@
+package commandserver
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/apoindevster/bitwarp/audit"
+	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// JobsServer serves the Jobs service for the commands started through a Server. It is a separate type because a Server
+// cannot embed the unimplemented stubs of two services.
+type JobsServer struct {
+	proto.UnimplementedJobsServer
+
+	server *Server
+}
+
+// JobsServer returns the Jobs service backed by the jobs of s.
+func (s *Server) JobsServer() *JobsServer {
+	return &JobsServer{server: s}
+}
+
+func (js *JobsServer) ListJobs(ctx context.Context, _ *emptypb.Empty) (*proto.JobList, error) {
+	list := &proto.JobList{}
+	js.server.audited(ctx, audit.Entry{RPC: "ListJobs"}, func() error {
+		for _, j := range js.server.jobs.list(callerName(ctx)) {
+			list.Jobs = append(list.Jobs, j.info())
+		}
+		return nil
+	})
+	return list, nil
+}
+
+func (js *JobsServer) AttachJob(stream grpc.BidiStreamingServer[proto.RunExecutableInput, proto.RunExecutableResult]) error {
+	start := time.Now()
+	entry := audit.Entry{RPC: "AttachJob"}
+	err := js.attachJob(stream, &entry)
+	if entry.Error == "" {
+		entry.Error = auditError(err)
+	}
+	js.server.recordAudit(stream.Context(), start, entry)
+	return err
+}
+
+func (js *JobsServer) attachJob(stream grpc.BidiStreamingServer[proto.RunExecutableInput, proto.RunExecutableResult], entry *audit.Entry) error {
+	in, err := stream.Recv()
+	if err != nil {
+		Logger.Warn("Failed to get which job to attach to")
+		return err
+	}
+
+	caller := callerName(stream.Context())
+	entry.Job = in.GetJobId()
+	j, ok := js.server.jobs.get(caller, in.GetJobId())
+	if !ok {
+		return status.Errorf(codes.NotFound, "no job %s", in.GetJobId())
+	}
+	entry.Command = j.options.GetCommand()
+	entry.Args = j.options.GetArgs()
+
+	Logger.Infof("%s attaching to job %s", caller, j.id)
+	var bytesIn atomic.Int64
+	detached, err := j.serve(stream, j.attach(), in.GetOptions().GetCompression(), &bytesIn, &entry.BytesOut)
+	entry.BytesIn = bytesIn.Load()
+	if detached || err != nil {
+		// Only the client that started a job can take it down by going away. Anyone attached later just detaches.
+		Logger.Infof("%s detached from job %s", caller, j.id)
+		return nil
+	}
+
+	_, code, _ := j.result()
+	entry.ExitCode = &code
+	return nil
+}
+
+func (js *JobsServer) SignalJob(ctx context.Context, in *proto.JobSignal) (*emptypb.Empty, error) {
+	start := time.Now()
+	entry := audit.Entry{RPC: "SignalJob", Job: in.GetJobId()}
+	err := js.signalJob(ctx, in, &entry)
+	entry.Error = auditError(err)
+	js.server.recordAudit(ctx, start, entry)
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (js *JobsServer) signalJob(ctx context.Context, in *proto.JobSignal, entry *audit.Entry) error {
+	caller := callerName(ctx)
+	j, ok := js.server.jobs.get(caller, in.GetJobId())
+	if !ok {
+		return status.Errorf(codes.NotFound, "no job %s", in.GetJobId())
+	}
+	entry.Command = j.options.GetCommand()
+	entry.Args = j.options.GetArgs()
+
+	Logger.Infof("%s sending %v to job %s", caller, in.GetSignal().GetSignal(), j.id)
+	if err := j.signal(in.GetSignal()); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "failed to send %v to job %s: %v", in.GetSignal().GetSignal(), j.id, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package commandserver
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// Build the environment for a command. The base is either the server environment or nothing, and every override replaces
+// an inherited variable of the same name.
+func commandEnv(mode proto.EnvMode, overrides map[string]string) []string {
+	var env []string
+	if mode == proto.EnvMode_ENV_INHERIT {
+		env = os.Environ()
+	}
+
+	if len(overrides) == 0 {
+		return env
+	}
+
+	merged := env[:0:0]
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := overrides[name]; !ok {
+			merged = append(merged, kv)
+		}
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		merged = append(merged, name+"="+overrides[name])
+	}
+
+	return merged
+}
+
+// Create the command described by options. The command is bound to parent, normally the stream context, so it is killed
+// along with everything it started when the client goes away. The returned context also expires with the timeout, if any,
+// and the cancel function releases it and must be called once the command has finished.
+func buildCommand(parent context.Context, options *proto.RunExecutableOptions) (*exec.Cmd, context.Context, context.CancelFunc, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout := options.GetTimeout().AsDuration(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+
+	command := exec.CommandContext(ctx, options.GetCommand(), options.GetArgs()...)
+	command.Cancel = func() error { return killProcessTree(command.Process) }
+	command.Dir = os.ExpandEnv(options.GetCwd())
+	if !options.GetPty() {
+		setProcessGroup(command)
+	}
+
+	overrides := make(map[string]string, len(options.GetEnv())+3)
+	if options.GetUser() != "" {
+		account, err := runAs(command, options.GetUser())
+		if err != nil {
+			cancel()
+			return nil, nil, nil, err
+		}
+
+		// Programs find their home directory and name through the environment, so point it at the new user.
+		overrides["HOME"] = account.HomeDir
+		overrides["USER"] = account.Username
+		overrides["LOGNAME"] = account.Username
+	}
+	for name, value := range options.GetEnv() {
+		overrides[name] = value
+	}
+
+	command.Env = commandEnv(options.GetEnvMode(), overrides)
+	return command, ctx, cancel, nil
+}
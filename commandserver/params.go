@@ -0,0 +1,74 @@
+package commandserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Version is reported to clients by GetConnectionParams. Release builds set it with
+// -ldflags "-X github.com/apoindevster/bitwarp/commandserver.Version=...".
+var Version = "dev"
+
+// Features every server supports. Platform specific ones are added from platformCapabilities.
+var capabilities = []string{"exec", "stdin", "jobs", "upload", "download", "hash", "tree", "sync", "fs"}
+
+// DefaultIDFile is where the server keeps its identity unless told otherwise.
+func DefaultIDFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "bitwarp", "server-id")
+}
+
+// LoadOrCreateID returns the server identity stored at path, generating and saving a new one the first time the server
+// starts.
+func LoadOrCreateID(path string) (uuid.UUID, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		id, err := uuid.Parse(strings.TrimSpace(string(data)))
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("invalid server id in %s: %w", path, err)
+		}
+		return id, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return uuid.Nil, fmt.Errorf("failed to read server id: %w", err)
+	}
+
+	id := uuid.New()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create server id directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(id.String()+"\n"), 0o600); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to save server id: %w", err)
+	}
+	return id, nil
+}
+
+func (s *Server) GetConnectionParams(ctx context.Context, _ *emptypb.Empty) (*proto.ConnectionParams, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		Logger.Warnf("Failed to get hostname: %v", err)
+	}
+
+	return &proto.ConnectionParams{
+		Uuid:         s.ID[:],
+		Hostname:     hostname,
+		Os:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		Version:      Version,
+		Capabilities: append(append([]string{}, capabilities...), platformCapabilities...),
+		Compressions: codec.Supported,
+	}, nil
+}
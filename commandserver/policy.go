@@ -0,0 +1,311 @@
+package commandserver
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+// ExecRule allows RunExecutable to launch a command. Command is a glob matched against the requested executable, where a lone
+// "*" matches anything. Args, when set, is a regular expression that must match the whole argument list joined by spaces.
+// Users lists who the command may be run as, "*" allowing anyone. Without it the rule only covers commands that do not ask
+// to switch users.
+//
+// Env lists globs of the environment variables the command may set, and ClearEnv lets it start from an empty environment.
+// The variables that change what code gets loaded, PATH, LD_* and DYLD_*, are only allowed when a glob other than a lone
+// "*" matches them. Cwd lists the path prefixes the command may be started in. Without them the command can only run
+// with the server's environment and working directory.
+type ExecRule struct {
+	Command  string   `yaml:"command" json:"command"`
+	Args     string   `yaml:"args" json:"args"`
+	Users    []string `yaml:"users" json:"users"`
+	Env      []string `yaml:"env" json:"env"`
+	ClearEnv bool     `yaml:"clearenv" json:"clearenv"`
+	Cwd      []string `yaml:"cwd" json:"cwd"`
+
+	args *regexp.Regexp
+}
+
+// RolePolicy lists what a role is allowed to do. Upload and Download are path prefixes the role may write to and read from,
+// through file transfers and the Filesystem service.
+type RolePolicy struct {
+	Exec     []ExecRule `yaml:"exec" json:"exec"`
+	Upload   []string   `yaml:"upload" json:"upload"`
+	Download []string   `yaml:"download" json:"download"`
+}
+
+// Policy maps roles to permissions. Operators grants extra roles by identity name on top of the roles carried by the caller's
+// token. The name "anonymous" applies to callers when no authentication is configured.
+type Policy struct {
+	Roles     map[string]RolePolicy `yaml:"roles" json:"roles"`
+	Operators map[string][]string   `yaml:"operators" json:"operators"`
+}
+
+// PolicyEngine authorizes RPCs against a policy file. The policy can be swapped with Reload while RPCs are running; checks
+// are made when an RPC starts, so streams that were already allowed are left alone.
+type PolicyEngine struct {
+	path string
+
+	mu     sync.RWMutex
+	policy *Policy
+}
+
+// Parse a policy file. YAML is a superset of JSON so either format is accepted.
+func parsePolicy(data []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+
+	for name, role := range policy.Roles {
+		for i := range role.Exec {
+			rule := &role.Exec[i]
+			if rule.Command == "" {
+				return nil, fmt.Errorf("role %s has an exec rule without a command", name)
+			}
+			if _, err := path.Match(rule.Command, ""); err != nil {
+				return nil, fmt.Errorf("role %s has an invalid command glob %q: %w", name, rule.Command, err)
+			}
+			if rule.Args != "" {
+				re, err := regexp.Compile("^(?:" + rule.Args + ")$")
+				if err != nil {
+					return nil, fmt.Errorf("role %s has an invalid args pattern %q: %w", name, rule.Args, err)
+				}
+				rule.args = re
+			}
+			for _, glob := range rule.Env {
+				if _, err := path.Match(glob, ""); err != nil {
+					return nil, fmt.Errorf("role %s has an invalid env glob %q: %w", name, glob, err)
+				}
+			}
+		}
+		policy.Roles[name] = role
+	}
+
+	return policy, nil
+}
+
+// LoadPolicy reads the policy at path.
+func LoadPolicy(path string) (*PolicyEngine, error) {
+	e := &PolicyEngine{path: path}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload re-reads the policy file. If the new file is invalid the previous policy stays in force.
+func (e *PolicyEngine) Reload() error {
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	policy, err := parsePolicy(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	e.mu.Lock()
+	e.policy = policy
+	e.mu.Unlock()
+	return nil
+}
+
+// Collect the role policies that apply to an identity.
+func (e *PolicyEngine) rolesFor(id *Identity) (string, []RolePolicy) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	name := "anonymous"
+	var names []string
+	if id != nil {
+		name = id.Name
+		names = append(names, id.Roles...)
+	}
+	names = append(names, e.policy.Operators[name]...)
+
+	var roles []RolePolicy
+	for _, n := range names {
+		if role, ok := e.policy.Roles[n]; ok {
+			roles = append(roles, role)
+		}
+	}
+	return name, roles
+}
+
+func (r ExecRule) matches(command string, args []string, user string) bool {
+	if user != "" && !slices.Contains(r.Users, user) && !slices.Contains(r.Users, "*") {
+		return false
+	}
+	if r.Command != "*" {
+		if ok, _ := path.Match(r.Command, command); !ok {
+			return false
+		}
+	}
+	return r.args == nil || r.args.MatchString(strings.Join(args, " "))
+}
+
+// Report whether a variable changes which code a program loads, so it has to be allowed by name.
+func sensitiveEnv(name string) bool {
+	name = strings.ToUpper(name)
+	return name == "PATH" || strings.HasPrefix(name, "LD_") || strings.HasPrefix(name, "DYLD_")
+}
+
+func (r ExecRule) allowsEnv(name string) bool {
+	for _, glob := range r.Env {
+		if glob == "*" {
+			if !sensitiveEnv(name) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain why the rule does not let a command set the variables in env, start from an empty environment with clearEnv, or
+// start in the resolved directory cwd. It returns "" when the rule allows all of them.
+func (r ExecRule) environmentDenial(env []string, clearEnv bool, cwd string) string {
+	for _, name := range env {
+		if !r.allowsEnv(name) {
+			return fmt.Sprintf("setting %s is not allowed", name)
+		}
+	}
+	if clearEnv && !r.ClearEnv {
+		return "clearing the environment is not allowed"
+	}
+	if cwd != "" && !slices.ContainsFunc(r.Cwd, func(prefix string) bool { return underPrefix(cwd, prefix) }) {
+		return fmt.Sprintf("running in %s is not allowed", cwd)
+	}
+	return ""
+}
+
+// AuthorizeExec returns a PermissionDenied error unless one of the caller's roles may run the command described by options,
+// with its arguments, run-as user, environment and working directory. An empty user means the command runs as the server
+// user.
+func (e *PolicyEngine) AuthorizeExec(id *Identity, options *proto.RunExecutableOptions) error {
+	if e == nil {
+		return nil
+	}
+
+	command, args, user := options.GetCommand(), options.GetArgs(), options.GetUser()
+	env := make([]string, 0, len(options.GetEnv()))
+	for name := range options.GetEnv() {
+		env = append(env, name)
+	}
+	sort.Strings(env)
+	clearEnv := options.GetEnvMode() == proto.EnvMode_ENV_CLEAR
+
+	name, roles := e.rolesFor(id)
+	var cwd string
+	if options.GetCwd() != "" {
+		// Resolved the way upload and download paths are, so a symlink cannot lead out of an allowed prefix.
+		resolved, err := resolvePath(os.ExpandEnv(options.GetCwd()))
+		if err != nil {
+			return status.Errorf(codes.PermissionDenied, "%s is not allowed to run %s in %s: %v", name, command, options.GetCwd(), err)
+		}
+		cwd = resolved
+	}
+
+	var denial string
+	for _, role := range roles {
+		for _, rule := range role.Exec {
+			if !rule.matches(command, args, user) {
+				continue
+			}
+			if denial = rule.environmentDenial(env, clearEnv, cwd); denial == "" {
+				return nil
+			}
+		}
+	}
+
+	if denial != "" {
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed to run %s %s: %s", name, command, strings.Join(args, " "), denial)
+	}
+
+	if user != "" {
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed to run %s %s as %s", name, command, strings.Join(args, " "), user)
+	}
+	return status.Errorf(codes.PermissionDenied, "%s is not allowed to run %s %s", name, command, strings.Join(args, " "))
+}
+
+// Resolve p to an absolute path with symlinks evaluated so a link cannot be used to escape an allowed prefix. Paths that do
+// not exist yet are resolved through their closest existing parent.
+func resolvePath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	var rest []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) || dir == filepath.Dir(dir) {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+	}
+}
+
+// Report whether p is prefix itself or lives underneath it.
+func underPrefix(p string, prefix string) bool {
+	prefix = filepath.Clean(prefix)
+	if p == prefix {
+		return true
+	}
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	return strings.HasPrefix(p, prefix)
+}
+
+func (e *PolicyEngine) authorizePath(id *Identity, op string, p string, prefixes func(RolePolicy) []string) error {
+	if e == nil {
+		return nil
+	}
+
+	name, roles := e.rolesFor(id)
+	resolved, err := resolvePath(p)
+	if err != nil {
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed to %s %s: %v", name, op, p, err)
+	}
+
+	for _, role := range roles {
+		for _, prefix := range prefixes(role) {
+			if underPrefix(resolved, prefix) {
+				return nil
+			}
+		}
+	}
+
+	return status.Errorf(codes.PermissionDenied, "%s is not allowed to %s %s", name, op, resolved)
+}
+
+// AuthorizeUpload returns a PermissionDenied error unless one of the caller's roles may write to p.
+func (e *PolicyEngine) AuthorizeUpload(id *Identity, p string) error {
+	return e.authorizePath(id, "upload to", p, func(r RolePolicy) []string { return r.Upload })
+}
+
+// AuthorizeDownload returns a PermissionDenied error unless one of the caller's roles may read p.
+func (e *PolicyEngine) AuthorizeDownload(id *Identity, p string) error {
+	return e.authorizePath(id, "download", p, func(r RolePolicy) []string { return r.Download })
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+
+package commandserver
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// Features only available on platforms with POSIX processes and terminals.
+var platformCapabilities = []string{"pty", "run-as", "signals"}
+
+var signals = map[proto.Signal]syscall.Signal{
+	proto.Signal_SIGNAL_HUP:  syscall.SIGHUP,
+	proto.Signal_SIGNAL_INT:  syscall.SIGINT,
+	proto.Signal_SIGNAL_QUIT: syscall.SIGQUIT,
+	proto.Signal_SIGNAL_KILL: syscall.SIGKILL,
+	proto.Signal_SIGNAL_USR1: syscall.SIGUSR1,
+	proto.Signal_SIGNAL_USR2: syscall.SIGUSR2,
+	proto.Signal_SIGNAL_TERM: syscall.SIGTERM,
+	proto.Signal_SIGNAL_CONT: syscall.SIGCONT,
+	proto.Signal_SIGNAL_STOP: syscall.SIGSTOP,
+}
+
+// Start command in a process group of its own so it and everything it spawns can be signalled together. Commands on a
+// pseudo-terminal already lead a new session, which is also a new process group.
+func setProcessGroup(command *exec.Cmd) {
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Setpgid = true
+}
+
+// Deliver sig to process, or to its whole process group when group is set.
+func signalProcess(process *os.Process, sig proto.Signal, group bool) error {
+	s, ok := signals[sig]
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+
+	if group {
+		return syscall.Kill(-process.Pid, s)
+	}
+	return process.Signal(s)
+}
+
+// Kill process and every other process in its group.
+func killProcessTree(process *os.Process) error {
+	if err := syscall.Kill(-process.Pid, syscall.SIGKILL); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package commandserver
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// Windows has no pseudo-terminals, user switching or signals beyond killing a process.
+var platformCapabilities = []string{}
+
+// Windows has no process groups in the POSIX sense, so children are found through the process tree when killing instead.
+func setProcessGroup(command *exec.Cmd) {}
+
+// Windows can only terminate a process, so every signal other than SIGKILL is refused.
+func signalProcess(process *os.Process, sig proto.Signal, group bool) error {
+	if sig != proto.Signal_SIGNAL_KILL {
+		return fmt.Errorf("%v is not supported on windows", sig)
+	}
+
+	if group {
+		return killProcessTree(process)
+	}
+	return process.Kill()
+}
+
+// Kill process and all of its descendants.
+func killProcessTree(process *os.Process) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid)).Run(); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package commandserver
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/creack/pty"
+)
+
+// Start command on a new pseudo-terminal and return its controlling side. Programs on a terminal expect TERM to be set, so a
+// sensible default is provided when the command environment does not have one.
+func startPty(command *exec.Cmd, size *proto.WindowSize) (*os.File, error) {
+	env := command.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	if !slices.ContainsFunc(env, func(kv string) bool { return strings.HasPrefix(kv, "TERM=") }) {
+		command.Env = append(env, "TERM=xterm-256color")
+	}
+
+	if size == nil || size.GetRows() == 0 || size.GetCols() == 0 {
+		return pty.Start(command)
+	}
+
+	return pty.StartWithSize(command, &pty.Winsize{Rows: uint16(size.GetRows()), Cols: uint16(size.GetCols())})
+}
+
+// Change the size of a pseudo-terminal. The kernel lets the foreground process know with SIGWINCH.
+func resizePty(ptmx *os.File, size *proto.WindowSize) error {
+	return pty.Setsize(ptmx, &pty.Winsize{Rows: uint16(size.GetRows()), Cols: uint16(size.GetCols())})
+}
@@ -0,0 +1,53 @@
+package commandserver
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"google.golang.org/grpc"
+)
+
+// Bounds on the wait between attempts to reach the operator.
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// ServeReverse serves s to an operator instead of waiting for one to connect. It dials the listener at address and
+// multiplexes the TCP connection with yamux, with the operator opening streams and this side accepting them, so gRPC and
+// its TLS handshake run exactly as they would over a normal listener. Whenever the connection fails or drops it is dialled
+// again with exponential backoff. ServeReverse only returns once s has been stopped.
+func ServeReverse(s *grpc.Server, address string) error {
+	delay := minReconnectDelay
+	for {
+		conn, err := net.DialTimeout("tcp", address, 30*time.Second)
+		if err == nil {
+			session, err := yamux.Server(conn, nil)
+			if err != nil {
+				conn.Close()
+				return err
+			}
+
+			Logger.Infof("connected to operator at %s", address)
+			delay = minReconnectDelay
+			// Serve returns nil when s is stopped while the session is up, and ErrServerStopped when it was stopped before.
+			// A dropped session is reported as the error yamux gave back when accepting the next stream.
+			err = s.Serve(session)
+			if err == nil || errors.Is(err, grpc.ErrServerStopped) {
+				return nil
+			}
+			Logger.Warnf("lost connection to operator at %s: %v", address, err)
+		} else {
+			Logger.Warnf("failed to reach operator at %s: %v", address, err)
+		}
+
+		// Jitter the wait so a fleet of agents does not reconnect in lock step.
+		wait := delay/2 + rand.N(delay/2+1)
+		Logger.Infof("reconnecting to %s in %v", address, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
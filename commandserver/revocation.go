@@ -0,0 +1,130 @@
+package commandserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// A certificate revocation list on disk that is consulted on every TLS handshake. The file is re-read whenever its
+// modification time changes so a freshly generated list takes effect without restarting the server.
+type revocationList struct {
+	path    string
+	issuers []*x509.Certificate
+
+	mu      sync.Mutex
+	modTime time.Time
+	list    *x509.RevocationList
+	revoked map[string]struct{}
+}
+
+func newRevocationList(path string, caFile string) (*revocationList, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	r := &revocationList{path: path}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		r.issuers = append(r.issuers, cert)
+	}
+
+	// Fail at startup rather than on the first handshake if the list is unusable.
+	if _, err := r.current(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Return the revoked serial numbers, reloading the list from disk if it changed.
+func (r *revocationList) current() (map[string]struct{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat revocation list: %w", err)
+	}
+
+	if r.revoked != nil && info.ModTime().Equal(r.modTime) {
+		return r.revoked, nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revocation list: %w", err)
+	}
+
+	der := data
+	if block, _ := pem.Decode(data); block != nil {
+		der = block.Bytes
+	}
+
+	list, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse revocation list: %w", err)
+	}
+
+	signed := false
+	for _, issuer := range r.issuers {
+		if list.CheckSignatureFrom(issuer) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return nil, errors.New("revocation list is not signed by a trusted CA")
+	}
+
+	revoked := make(map[string]struct{}, len(list.RevokedCertificateEntries))
+	for _, entry := range list.RevokedCertificateEntries {
+		revoked[entry.SerialNumber.String()] = struct{}{}
+	}
+
+	r.modTime = info.ModTime()
+	r.list = list
+	r.revoked = revoked
+	return revoked, nil
+}
+
+// Reject the handshake if the client certificate has been revoked. An unreadable or expired list fails closed.
+func (r *revocationList) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no client certificate presented")
+	}
+
+	revoked, err := r.current()
+	if err != nil {
+		Logger.Warnf("Rejecting handshake, revocation list unavailable: %v", err)
+		return err
+	}
+
+	r.mu.Lock()
+	nextUpdate := r.list.NextUpdate
+	r.mu.Unlock()
+	if !nextUpdate.IsZero() && time.Now().After(nextUpdate) {
+		Logger.Warnf("Rejecting handshake, revocation list expired at %v", nextUpdate)
+		return errors.New("revocation list has expired")
+	}
+
+	leaf := cs.PeerCertificates[0]
+	if _, ok := revoked[leaf.SerialNumber.String()]; ok {
+		Logger.Warnf("Rejecting revoked client certificate %s (serial %x)", leaf.Subject.CommonName, leaf.SerialNumber)
+		return fmt.Errorf("certificate %x has been revoked", leaf.SerialNumber)
+	}
+
+	return nil
+}
@@ -2,106 +2,78 @@ package commandserver
 
 import (
 	"fmt"
-	"io"
-	"os/exec"
 	"strings"
-	"syscall"
+	"sync/atomic"
+	"time"
 
+	"github.com/apoindevster/bitwarp/audit"
 	"github.com/apoindevster/bitwarp/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
-func readOutputPipe(pipe io.ReadCloser, output chan []byte) {
-	buf := make([]byte, 1024)
-	for {
-		read, err := pipe.Read(buf)
-		if err != nil {
-			break
-		}
-		output <- buf[:read]
+func (s *Server) RunExecutable(stream grpc.BidiStreamingServer[proto.RunExecutableInput, proto.RunExecutableResult]) error {
+	start := time.Now()
+	entry := audit.Entry{RPC: "RunExecutable"}
+	err := s.runExecutable(stream, &entry)
+	if entry.Error == "" {
+		entry.Error = auditError(err)
 	}
-	close(output)
+	s.recordAudit(stream.Context(), start, entry)
+	return err
 }
 
-func (s *Server) RunExecutable(stream grpc.BidiStreamingServer[proto.RunExecutableInput, proto.RunExecutableResult]) error {
+func (s *Server) runExecutable(stream grpc.BidiStreamingServer[proto.RunExecutableInput, proto.RunExecutableResult], entry *audit.Entry) error {
 	options, err := stream.Recv()
 	if err != nil {
 		Logger.Warn("Failed to get which command to run")
 		return err
 	}
 
-	Logger.Infof("Starting command %s %s", options.GetOptions().Command, strings.Join(options.GetOptions().Args, " "))
-	command := exec.Command(options.GetOptions().Command, options.GetOptions().Args...)
-
-	stdout, err := command.StdoutPipe()
-	if err != nil {
-		stream.Send(&proto.RunExecutableResult{Stderr: []byte(fmt.Sprintf("Failed to create stdout pipe with error: %v", err)), ReturnCode: -1})
-		Logger.Warn("Failed to create pipe for stdout")
-		return nil
-	}
+	entry.Command = options.GetOptions().Command
+	entry.Args = options.GetOptions().Args
+	entry.Cwd = options.GetOptions().Cwd
+	entry.User = options.GetOptions().User
 
-	stderr, err := command.StderrPipe()
-	if err != nil {
-		stream.Send(&proto.RunExecutableResult{Stderr: []byte(fmt.Sprintf("Failed to create stderr pipe with error: %v", err)), ReturnCode: -1})
-		Logger.Warn("Failed to create pipe for stderr")
-		return nil
+	caller := callerName(stream.Context())
+	id, _ := IdentityFromContext(stream.Context())
+	if err := s.Policy.AuthorizeExec(id, options.GetOptions()); err != nil {
+		Logger.Warnf("Denied: %v", status.Convert(err).Message())
+		return err
 	}
 
-	var returnCode int = 0
-	err = command.Start()
+	Logger.Infof("%s starting command %s %s", caller, options.GetOptions().Command, strings.Join(options.GetOptions().Args, " "))
+	j, reader, err := startJob(caller, options.GetOptions())
 	if err != nil {
 		stream.Send(&proto.RunExecutableResult{Stderr: []byte(fmt.Sprintf("Failed to start command with error: %v", err)), ReturnCode: -1})
-		Logger.Warnf("Failed to start the command: %s with args: %s", options.GetOptions().Command, options.GetOptions().Args)
+		entry.Error = err.Error()
+		Logger.Warnf("Failed to start the command: %s with args: %s: %v", options.GetOptions().Command, options.GetOptions().Args, err)
 		return nil
 	}
+	s.jobs.add(j)
+	entry.Job = j.id
 
-	sout := make(chan []byte)
-	serr := make(chan []byte)
-	go readOutputPipe(stdout, sout)
-	go readOutputPipe(stderr, serr)
-
-	stdoutFinished := false
-	stderrFinished := false
-finished:
-	for {
-		select {
-		case output, ok := <-sout:
-			if ok {
-				stream.Send(&proto.RunExecutableResult{Stdout: output})
-			} else {
-				stdoutFinished = true
-			}
-		case output, ok := <-serr:
-			if ok {
-				stream.Send(&proto.RunExecutableResult{Stderr: output})
-			} else {
-				stderrFinished = true
-			}
-		}
-
-		if (stdoutFinished && stderrFinished) || command.ProcessState != nil {
-			break finished
-		}
+	var bytesIn atomic.Int64
+	detached, err := j.serve(stream, reader, options.GetOptions().GetCompression(), &bytesIn, &entry.BytesOut)
+	entry.BytesIn = bytesIn.Load()
+	if detached {
+		Logger.Infof("%s detached from job %s", caller, j.id)
+		return nil
 	}
-	Logger.Infof("Finishing command %s %s", options.GetOptions().Command, strings.Join(options.GetOptions().Args, " "))
-	err = command.Wait()
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			// We were able to cast the error. Now see if we can get the wait status
-			if ws, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				// We were able to get the Wait status so we should be able to get the return code
-				returnCode = ws.ExitStatus()
-			} else {
-				stream.Send(&proto.RunExecutableResult{Stderr: []byte(fmt.Sprintf("Failed to get exit status from the command with error: %v", err)), ReturnCode: -1})
-				Logger.Warn("Failed to get the Exit status from the command.")
-				return nil
-			}
-		} else {
-			stream.Send(&proto.RunExecutableResult{Stderr: []byte(fmt.Sprintf("Failed to cast the error to an ExitError with error: %v", err)), ReturnCode: -1})
-			return nil
+		if j.isDetached() {
+			return err
 		}
+		// Nobody is left to read the output, so the command and everything it started is killed.
+		j.kill()
+		entry.Error = "client went away, command killed"
+		Logger.Warnf("Client of %s went away, killed the command", options.GetOptions().Command)
+		return err
 	}
 
-	stream.Send(&proto.RunExecutableResult{ReturnCode: int32(returnCode)})
+	Logger.Infof("%s finishing command %s %s", caller, options.GetOptions().Command, strings.Join(options.GetOptions().Args, " "))
+	_, code, failure := j.result()
+	entry.ExitCode = &code
+	entry.Error = failure
 	return nil
 }
@@ -0,0 +1,46 @@
+//go:build !windows
+
+package commandserver
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// Make command run with the credentials of the named user, given by name or uid.
+func runAs(command *exec.Cmd, name string) (*user.User, error) {
+	account, err := user.Lookup(name)
+	if err != nil {
+		account, err = user.LookupId(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown user %s", name)
+		}
+	}
+
+	uid, err := strconv.ParseUint(account.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s has a non numeric uid %s", name, account.Uid)
+	}
+	gid, err := strconv.ParseUint(account.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s has a non numeric gid %s", name, account.Gid)
+	}
+
+	var groups []uint32
+	if ids, err := account.GroupIds(); err == nil {
+		for _, id := range ids {
+			if g, err := strconv.ParseUint(id, 10, 32); err == nil {
+				groups = append(groups, uint32(g))
+			}
+		}
+	}
+
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups}
+	return account, nil
+}
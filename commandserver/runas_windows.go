@@ -0,0 +1,14 @@
+//go:build windows
+
+package commandserver
+
+import (
+	"errors"
+	"os/exec"
+	"os/user"
+)
+
+// Switching users needs a logon token on Windows, which BitWarp does not support.
+func runAs(command *exec.Cmd, name string) (*user.User, error) {
+	return nil, errors.New("running commands as another user is not supported on windows")
+}
@@ -4,12 +4,24 @@ import (
 	"errors"
 	"os"
 
+	"github.com/apoindevster/bitwarp/audit"
 	"github.com/apoindevster/bitwarp/proto"
 	log "github.com/charmbracelet/log"
+	"github.com/google/uuid"
 )
 
 type Server struct {
 	proto.UnimplementedCommandServer
+
+	// ID identifies the server to clients through GetConnectionParams. See LoadOrCreateID.
+	ID uuid.UUID
+
+	// Policy authorizes RunExecutable and the file RPCs. A nil policy allows everything.
+	Policy *PolicyEngine
+	// Audit records every remote action. A nil log disables auditing.
+	Audit *audit.Log
+
+	jobs jobRegistry
 }
 
 var logFile *os.File = nil
@@ -0,0 +1,98 @@
+package commandserver
+
+import (
+	"os"
+	"time"
+
+	"github.com/apoindevster/bitwarp/audit"
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/delta"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/tree"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Counts the literal data going each way through a Sync stream for the audit log.
+type syncStream struct {
+	grpc.BidiStreamingServer[proto.SyncMessage, proto.SyncMessage]
+	entry *audit.Entry
+}
+
+func (s syncStream) Send(m *proto.SyncMessage) error {
+	s.entry.BytesOut += int64(len(m.GetData().GetChunk()))
+	return s.BidiStreamingServer.Send(m)
+}
+
+func (s syncStream) Recv() (*proto.SyncMessage, error) {
+	m, err := s.BidiStreamingServer.Recv()
+	s.entry.BytesIn += int64(len(m.GetData().GetChunk()))
+	return m, err
+}
+
+func (s *Server) Sync(stream grpc.BidiStreamingServer[proto.SyncMessage, proto.SyncMessage]) error {
+	start := time.Now()
+	entry := audit.Entry{RPC: "Sync"}
+	err := s.sync(syncStream{stream, &entry}, &entry)
+	entry.Error = auditError(err)
+	s.recordAudit(stream.Context(), start, entry)
+	return err
+}
+
+func (s *Server) sync(stream syncStream, entry *audit.Entry) error {
+	m, err := stream.Recv()
+	if err != nil {
+		Logger.Warn("Failed to get what to sync")
+		return err
+	}
+	request := m.GetRequest()
+	if request == nil {
+		return status.Error(codes.InvalidArgument, "the first message has to be the request")
+	}
+
+	expPath := os.ExpandEnv(request.GetPath())
+	entry.Paths = []string{expPath}
+	id, _ := IdentityFromContext(stream.Context())
+
+	if request.GetDirection() == proto.SyncDirection_SYNC_PULL {
+		if err := s.Policy.AuthorizeDownload(id, expPath); err != nil {
+			Logger.Warnf("Denied: %v", status.Convert(err).Message())
+			return err
+		}
+		filter := tree.Filter{Include: request.GetInclude(), Exclude: request.GetExclude()}
+		if err := filter.Validate(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		if _, err := os.Stat(expPath); err != nil {
+			Logger.Warnf("Failed to stat path with error: %v", err)
+			return fsError(err)
+		}
+
+		Logger.Infof("%s syncing from %s", callerName(stream.Context()), expPath)
+		compression := codec.Negotiate(codec.Supported, request.GetCompression())
+		if err := delta.Send(stream, expPath, filter, compression); err != nil {
+			Logger.Warnf("Failed to sync from %s with error: %v", expPath, err)
+			return err
+		}
+		Logger.Infof("Synced %s, sending %d bytes", expPath, entry.BytesOut)
+		return nil
+	}
+
+	if err := s.Policy.AuthorizeUpload(id, expPath); err != nil {
+		Logger.Warnf("Denied: %v", status.Convert(err).Message())
+		return err
+	}
+
+	Logger.Infof("%s syncing to %s", callerName(stream.Context()), expPath)
+	result, err := delta.Receive(stream, expPath, request.GetDryRun())
+	if err != nil {
+		Logger.Warnf("Failed to sync to %s with error: %v", expPath, err)
+		if _, ok := status.FromError(err); !ok {
+			err = status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return err
+	}
+	Logger.Infof("Synced %s, %d changes with %d literal and %d matched bytes", expPath, len(result.GetChanges()), result.GetLiteralBytes(), result.GetMatchedBytes())
+	return stream.Send(&proto.SyncMessage{Result: result})
+}
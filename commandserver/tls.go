@@ -0,0 +1,62 @@
+package commandserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Load the CA bundle at caFile into a certificate pool used to verify peers.
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in CA bundle")
+	}
+
+	return pool, nil
+}
+
+// LoadServerTLSConfig builds a mutual TLS configuration for the Command service. The server presents the certificate and key
+// found at certFile and keyFile, and every client must present a certificate signed by a CA in the caFile bundle. When
+// crlFile is not empty, the revocation list it points to is consulted on every handshake.
+func LoadServerTLSConfig(certFile string, keyFile string, caFile string, crlFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("a certificate, key and CA bundle are all required for mutual TLS")
+	}
+	// Expanded the same way as on the client, so one config works for both.
+	certFile, keyFile, caFile, crlFile = os.ExpandEnv(certFile), os.ExpandEnv(keyFile), os.ExpandEnv(caFile), os.ExpandEnv(crlFile)
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server key pair: %w", err)
+	}
+
+	pool, err := loadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if crlFile != "" {
+		crl, err := newRevocationList(crlFile, caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.VerifyConnection = crl.verifyConnection
+	}
+
+	return config, nil
+}
@@ -0,0 +1,116 @@
+package commandserver
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/apoindevster/bitwarp/audit"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/tree"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) TreeDownload(in *proto.TreeRequest, stream grpc.ServerStreamingServer[proto.TreeChunk]) error {
+	start := time.Now()
+	entry := audit.Entry{RPC: "TreeDownload"}
+	err := s.treeDownload(in, stream, &entry)
+	entry.Error = auditError(err)
+	s.recordAudit(stream.Context(), start, entry)
+	return err
+}
+
+func (s *Server) treeDownload(in *proto.TreeRequest, stream grpc.ServerStreamingServer[proto.TreeChunk], entry *audit.Entry) error {
+	expPath := os.ExpandEnv(in.GetPath())
+	entry.Paths = []string{expPath}
+	id, _ := IdentityFromContext(stream.Context())
+	if err := s.Policy.AuthorizeDownload(id, expPath); err != nil {
+		Logger.Warnf("Denied: %v", status.Convert(err).Message())
+		return err
+	}
+
+	info, err := os.Stat(expPath)
+	if err != nil {
+		Logger.Warnf("Failed to stat directory with error: %v\n", err)
+		return fsError(err)
+	}
+	if !info.IsDir() {
+		return status.Errorf(codes.InvalidArgument, "%s is not a directory", expPath)
+	}
+
+	Logger.Infof("%s downloading the tree under %s", callerName(stream.Context()), expPath)
+	filter := tree.Filter{Include: in.GetInclude(), Exclude: in.GetExclude()}
+	if err := filter.Validate(); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	summary, err := tree.Walk(expPath, filter, func(chunk *proto.TreeChunk) error {
+		entry.BytesOut += int64(len(chunk.GetData()))
+		return stream.Send(chunk)
+	})
+	if err != nil {
+		Logger.Warnf("Failed to send tree with error: %v", err)
+		return err
+	}
+
+	Logger.Infof("Sent %d files, %d directories and %d symlinks from %s", summary.GetFiles(), summary.GetDirs(), summary.GetSymlinks(), expPath)
+	return nil
+}
+
+func (s *Server) TreeUpload(stream grpc.ClientStreamingServer[proto.TreeChunk, proto.TreeSummary]) error {
+	start := time.Now()
+	entry := audit.Entry{RPC: "TreeUpload"}
+	err := s.treeUpload(stream, &entry)
+	entry.Error = auditError(err)
+	s.recordAudit(stream.Context(), start, entry)
+	return err
+}
+
+func (s *Server) treeUpload(stream grpc.ClientStreamingServer[proto.TreeChunk, proto.TreeSummary], entry *audit.Entry) error {
+	m, err := stream.Recv()
+	if err != nil {
+		Logger.Warn("Failed to get where to upload the tree to")
+		return err
+	}
+
+	expPath := os.ExpandEnv(m.GetRequest().GetPath())
+	entry.Paths = []string{expPath}
+	id, _ := IdentityFromContext(stream.Context())
+	if err := s.Policy.AuthorizeUpload(id, expPath); err != nil {
+		Logger.Warnf("Denied: %v", status.Convert(err).Message())
+		return err
+	}
+
+	w, err := tree.NewWriter(expPath)
+	if err != nil {
+		Logger.Warnf("Failed to create directory with error: %v", err)
+		return err
+	}
+
+	Logger.Infof("%s uploading a tree to %s", callerName(stream.Context()), expPath)
+	for {
+		if err := w.Write(m); err != nil {
+			w.Close()
+			Logger.Warnf("Failed to write tree with error: %v", err)
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		entry.BytesIn += int64(len(m.GetData()))
+
+		m, err = stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			w.Close()
+			Logger.Warnf("Failed tree upload with err: %v\n", err)
+			return err
+		}
+	}
+
+	summary, err := w.Close()
+	if err != nil {
+		Logger.Warnf("Failed to finish tree with error: %v", err)
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return stream.SendAndClose(summary)
+}
@@ -0,0 +1,259 @@
+package delta
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/tree"
+)
+
+// One end of an in-memory Sync stream.
+type pipeConn struct {
+	in  <-chan *proto.SyncMessage
+	out chan<- *proto.SyncMessage
+
+	// Block copies sent through this end.
+	copies int
+}
+
+func (c *pipeConn) Send(m *proto.SyncMessage) error {
+	if m.GetCopy() != nil {
+		c.copies++
+	}
+	c.out <- m
+	return nil
+}
+
+func (c *pipeConn) Recv() (*proto.SyncMessage, error) {
+	m, ok := <-c.in
+	if !ok {
+		return nil, io.EOF
+	}
+	return m, nil
+}
+
+func newPipe() (*pipeConn, *pipeConn) {
+	a, b := make(chan *proto.SyncMessage, 64), make(chan *proto.SyncMessage, 64)
+	return &pipeConn{in: a, out: b}, &pipeConn{in: b, out: a}
+}
+
+// A conn that hands out a fixed list of messages.
+type scriptConn struct {
+	messages []*proto.SyncMessage
+}
+
+func (c *scriptConn) Send(*proto.SyncMessage) error {
+	return nil
+}
+
+func (c *scriptConn) Recv() (*proto.SyncMessage, error) {
+	if len(c.messages) == 0 {
+		return nil, io.EOF
+	}
+	m := c.messages[0]
+	c.messages = c.messages[1:]
+	return m, nil
+}
+
+func randomBytes(r *rand.Rand, n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(r.UintN(256))
+	}
+	return data
+}
+
+// Sync src over dst, returning what the receiver reports and how many block copies the sender sent.
+func syncFile(t *testing.T, src string, dst string) (*proto.SyncResult, int) {
+	t.Helper()
+	sender, receiver := newPipe()
+	sent := make(chan error, 1)
+	go func() { sent <- Send(sender, src, tree.Filter{}, proto.Compression_COMPRESSION_NONE) }()
+
+	result, err := Receive(receiver, dst, false)
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if err := <-sent; err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	return result, sender.copies
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	base := randomBytes(r, 300_001)
+	bs := int64(blockSize(int64(len(base))))
+	full := int64(len(base)) / bs * bs
+
+	tests := []struct {
+		name   string
+		basis  []byte
+		source []byte
+		// Bytes expected to be copied from the basis, and the most block copy messages that may carry them.
+		matched int64
+		copies  int
+	}{
+		{name: "identical", basis: base, source: base, matched: int64(len(base)), copies: 1},
+		{name: "appended", basis: base, source: append(append([]byte{}, base...), randomBytes(r, 5000)...), matched: full, copies: 1},
+		{name: "truncated", basis: base, source: base[:len(base)-7000], matched: int64(len(base)-7000) / bs * bs, copies: 1},
+		{name: "prefix inserted", basis: base, source: append(randomBytes(r, 1000), base...), matched: int64(len(base)), copies: 1},
+		{name: "repeated blocks", basis: make([]byte, 10*bs), source: make([]byte, 10*bs), matched: 10 * bs, copies: 1},
+		{name: "no basis", basis: nil, source: base, matched: 0, copies: 0},
+		{name: "both empty", basis: []byte{}, source: []byte{}, matched: 0, copies: 0},
+		{name: "emptied", basis: base, source: []byte{}, matched: 0, copies: 0},
+		{name: "filled", basis: []byte{}, source: base, matched: 0, copies: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+			if err := os.WriteFile(src, test.source, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if test.basis != nil {
+				if err := os.WriteFile(dst, test.basis, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				// An older copy, so it is not skipped as unchanged.
+				old := time.Now().Add(-time.Hour)
+				if err := os.Chtimes(dst, old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, copies := syncFile(t, src, dst)
+
+			got, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, test.source) {
+				t.Fatalf("rebuilt file differs from the source: %d bytes, expected %d", len(got), len(test.source))
+			}
+			if result.GetMatchedBytes() != test.matched {
+				t.Errorf("matched %d bytes, expected %d", result.GetMatchedBytes(), test.matched)
+			}
+			if literal := int64(len(test.source)) - test.matched; result.GetLiteralBytes() != literal {
+				t.Errorf("sent %d literal bytes, expected %d", result.GetLiteralBytes(), literal)
+			}
+			if copies > test.copies {
+				t.Errorf("sent %d block copies, expected at most %d", copies, test.copies)
+			}
+			if _, err := os.Lstat(dst + partSuffix); err == nil {
+				t.Errorf("part file left behind")
+			}
+		})
+	}
+}
+
+func TestUnchangedFileIsSkipped(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	data := []byte("unchanged")
+	for _, p := range []string{src, dst} {
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	for _, p := range []string{src, dst} {
+		if err := os.Chtimes(p, now, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, _ := syncFile(t, src, dst)
+	if result.GetUnchanged() != 1 || result.GetMatchedBytes() != 0 || result.GetLiteralBytes() != 0 {
+		t.Errorf("got %+v, expected the file to be skipped", result)
+	}
+}
+
+func TestPartSymlinkIsNotFollowed(t *testing.T) {
+	dir := t.TempDir()
+	src, dst, outside := filepath.Join(dir, "src"), filepath.Join(dir, "dst"), filepath.Join(dir, "outside")
+	if err := os.WriteFile(src, []byte("new contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(outside, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, dst+partSuffix); err != nil {
+		t.Fatal(err)
+	}
+
+	syncFile(t, src, dst)
+	if got, _ := os.ReadFile(outside); string(got) != "keep" {
+		t.Errorf("wrote %q through the part symlink", got)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "new contents" {
+		t.Errorf("got %q, expected the source", got)
+	}
+}
+
+func TestRollingMatchesFreshSum(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	data := randomBytes(r, 4096)
+	const window = 512
+	rolled := newRolling(data[:window])
+	for i := 1; i+window <= len(data); i++ {
+		rolled.roll(data[i-1], data[i+window-1])
+		if want := newRolling(data[i : i+window]).sum(); rolled.sum() != want {
+			t.Fatalf("rolled sum at %d is %x, expected %x", i, rolled.sum(), want)
+		}
+	}
+}
+
+func TestRecvSignaturesChecksCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int64
+		blocks int
+		ok     bool
+	}{
+		{name: "exact", size: 2 * minBlockSize, blocks: 2, ok: true},
+		{name: "short last block", size: 2*minBlockSize + 1, blocks: 3, ok: true},
+		{name: "empty", size: 0, blocks: 0, ok: true},
+		{name: "missing block", size: 2*minBlockSize + 1, blocks: 2},
+		{name: "extra block", size: minBlockSize, blocks: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signatures := &proto.BlockSignatures{BlockSize: minBlockSize, Size: test.size, Last: true}
+			for i := 0; i < test.blocks; i++ {
+				signatures.Blocks = append(signatures.Blocks, &proto.BlockSignature{Weak: uint32(i), Strong: make([]byte, strongSize)})
+			}
+			_, err := recvSignatures(&scriptConn{messages: []*proto.SyncMessage{{Signatures: signatures}}})
+			if (err == nil) != test.ok {
+				t.Errorf("got error %v, expected ok %v", err, test.ok)
+			}
+		})
+	}
+}
+
+func TestFindPrefersHint(t *testing.T) {
+	block := make([]byte, minBlockSize)
+	x := &index{blockSize: minBlockSize, size: 3 * minBlockSize, weak: map[uint32][]int64{}}
+	weak := newRolling(block).sum()
+	for i := int64(0); i < 3; i++ {
+		x.weak[weak] = append(x.weak[weak], i)
+		x.strong = append(x.strong, strongSum(block))
+	}
+
+	if got := x.find(weak, block, 2); got != 2 {
+		t.Errorf("got block %d, expected the hint 2", got)
+	}
+	if got := x.find(weak, block, -1); got != 0 {
+		t.Errorf("got block %d, expected the first match 0", got)
+	}
+	if got := x.find(weak, block[:10], -1); got != -1 {
+		t.Errorf("got block %d for a window of the wrong length", got)
+	}
+}
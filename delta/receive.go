@@ -0,0 +1,244 @@
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/tree"
+)
+
+// Files are rebuilt next to their destination under this suffix, and only renamed into place once their checksum
+// matches.
+const partSuffix = ".bitwarp-part"
+
+type receiver struct {
+	conn   Conn
+	root   string
+	dryRun bool
+
+	// Applies directories and symlinks, created once the first one arrives.
+	w *tree.Writer
+
+	result proto.SyncResult
+}
+
+// Receive brings root up to date with the tree sent by Send on the other end of conn and returns what changed. With
+// dryRun nothing is written, and the result lists what would have changed instead.
+func Receive(conn Conn, root string, dryRun bool) (*proto.SyncResult, error) {
+	rc := &receiver{conn: conn, root: root, dryRun: dryRun}
+	for {
+		m, err := conn.Recv()
+		if err == io.EOF {
+			return nil, errors.New("sync ended before the whole tree was sent")
+		} else if err != nil {
+			return nil, err
+		}
+		if m.GetDone() {
+			break
+		}
+		if m.GetEntry() == nil {
+			return nil, errors.New("expected an entry")
+		}
+		if err := rc.apply(m.GetEntry()); err != nil {
+			if rc.w != nil {
+				rc.w.Close()
+			}
+			return nil, err
+		}
+	}
+
+	if rc.w != nil {
+		if _, err := rc.w.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return &rc.result, nil
+}
+
+func (rc *receiver) change(entry *proto.TreeEntry, exists bool) {
+	action := proto.SyncAction_SYNC_CREATE
+	if exists {
+		action = proto.SyncAction_SYNC_UPDATE
+	}
+	rc.result.Changes = append(rc.result.Changes, &proto.SyncChange{Path: entry.GetPath(), Type: entry.GetType(), Action: action})
+}
+
+// Hand entry to the tree writer, creating it first if this is the first entry that needs it.
+func (rc *receiver) write(entry *proto.TreeEntry) error {
+	if rc.w == nil {
+		w, err := tree.NewWriter(rc.root)
+		if err != nil {
+			return err
+		}
+		rc.w = w
+	}
+	return rc.w.Write(&proto.TreeChunk{Entry: entry})
+}
+
+func (rc *receiver) apply(entry *proto.TreeEntry) error {
+	if entry.GetPath() == "." && entry.GetType() == proto.EntryType_ENTRY_SYMLINK {
+		return fmt.Errorf("cannot sync the symlink %s itself", rc.root)
+	}
+	local, err := tree.LocalPath(rc.root, entry.GetPath())
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(local)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	switch entry.GetType() {
+	case proto.EntryType_ENTRY_DIR:
+		if !exists || !info.IsDir() {
+			rc.change(entry, exists)
+		}
+		if rc.dryRun {
+			return nil
+		}
+		// Directories go through the writer even when they exist, so they get their mode and modification time.
+		return rc.write(entry)
+	case proto.EntryType_ENTRY_SYMLINK:
+		if exists && info.Mode()&fs.ModeSymlink != 0 {
+			if target, err := os.Readlink(local); err == nil && target == entry.GetLinkTarget() {
+				return nil
+			}
+		}
+		rc.change(entry, exists)
+		if rc.dryRun {
+			return nil
+		}
+		return rc.write(entry)
+	case proto.EntryType_ENTRY_FILE:
+		if exists && info.IsDir() {
+			return fmt.Errorf("%s is a directory", local)
+		}
+		if exists && info.Mode().IsRegular() && info.Size() == entry.GetSize() && info.ModTime().Equal(entry.GetModTime().AsTime()) {
+			rc.result.Unchanged++
+			return rc.conn.Send(&proto.SyncMessage{Skip: true})
+		}
+		rc.change(entry, exists)
+		if rc.dryRun {
+			return rc.conn.Send(&proto.SyncMessage{Skip: true})
+		}
+		var basis *os.File
+		if exists && info.Mode().IsRegular() {
+			if basis, err = os.Open(local); err != nil {
+				return err
+			}
+			defer basis.Close()
+		}
+		return rc.receiveFile(local, entry, basis)
+	}
+	return fmt.Errorf("unknown type %v for entry %q", entry.GetType(), entry.GetPath())
+}
+
+// Send the signatures of basis, the current copy of the file if there is one, and rebuild the file from the delta that
+// comes back.
+func (rc *receiver) receiveFile(local string, entry *proto.TreeEntry, basis *os.File) (err error) {
+	var size int64
+	var r io.Reader
+	if basis != nil {
+		info, err := basis.Stat()
+		if err != nil {
+			return err
+		}
+		size, r = info.Size(), basis
+	}
+	bs, err := sendSignatures(rc.conn, r, size)
+	if err != nil {
+		return err
+	}
+	blocks := (size + int64(bs) - 1) / int64(bs)
+
+	if err := os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+		return err
+	}
+	// Whatever is at the part name goes first, and O_EXCL refuses to follow a symlink the sender may have put there, which
+	// could point anywhere outside root.
+	if err := os.Remove(local + partSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	part, err := os.OpenFile(local+partSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			part.Close()
+			os.Remove(part.Name())
+		}
+	}()
+
+	h := sha256.New()
+	out := bufio.NewWriterSize(io.MultiWriter(part, h), chunkSize)
+	block := make([]byte, bs)
+	var pos int64
+	for {
+		m, err := rc.conn.Recv()
+		if err == io.EOF {
+			return fmt.Errorf("%s ended before its checksum arrived", entry.GetPath())
+		} else if err != nil {
+			return err
+		}
+
+		if run := m.GetCopy(); run != nil {
+			if run.GetIndex() < 0 || run.GetCount() <= 0 || run.GetIndex()+run.GetCount() > blocks {
+				return fmt.Errorf("copy of blocks %d+%d is out of range for %s", run.GetIndex(), run.GetCount(), entry.GetPath())
+			}
+			for i := run.GetIndex(); i < run.GetIndex()+run.GetCount(); i++ {
+				n := min(int64(bs), size-i*int64(bs))
+				if _, err := basis.ReadAt(block[:n], i*int64(bs)); err != nil {
+					return err
+				}
+				out.Write(block[:n])
+				pos += n
+				rc.result.MatchedBytes += n
+			}
+			continue
+		}
+
+		chunk := m.GetData()
+		if chunk == nil {
+			return fmt.Errorf("expected the contents of %s", entry.GetPath())
+		}
+		if chunk.GetOffset() != pos {
+			return fmt.Errorf("data for offset %d of %s arrived at offset %d", chunk.GetOffset(), entry.GetPath(), pos)
+		}
+		data, err := codec.Decode(chunk.GetCompression(), chunk.GetChunk())
+		if err != nil {
+			return fmt.Errorf("failed to decode data at offset %d of %s: %v", chunk.GetOffset(), entry.GetPath(), err)
+		}
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+		pos += int64(len(data))
+		rc.result.LiteralBytes += int64(len(data))
+
+		if chunk.GetSha256() != nil {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			if sum := h.Sum(nil); !bytes.Equal(sum, chunk.GetSha256()) {
+				return fmt.Errorf("checksum mismatch rebuilding %s: got %x, expected %x", entry.GetPath(), sum, chunk.GetSha256())
+			}
+			if err := part.Close(); err != nil {
+				return err
+			}
+			if err := tree.SetAttributes(part.Name(), entry); err != nil {
+				return err
+			}
+			return os.Rename(part.Name(), local)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package delta
+
+import (
+	"crypto/sha256"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/apoindevster/bitwarp/codec"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/tree"
+)
+
+// Size of the literal data messages.
+const chunkSize = 1024 * 1000
+
+// Conn is either end of a Sync stream.
+type Conn interface {
+	Send(*proto.SyncMessage) error
+	Recv() (*proto.SyncMessage, error)
+}
+
+// Send walks the tree under root, which may also be a single file, and brings the receiver on the other end of conn
+// up to date with it. Only the parts of files the receiver does not already have are sent, as literal data compressed
+// with compression.
+func Send(conn Conn, root string, filter tree.Filter, compression proto.Compression) error {
+	err := tree.Visit(root, filter, func(local string, entry *proto.TreeEntry) error {
+		if err := conn.Send(&proto.SyncMessage{Entry: entry}); err != nil {
+			return err
+		}
+		if entry.GetType() != proto.EntryType_ENTRY_FILE {
+			return nil
+		}
+
+		x, err := recvSignatures(conn)
+		if err != nil || x == nil {
+			return err
+		}
+		return sendDelta(conn, local, x, compression)
+	})
+	if err != nil {
+		return err
+	}
+	return conn.Send(&proto.SyncMessage{Done: true})
+}
+
+// Turns the contents of a file into block copies and literal data.
+type encoder struct {
+	conn        Conn
+	compression proto.Compression
+
+	// Offset in the file of what has been handed over so far, and its hash.
+	pos int64
+	h   hash.Hash
+
+	// Blocks and literal data waiting to be sent. Only one of them is ever pending.
+	run     proto.BlockCopy
+	literal []byte
+}
+
+func (e *encoder) copyBlock(i int64, block []byte) error {
+	if err := e.flushLiteral(false); err != nil {
+		return err
+	}
+	if e.run.Count > 0 && e.run.Index+e.run.Count != i {
+		if err := e.flushCopy(); err != nil {
+			return err
+		}
+	}
+	if e.run.Count == 0 {
+		e.run.Index = i
+	}
+	e.run.Count++
+	e.h.Write(block)
+	e.pos += int64(len(block))
+	return nil
+}
+
+func (e *encoder) addLiteral(data []byte) error {
+	if err := e.flushCopy(); err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		n := min(len(data), chunkSize-len(e.literal))
+		e.literal = append(e.literal, data[:n]...)
+		data = data[n:]
+		if len(e.literal) == chunkSize {
+			if err := e.flushLiteral(false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *encoder) flushCopy() error {
+	if e.run.Count == 0 {
+		return nil
+	}
+	run := &proto.BlockCopy{Index: e.run.Index, Count: e.run.Count}
+	e.run.Count = 0
+	return e.conn.Send(&proto.SyncMessage{Copy: run})
+}
+
+// Send the pending literal data. The last message of a file is always sent, even when empty, since it carries the
+// SHA-256 of the whole file.
+func (e *encoder) flushLiteral(last bool) error {
+	if len(e.literal) == 0 && !last {
+		return nil
+	}
+	chunk := &proto.FileChunk{Offset: e.pos}
+	chunk.Chunk, chunk.Compression = codec.Encode(e.compression, e.literal)
+	e.h.Write(e.literal)
+	e.pos += int64(len(e.literal))
+	if last {
+		chunk.Sha256 = e.h.Sum(nil)
+	}
+	if err := e.conn.Send(&proto.SyncMessage{Data: chunk}); err != nil {
+		return err
+	}
+	e.literal = e.literal[:0]
+	return nil
+}
+
+// Send the file at local as a delta against the receiver's blocks in x, ending with the SHA-256 of the whole file.
+func sendDelta(conn Conn, local string, x *index, compression proto.Compression) error {
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	e := &encoder{conn: conn, compression: compression, h: sha256.New(), literal: make([]byte, 0, chunkSize)}
+	bs := x.blockSize
+
+	// buf holds the window being matched at its start, followed by what has been read past it.
+	buf := make([]byte, 0, 2*bs+chunkSize)
+	eof := false
+	fill := func() error {
+		for len(buf) <= bs && !eof {
+			if cap(buf)-len(buf) < chunkSize {
+				grown := make([]byte, len(buf), len(buf)+bs+chunkSize)
+				copy(grown, buf)
+				buf = grown
+			}
+			n, err := f.Read(buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(x.strong) > 0 {
+		var r rolling
+		fresh, hint := true, int64(0)
+		for {
+			if err := fill(); err != nil {
+				return err
+			}
+			if len(buf) < bs {
+				break
+			}
+			if fresh {
+				r, fresh = newRolling(buf[:bs]), false
+			}
+			if i := x.find(r.sum(), buf[:bs], hint); i >= 0 {
+				if err := e.copyBlock(i, buf[:bs]); err != nil {
+					return err
+				}
+				buf, fresh, hint = buf[bs:], true, i+1
+				continue
+			}
+			if len(buf) == bs {
+				// Nothing follows the window to slide it onto.
+				break
+			}
+			if err := e.addLiteral(buf[:1]); err != nil {
+				return err
+			}
+			r.roll(buf[0], buf[bs])
+			buf = buf[1:]
+		}
+
+		// What is left is shorter than a block, but may still be the receiver's short last block.
+		if len(buf) > 0 && len(buf) < bs {
+			if i := x.find(newRolling(buf).sum(), buf, -1); i >= 0 {
+				if err := e.copyBlock(i, buf); err != nil {
+					return err
+				}
+				buf = buf[:0]
+			}
+		}
+	}
+
+	// Whatever is left goes as literal data, which is the whole file when there is nothing to match against.
+	if err := e.addLiteral(buf); err != nil {
+		return err
+	}
+	if !eof {
+		buf = make([]byte, chunkSize)
+	}
+	for !eof {
+		n, err := f.Read(buf)
+		if err == io.EOF {
+			eof = true
+		} else if err != nil {
+			return err
+		}
+		if err := e.addLiteral(buf[:n]); err != nil {
+			return err
+		}
+	}
+
+	if err := e.flushCopy(); err != nil {
+		return err
+	}
+	return e.flushLiteral(true)
+}
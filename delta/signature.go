@@ -0,0 +1,163 @@
+package delta
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// Bounds on the size of the blocks a file is split into for its signatures.
+const (
+	minBlockSize = 2048
+	maxBlockSize = 128 << 10
+)
+
+// Bytes of the SHA-256 of a block kept as its strong checksum.
+const strongSize = 16
+
+// How many block signatures go in one message.
+const signaturesPerMessage = 16384
+
+// Pick a block size for a file of size bytes. Around the square root of the size keeps both the signatures and the
+// literal data around a changed spot small.
+func blockSize(size int64) int {
+	bs := int(math.Sqrt(float64(size))) &^ 7
+	return min(max(bs, minBlockSize), maxBlockSize)
+}
+
+// Rolling checksum from rsync. a is the sum of the bytes in the window and b the sum of every prefix sum, so sliding
+// the window by a byte only needs the byte leaving and the byte entering it.
+type rolling struct {
+	a, b uint32
+	n    uint32
+}
+
+func newRolling(window []byte) rolling {
+	r := rolling{n: uint32(len(window))}
+	for i, c := range window {
+		r.a += uint32(c)
+		r.b += uint32(len(window)-i) * uint32(c)
+	}
+	return r
+}
+
+func (r *rolling) roll(out byte, in byte) {
+	r.a += uint32(in) - uint32(out)
+	r.b += r.a - r.n*uint32(out)
+}
+
+func (r rolling) sum() uint32 {
+	return r.a&0xffff | r.b<<16
+}
+
+func strongSum(block []byte) []byte {
+	sum := sha256.Sum256(block)
+	return sum[:strongSize]
+}
+
+// Send the signatures of the size bytes of r split into blocks, returning the block size used. r may be nil when there
+// is nothing to match against.
+func sendSignatures(conn Conn, r io.Reader, size int64) (int, error) {
+	bs := blockSize(size)
+	signatures := &proto.BlockSignatures{BlockSize: int32(bs), Size: size}
+	if r != nil {
+		br := bufio.NewReaderSize(io.LimitReader(r, size), bs)
+		block := make([]byte, bs)
+		for {
+			n, err := io.ReadFull(br, block)
+			if n > 0 {
+				signatures.Blocks = append(signatures.Blocks, &proto.BlockSignature{Weak: newRolling(block[:n]).sum(), Strong: strongSum(block[:n])})
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			} else if err != nil {
+				return 0, err
+			}
+			if len(signatures.Blocks) == signaturesPerMessage {
+				if err := conn.Send(&proto.SyncMessage{Signatures: signatures}); err != nil {
+					return 0, err
+				}
+				signatures = &proto.BlockSignatures{BlockSize: int32(bs), Size: size}
+			}
+		}
+	}
+
+	signatures.Last = true
+	return bs, conn.Send(&proto.SyncMessage{Signatures: signatures})
+}
+
+// The receiver's blocks, looked up by weak checksum.
+type index struct {
+	blockSize int
+	size      int64
+	weak      map[uint32][]int64
+	strong    [][]byte
+}
+
+// Collect the signatures the receiver sends for a file, or nil if it skipped the file.
+func recvSignatures(conn Conn) (*index, error) {
+	x := &index{weak: make(map[uint32][]int64)}
+	for {
+		m, err := conn.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if m.GetSkip() {
+			return nil, nil
+		}
+		signatures := m.GetSignatures()
+		if signatures == nil {
+			return nil, errors.New("expected block signatures")
+		}
+
+		x.blockSize, x.size = int(signatures.GetBlockSize()), signatures.GetSize()
+		for _, block := range signatures.GetBlocks() {
+			x.weak[block.GetWeak()] = append(x.weak[block.GetWeak()], int64(len(x.strong)))
+			x.strong = append(x.strong, block.GetStrong())
+		}
+		if signatures.GetLast() {
+			break
+		}
+	}
+
+	if x.blockSize < minBlockSize || x.blockSize > maxBlockSize {
+		return nil, fmt.Errorf("block size %d is out of range", x.blockSize)
+	}
+	if count := (x.size + int64(x.blockSize) - 1) / int64(x.blockSize); int64(len(x.strong)) != count {
+		return nil, fmt.Errorf("got %d block signatures for %d bytes in blocks of %d", len(x.strong), x.size, x.blockSize)
+	}
+	return x, nil
+}
+
+// Length of block i, which is only short for the last block.
+func (x *index) blockLen(i int64) int {
+	return int(min(int64(x.blockSize), x.size-i*int64(x.blockSize)))
+}
+
+// Find a block with the same contents as window, preferring hint so runs of blocks stay together. Returns -1 when
+// there is none.
+func (x *index) find(weak uint32, window []byte, hint int64) int64 {
+	candidates := x.weak[weak]
+	if len(candidates) == 0 {
+		return -1
+	}
+	strong := strongSum(window)
+	match := int64(-1)
+	for _, i := range candidates {
+		if x.blockLen(i) != len(window) || string(x.strong[i]) != string(strong) {
+			continue
+		}
+		if i == hint {
+			return i
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	return match
+}
@@ -9,7 +9,9 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -22,29 +24,2513 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Payloads are compressed one message at a time, so each message can be decoded on its own and resumed transfers keep
+// their offsets. A sender leaves a message uncompressed when compressing would not make it smaller.
+type Compression int32
+
+const (
+	Compression_COMPRESSION_NONE Compression = 0
+	Compression_COMPRESSION_GZIP Compression = 1
+	Compression_COMPRESSION_ZSTD Compression = 2
+)
+
+// Enum value maps for Compression.
+var (
+	Compression_name = map[int32]string{
+		0: "COMPRESSION_NONE",
+		1: "COMPRESSION_GZIP",
+		2: "COMPRESSION_ZSTD",
+	}
+	Compression_value = map[string]int32{
+		"COMPRESSION_NONE": 0,
+		"COMPRESSION_GZIP": 1,
+		"COMPRESSION_ZSTD": 2,
+	}
+)
+
+func (x Compression) Enum() *Compression {
+	p := new(Compression)
+	*p = x
+	return p
+}
+
+func (x Compression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Compression) Descriptor() protoreflect.EnumDescriptor {
+	return file_commands_proto_enumTypes[0].Descriptor()
+}
+
+func (Compression) Type() protoreflect.EnumType {
+	return &file_commands_proto_enumTypes[0]
+}
+
+func (x Compression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Compression.Descriptor instead.
+func (Compression) EnumDescriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{0}
+}
+
+type EnvMode int32
+
+const (
+	// Start from the environment of the server.
+	EnvMode_ENV_INHERIT EnvMode = 0
+	// Start from an empty environment.
+	EnvMode_ENV_CLEAR EnvMode = 1
+)
+
+// Enum value maps for EnvMode.
+var (
+	EnvMode_name = map[int32]string{
+		0: "ENV_INHERIT",
+		1: "ENV_CLEAR",
+	}
+	EnvMode_value = map[string]int32{
+		"ENV_INHERIT": 0,
+		"ENV_CLEAR":   1,
+	}
+)
+
+func (x EnvMode) Enum() *EnvMode {
+	p := new(EnvMode)
+	*p = x
+	return p
+}
+
+func (x EnvMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EnvMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_commands_proto_enumTypes[1].Descriptor()
+}
+
+func (EnvMode) Type() protoreflect.EnumType {
+	return &file_commands_proto_enumTypes[1]
+}
+
+func (x EnvMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EnvMode.Descriptor instead.
+func (EnvMode) EnumDescriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{1}
+}
+
+// Signals that can be delivered to a running command. The values follow the Linux numbering.
+type Signal int32
+
+const (
+	Signal_SIGNAL_NONE Signal = 0
+	Signal_SIGNAL_HUP  Signal = 1
+	Signal_SIGNAL_INT  Signal = 2
+	Signal_SIGNAL_QUIT Signal = 3
+	Signal_SIGNAL_KILL Signal = 9
+	Signal_SIGNAL_USR1 Signal = 10
+	Signal_SIGNAL_USR2 Signal = 12
+	Signal_SIGNAL_TERM Signal = 15
+	Signal_SIGNAL_CONT Signal = 18
+	Signal_SIGNAL_STOP Signal = 19
+)
+
+// Enum value maps for Signal.
+var (
+	Signal_name = map[int32]string{
+		0:  "SIGNAL_NONE",
+		1:  "SIGNAL_HUP",
+		2:  "SIGNAL_INT",
+		3:  "SIGNAL_QUIT",
+		9:  "SIGNAL_KILL",
+		10: "SIGNAL_USR1",
+		12: "SIGNAL_USR2",
+		15: "SIGNAL_TERM",
+		18: "SIGNAL_CONT",
+		19: "SIGNAL_STOP",
+	}
+	Signal_value = map[string]int32{
+		"SIGNAL_NONE": 0,
+		"SIGNAL_HUP":  1,
+		"SIGNAL_INT":  2,
+		"SIGNAL_QUIT": 3,
+		"SIGNAL_KILL": 9,
+		"SIGNAL_USR1": 10,
+		"SIGNAL_USR2": 12,
+		"SIGNAL_TERM": 15,
+		"SIGNAL_CONT": 18,
+		"SIGNAL_STOP": 19,
+	}
+)
+
+func (x Signal) Enum() *Signal {
+	p := new(Signal)
+	*p = x
+	return p
+}
+
+func (x Signal) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Signal) Descriptor() protoreflect.EnumDescriptor {
+	return file_commands_proto_enumTypes[2].Descriptor()
+}
+
+func (Signal) Type() protoreflect.EnumType {
+	return &file_commands_proto_enumTypes[2]
+}
+
+func (x Signal) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Signal.Descriptor instead.
+func (Signal) EnumDescriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{2}
+}
+
+// Jobs
+type JobState int32
+
+const (
+	JobState_JOB_RUNNING JobState = 0
+	JobState_JOB_EXITED  JobState = 1
+)
+
+// Enum value maps for JobState.
+var (
+	JobState_name = map[int32]string{
+		0: "JOB_RUNNING",
+		1: "JOB_EXITED",
+	}
+	JobState_value = map[string]int32{
+		"JOB_RUNNING": 0,
+		"JOB_EXITED":  1,
+	}
+)
+
+func (x JobState) Enum() *JobState {
+	p := new(JobState)
+	*p = x
+	return p
+}
+
+func (x JobState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JobState) Descriptor() protoreflect.EnumDescriptor {
+	return file_commands_proto_enumTypes[3].Descriptor()
+}
+
+func (JobState) Type() protoreflect.EnumType {
+	return &file_commands_proto_enumTypes[3]
+}
+
+func (x JobState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JobState.Descriptor instead.
+func (JobState) EnumDescriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{3}
+}
+
+// Upload/Download Directory
+type EntryType int32
+
+const (
+	EntryType_ENTRY_FILE    EntryType = 0
+	EntryType_ENTRY_DIR     EntryType = 1
+	EntryType_ENTRY_SYMLINK EntryType = 2
+	// Devices, sockets and named pipes, which only show up in Filesystem listings.
+	EntryType_ENTRY_OTHER EntryType = 3
+)
+
+// Enum value maps for EntryType.
+var (
+	EntryType_name = map[int32]string{
+		0: "ENTRY_FILE",
+		1: "ENTRY_DIR",
+		2: "ENTRY_SYMLINK",
+		3: "ENTRY_OTHER",
+	}
+	EntryType_value = map[string]int32{
+		"ENTRY_FILE":    0,
+		"ENTRY_DIR":     1,
+		"ENTRY_SYMLINK": 2,
+		"ENTRY_OTHER":   3,
+	}
+)
+
+func (x EntryType) Enum() *EntryType {
+	p := new(EntryType)
+	*p = x
+	return p
+}
+
+func (x EntryType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EntryType) Descriptor() protoreflect.EnumDescriptor {
+	return file_commands_proto_enumTypes[4].Descriptor()
+}
+
+func (EntryType) Type() protoreflect.EnumType {
+	return &file_commands_proto_enumTypes[4]
+}
+
+func (x EntryType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EntryType.Descriptor instead.
+func (EntryType) EnumDescriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{4}
+}
+
+// HashFile
+type HashAlgorithm int32
+
+const (
+	HashAlgorithm_HASH_SHA256  HashAlgorithm = 0
+	HashAlgorithm_HASH_SHA1    HashAlgorithm = 1
+	HashAlgorithm_HASH_MD5     HashAlgorithm = 2
+	HashAlgorithm_HASH_BLAKE2B HashAlgorithm = 3
+)
+
+// Enum value maps for HashAlgorithm.
+var (
+	HashAlgorithm_name = map[int32]string{
+		0: "HASH_SHA256",
+		1: "HASH_SHA1",
+		2: "HASH_MD5",
+		3: "HASH_BLAKE2B",
+	}
+	HashAlgorithm_value = map[string]int32{
+		"HASH_SHA256":  0,
+		"HASH_SHA1":    1,
+		"HASH_MD5":     2,
+		"HASH_BLAKE2B": 3,
+	}
+)
+
+func (x HashAlgorithm) Enum() *HashAlgorithm {
+	p := new(HashAlgorithm)
+	*p = x
+	return p
+}
+
+func (x HashAlgorithm) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HashAlgorithm) Descriptor() protoreflect.EnumDescriptor {
+	return file_commands_proto_enumTypes[5].Descriptor()
+}
+
+func (HashAlgorithm) Type() protoreflect.EnumType {
+	return &file_commands_proto_enumTypes[5]
+}
+
+func (x HashAlgorithm) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HashAlgorithm.Descriptor instead.
+func (HashAlgorithm) EnumDescriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{5}
+}
+
+// Sync
+type SyncDirection int32
+
+const (
+	// The client's tree is synced to the path on the server.
+	SyncDirection_SYNC_PUSH SyncDirection = 0
+	// The path on the server is synced to the client's tree.
+	SyncDirection_SYNC_PULL SyncDirection = 1
+)
+
+// Enum value maps for SyncDirection.
+var (
+	SyncDirection_name = map[int32]string{
+		0: "SYNC_PUSH",
+		1: "SYNC_PULL",
+	}
+	SyncDirection_value = map[string]int32{
+		"SYNC_PUSH": 0,
+		"SYNC_PULL": 1,
+	}
+)
+
+func (x SyncDirection) Enum() *SyncDirection {
+	p := new(SyncDirection)
+	*p = x
+	return p
+}
+
+func (x SyncDirection) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SyncDirection) Descriptor() protoreflect.EnumDescriptor {
+	return file_commands_proto_enumTypes[6].Descriptor()
+}
+
+func (SyncDirection) Type() protoreflect.EnumType {
+	return &file_commands_proto_enumTypes[6]
+}
+
+func (x SyncDirection) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SyncDirection.Descriptor instead.
+func (SyncDirection) EnumDescriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{6}
+}
+
+type SyncAction int32
+
+const (
+	SyncAction_SYNC_CREATE SyncAction = 0
+	SyncAction_SYNC_UPDATE SyncAction = 1
+)
+
+// Enum value maps for SyncAction.
+var (
+	SyncAction_name = map[int32]string{
+		0: "SYNC_CREATE",
+		1: "SYNC_UPDATE",
+	}
+	SyncAction_value = map[string]int32{
+		"SYNC_CREATE": 0,
+		"SYNC_UPDATE": 1,
+	}
+)
+
+func (x SyncAction) Enum() *SyncAction {
+	p := new(SyncAction)
+	*p = x
+	return p
+}
+
+func (x SyncAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SyncAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_commands_proto_enumTypes[7].Descriptor()
+}
+
+func (SyncAction) Type() protoreflect.EnumType {
+	return &file_commands_proto_enumTypes[7]
+}
+
+func (x SyncAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SyncAction.Descriptor instead.
+func (SyncAction) EnumDescriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{7}
+}
+
 // Connection Identifier
 type ConnectionParams struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Stable across restarts of the server, so the same host reached through different addresses can be recognised.
+	Uuid     []byte `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Hostname string `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Os       string `protobuf:"bytes,3,opt,name=os,proto3" json:"os,omitempty"`
+	Arch     string `protobuf:"bytes,4,opt,name=arch,proto3" json:"arch,omitempty"`
+	Version  string `protobuf:"bytes,5,opt,name=version,proto3" json:"version,omitempty"`
+	// Optional features the server supports, such as pty or jobs.
+	Capabilities []string `protobuf:"bytes,6,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// Codecs the server can compress and decompress streams with.
+	Compressions  []Compression `protobuf:"varint,7,rep,packed,name=compressions,proto3,enum=proto.Compression" json:"compressions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectionParams) Reset() {
+	*x = ConnectionParams{}
+	mi := &file_commands_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectionParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectionParams) ProtoMessage() {}
+
+func (x *ConnectionParams) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectionParams.ProtoReflect.Descriptor instead.
+func (*ConnectionParams) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ConnectionParams) GetUuid() []byte {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+func (x *ConnectionParams) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *ConnectionParams) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *ConnectionParams) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *ConnectionParams) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ConnectionParams) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *ConnectionParams) GetCompressions() []Compression {
+	if x != nil {
+		return x.Compressions
+	}
+	return nil
+}
+
+// Run Executable
+type WindowSize struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Uuid          []byte                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Rows          uint32                 `protobuf:"varint,1,opt,name=rows,proto3" json:"rows,omitempty"`
+	Cols          uint32                 `protobuf:"varint,2,opt,name=cols,proto3" json:"cols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WindowSize) Reset() {
+	*x = WindowSize{}
+	mi := &file_commands_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WindowSize) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WindowSize) ProtoMessage() {}
+
+func (x *WindowSize) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WindowSize.ProtoReflect.Descriptor instead.
+func (*WindowSize) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WindowSize) GetRows() uint32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *WindowSize) GetCols() uint32 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+type RunExecutableOptions struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Command string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Args    []string               `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// Run the command on a pseudo-terminal. Output is sent back as stdout and stdin is always forwarded.
+	Pty bool `protobuf:"varint,3,opt,name=pty,proto3" json:"pty,omitempty"`
+	// Initial size of the pseudo-terminal.
+	WindowSize *WindowSize `protobuf:"bytes,4,opt,name=windowSize,proto3" json:"windowSize,omitempty"`
+	// Forward stdin from RunExecutableInput to the command. Otherwise the command reads from an empty stdin.
+	Stdin bool `protobuf:"varint,5,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	// Working directory of the command. Defaults to the working directory of the server.
+	Cwd string `protobuf:"bytes,6,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	// Environment variables to set for the command. They override inherited variables of the same name.
+	Env     map[string]string `protobuf:"bytes,7,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	EnvMode EnvMode           `protobuf:"varint,8,opt,name=envMode,proto3,enum=proto.EnvMode" json:"envMode,omitempty"`
+	// Kill the command if it is still running after this long. Unset or zero means no timeout.
+	Timeout *durationpb.Duration `protobuf:"bytes,9,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Run the command as this user name or uid. The server needs the privilege to switch users.
+	User string `protobuf:"bytes,10,opt,name=user,proto3" json:"user,omitempty"`
+	// Codec the server should compress stdout and stderr with.
+	Compression   Compression `protobuf:"varint,11,opt,name=compression,proto3,enum=proto.Compression" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunExecutableOptions) Reset() {
+	*x = RunExecutableOptions{}
+	mi := &file_commands_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunExecutableOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunExecutableOptions) ProtoMessage() {}
+
+func (x *RunExecutableOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunExecutableOptions.ProtoReflect.Descriptor instead.
+func (*RunExecutableOptions) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RunExecutableOptions) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *RunExecutableOptions) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *RunExecutableOptions) GetPty() bool {
+	if x != nil {
+		return x.Pty
+	}
+	return false
+}
+
+func (x *RunExecutableOptions) GetWindowSize() *WindowSize {
+	if x != nil {
+		return x.WindowSize
+	}
+	return nil
+}
+
+func (x *RunExecutableOptions) GetStdin() bool {
+	if x != nil {
+		return x.Stdin
+	}
+	return false
+}
+
+func (x *RunExecutableOptions) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *RunExecutableOptions) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *RunExecutableOptions) GetEnvMode() EnvMode {
+	if x != nil {
+		return x.EnvMode
+	}
+	return EnvMode_ENV_INHERIT
+}
+
+func (x *RunExecutableOptions) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *RunExecutableOptions) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *RunExecutableOptions) GetCompression() Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return Compression_COMPRESSION_NONE
+}
+
+type SendSignal struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Signal Signal                 `protobuf:"varint,1,opt,name=signal,proto3,enum=proto.Signal" json:"signal,omitempty"`
+	// Deliver the signal to every process in the command's process group rather than just the command itself.
+	ProcessGroup  bool `protobuf:"varint,2,opt,name=processGroup,proto3" json:"processGroup,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendSignal) Reset() {
+	*x = SendSignal{}
+	mi := &file_commands_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendSignal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendSignal) ProtoMessage() {}
+
+func (x *SendSignal) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendSignal.ProtoReflect.Descriptor instead.
+func (*SendSignal) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SendSignal) GetSignal() Signal {
+	if x != nil {
+		return x.Signal
+	}
+	return Signal_SIGNAL_NONE
+}
+
+func (x *SendSignal) GetProcessGroup() bool {
+	if x != nil {
+		return x.ProcessGroup
+	}
+	return false
+}
+
+type RunExecutableInput struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Options *RunExecutableOptions  `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
+	Stdin   []byte                 `protobuf:"bytes,2,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	// Resize the pseudo-terminal of a command started with pty.
+	Resize *WindowSize `protobuf:"bytes,3,opt,name=resize,proto3" json:"resize,omitempty"`
+	Signal *SendSignal `protobuf:"bytes,4,opt,name=signal,proto3" json:"signal,omitempty"`
+	// Stop streaming and leave the command running as a background job.
+	Detach bool `protobuf:"varint,5,opt,name=detach,proto3" json:"detach,omitempty"`
+	// The job to attach to, in the first message of AttachJob.
+	JobId         string `protobuf:"bytes,6,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunExecutableInput) Reset() {
+	*x = RunExecutableInput{}
+	mi := &file_commands_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunExecutableInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunExecutableInput) ProtoMessage() {}
+
+func (x *RunExecutableInput) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunExecutableInput.ProtoReflect.Descriptor instead.
+func (*RunExecutableInput) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RunExecutableInput) GetOptions() *RunExecutableOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *RunExecutableInput) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *RunExecutableInput) GetResize() *WindowSize {
+	if x != nil {
+		return x.Resize
+	}
+	return nil
+}
+
+func (x *RunExecutableInput) GetSignal() *SendSignal {
+	if x != nil {
+		return x.Signal
+	}
+	return nil
+}
+
+func (x *RunExecutableInput) GetDetach() bool {
+	if x != nil {
+		return x.Detach
+	}
+	return false
+}
+
+func (x *RunExecutableInput) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type RunExecutableResult struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ReturnCode int32                  `protobuf:"varint,1,opt,name=returnCode,proto3" json:"returnCode,omitempty"`
+	Stdout     []byte                 `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr     []byte                 `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	// Set in the first message so the client can re-attach to the command later.
+	JobId string `protobuf:"bytes,4,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	// Set in the last message when the client detached and the command keeps running.
+	Detached bool `protobuf:"varint,5,opt,name=detached,proto3" json:"detached,omitempty"`
+	// How stdout and stderr in this message are encoded.
+	Compression   Compression `protobuf:"varint,6,opt,name=compression,proto3,enum=proto.Compression" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunExecutableResult) Reset() {
+	*x = RunExecutableResult{}
+	mi := &file_commands_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunExecutableResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunExecutableResult) ProtoMessage() {}
+
+func (x *RunExecutableResult) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunExecutableResult.ProtoReflect.Descriptor instead.
+func (*RunExecutableResult) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RunExecutableResult) GetReturnCode() int32 {
+	if x != nil {
+		return x.ReturnCode
+	}
+	return 0
+}
+
+func (x *RunExecutableResult) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *RunExecutableResult) GetStderr() []byte {
+	if x != nil {
+		return x.Stderr
+	}
+	return nil
+}
+
+func (x *RunExecutableResult) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *RunExecutableResult) GetDetached() bool {
+	if x != nil {
+		return x.Detached
+	}
+	return false
+}
+
+func (x *RunExecutableResult) GetCompression() Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return Compression_COMPRESSION_NONE
+}
+
+type JobInfo struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Owner      string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Command    string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`
+	Args       []string               `protobuf:"bytes,4,rep,name=args,proto3" json:"args,omitempty"`
+	Pty        bool                   `protobuf:"varint,5,opt,name=pty,proto3" json:"pty,omitempty"`
+	State      JobState               `protobuf:"varint,6,opt,name=state,proto3,enum=proto.JobState" json:"state,omitempty"`
+	ReturnCode int32                  `protobuf:"varint,7,opt,name=returnCode,proto3" json:"returnCode,omitempty"`
+	StartTime  *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=startTime,proto3" json:"startTime,omitempty"`
+	EndTime    *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=endTime,proto3" json:"endTime,omitempty"`
+	// Total output produced, which may be more than is still buffered.
+	OutputBytes int64 `protobuf:"varint,10,opt,name=outputBytes,proto3" json:"outputBytes,omitempty"`
+	// Number of clients currently streaming the job.
+	Attached      int32 `protobuf:"varint,11,opt,name=attached,proto3" json:"attached,omitempty"`
+	Detached      bool  `protobuf:"varint,12,opt,name=detached,proto3" json:"detached,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobInfo) Reset() {
+	*x = JobInfo{}
+	mi := &file_commands_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobInfo) ProtoMessage() {}
+
+func (x *JobInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobInfo.ProtoReflect.Descriptor instead.
+func (*JobInfo) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *JobInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *JobInfo) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *JobInfo) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *JobInfo) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *JobInfo) GetPty() bool {
+	if x != nil {
+		return x.Pty
+	}
+	return false
+}
+
+func (x *JobInfo) GetState() JobState {
+	if x != nil {
+		return x.State
+	}
+	return JobState_JOB_RUNNING
+}
+
+func (x *JobInfo) GetReturnCode() int32 {
+	if x != nil {
+		return x.ReturnCode
+	}
+	return 0
+}
+
+func (x *JobInfo) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *JobInfo) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+func (x *JobInfo) GetOutputBytes() int64 {
+	if x != nil {
+		return x.OutputBytes
+	}
+	return 0
+}
+
+func (x *JobInfo) GetAttached() int32 {
+	if x != nil {
+		return x.Attached
+	}
+	return 0
+}
+
+func (x *JobInfo) GetDetached() bool {
+	if x != nil {
+		return x.Detached
+	}
+	return false
+}
+
+type JobList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jobs          []*JobInfo             `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobList) Reset() {
+	*x = JobList{}
+	mi := &file_commands_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobList) ProtoMessage() {}
+
+func (x *JobList) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobList.ProtoReflect.Descriptor instead.
+func (*JobList) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *JobList) GetJobs() []*JobInfo {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type JobSignal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	Signal        *SendSignal            `protobuf:"bytes,2,opt,name=signal,proto3" json:"signal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobSignal) Reset() {
+	*x = JobSignal{}
+	mi := &file_commands_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobSignal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobSignal) ProtoMessage() {}
+
+func (x *JobSignal) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobSignal.ProtoReflect.Descriptor instead.
+func (*JobSignal) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *JobSignal) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobSignal) GetSignal() *SendSignal {
+	if x != nil {
+		return x.Signal
+	}
+	return nil
+}
+
+// Upload/Download File
+type FileChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Chunk []byte                 `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	// Where chunk belongs in the file. In a FileDownload request it is where to resume, and the server falls back to 0 if
+	// sha256 does not match the first offset bytes of the file.
+	Offset int64 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	// SHA-256 of the whole file, carried by the last chunk of a transfer. In a FileDownload request it is the hash of the
+	// part the client already has.
+	Sha256 []byte `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	// Size of the whole file, set on every chunk of a download.
+	Size int64 `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	// How chunk is encoded. In a FileDownload request it is the codec the server should compress the chunks with.
+	Compression   Compression `protobuf:"varint,6,opt,name=compression,proto3,enum=proto.Compression" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunk) Reset() {
+	*x = FileChunk{}
+	mi := &file_commands_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunk) ProtoMessage() {}
+
+func (x *FileChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
+func (*FileChunk) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FileChunk) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileChunk) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+func (x *FileChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *FileChunk) GetSha256() []byte {
+	if x != nil {
+		return x.Sha256
+	}
+	return nil
+}
+
+func (x *FileChunk) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileChunk) GetCompression() Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return Compression_COMPRESSION_NONE
+}
+
+type FileStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// How much of the file has been received.
+	Size int64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// SHA-256 of those bytes.
+	Sha256        []byte `protobuf:"bytes,3,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileStatus) Reset() {
+	*x = FileStatus{}
+	mi := &file_commands_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileStatus) ProtoMessage() {}
+
+func (x *FileStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileStatus.ProtoReflect.Descriptor instead.
+func (*FileStatus) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FileStatus) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileStatus) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileStatus) GetSha256() []byte {
+	if x != nil {
+		return x.Sha256
+	}
+	return nil
+}
+
+type TreeEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Slash separated path relative to the root of the tree, "." for the root itself.
+	Path string    `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Type EntryType `protobuf:"varint,2,opt,name=type,proto3,enum=proto.EntryType" json:"type,omitempty"`
+	// Permission bits.
+	Mode          uint32                 `protobuf:"varint,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	ModTime       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=modTime,proto3" json:"modTime,omitempty"`
+	Size          int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	LinkTarget    string                 `protobuf:"bytes,6,opt,name=linkTarget,proto3" json:"linkTarget,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeEntry) Reset() {
+	*x = TreeEntry{}
+	mi := &file_commands_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeEntry) ProtoMessage() {}
+
+func (x *TreeEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeEntry.ProtoReflect.Descriptor instead.
+func (*TreeEntry) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TreeEntry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *TreeEntry) GetType() EntryType {
+	if x != nil {
+		return x.Type
+	}
+	return EntryType_ENTRY_FILE
+}
+
+func (x *TreeEntry) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *TreeEntry) GetModTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModTime
+	}
+	return nil
+}
+
+func (x *TreeEntry) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *TreeEntry) GetLinkTarget() string {
+	if x != nil {
+		return x.LinkTarget
+	}
+	return ""
+}
+
+type TreeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The directory to download, or the one to upload into.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Globs picking what is sent, applied by whichever side walks the tree. A pattern without a slash matches the name of
+	// an entry anywhere in the tree, and one with a slash matches its whole relative path. When include is set only the
+	// files matching it are sent, along with the directories leading to them. Excluding a directory skips everything
+	// under it.
+	Include       []string `protobuf:"bytes,2,rep,name=include,proto3" json:"include,omitempty"`
+	Exclude       []string `protobuf:"bytes,3,rep,name=exclude,proto3" json:"exclude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeRequest) Reset() {
+	*x = TreeRequest{}
+	mi := &file_commands_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeRequest) ProtoMessage() {}
+
+func (x *TreeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeRequest.ProtoReflect.Descriptor instead.
+func (*TreeRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TreeRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *TreeRequest) GetInclude() []string {
+	if x != nil {
+		return x.Include
+	}
+	return nil
+}
+
+func (x *TreeRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+// A tree goes over the wire like a tar archive, each entry followed by the contents of a file spread over as many data
+// messages as it takes.
+type TreeChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only set on the first message of an upload.
+	Request       *TreeRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Entry         *TreeEntry   `protobuf:"bytes,2,opt,name=entry,proto3" json:"entry,omitempty"`
+	Data          []byte       `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeChunk) Reset() {
+	*x = TreeChunk{}
+	mi := &file_commands_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeChunk) ProtoMessage() {}
+
+func (x *TreeChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeChunk.ProtoReflect.Descriptor instead.
+func (*TreeChunk) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TreeChunk) GetRequest() *TreeRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *TreeChunk) GetEntry() *TreeEntry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *TreeChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type TreeSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Files         int32                  `protobuf:"varint,1,opt,name=files,proto3" json:"files,omitempty"`
+	Dirs          int32                  `protobuf:"varint,2,opt,name=dirs,proto3" json:"dirs,omitempty"`
+	Symlinks      int32                  `protobuf:"varint,3,opt,name=symlinks,proto3" json:"symlinks,omitempty"`
+	Bytes         int64                  `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeSummary) Reset() {
+	*x = TreeSummary{}
+	mi := &file_commands_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeSummary) ProtoMessage() {}
+
+func (x *TreeSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeSummary.ProtoReflect.Descriptor instead.
+func (*TreeSummary) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TreeSummary) GetFiles() int32 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *TreeSummary) GetDirs() int32 {
+	if x != nil {
+		return x.Dirs
+	}
+	return 0
+}
+
+func (x *TreeSummary) GetSymlinks() int32 {
+	if x != nil {
+		return x.Symlinks
+	}
+	return 0
+}
+
+func (x *TreeSummary) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+type HashRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Defaults to SHA-256 alone when empty.
+	Algorithms    []HashAlgorithm `protobuf:"varint,2,rep,packed,name=algorithms,proto3,enum=proto.HashAlgorithm" json:"algorithms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashRequest) Reset() {
+	*x = HashRequest{}
+	mi := &file_commands_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashRequest) ProtoMessage() {}
+
+func (x *HashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashRequest.ProtoReflect.Descriptor instead.
+func (*HashRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *HashRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *HashRequest) GetAlgorithms() []HashAlgorithm {
+	if x != nil {
+		return x.Algorithms
+	}
+	return nil
+}
+
+type Digest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Algorithm     HashAlgorithm          `protobuf:"varint,1,opt,name=algorithm,proto3,enum=proto.HashAlgorithm" json:"algorithm,omitempty"`
+	Sum           []byte                 `protobuf:"bytes,2,opt,name=sum,proto3" json:"sum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Digest) Reset() {
+	*x = Digest{}
+	mi := &file_commands_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Digest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Digest) ProtoMessage() {}
+
+func (x *Digest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Digest.ProtoReflect.Descriptor instead.
+func (*Digest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Digest) GetAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.Algorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+func (x *Digest) GetSum() []byte {
+	if x != nil {
+		return x.Sum
+	}
+	return nil
+}
+
+type FileHash struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The requested path for a file, or the path relative to it for each file in a directory.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Size int64  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// In the order the algorithms were requested.
+	Digests       []*Digest `protobuf:"bytes,3,rep,name=digests,proto3" json:"digests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileHash) Reset() {
+	*x = FileHash{}
+	mi := &file_commands_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileHash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileHash) ProtoMessage() {}
+
+func (x *FileHash) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileHash.ProtoReflect.Descriptor instead.
+func (*FileHash) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *FileHash) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileHash) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileHash) GetDigests() []*Digest {
+	if x != nil {
+		return x.Digests
+	}
+	return nil
+}
+
+type SyncRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path on the server, a file or a directory.
+	Path      string        `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Direction SyncDirection `protobuf:"varint,2,opt,name=direction,proto3,enum=proto.SyncDirection" json:"direction,omitempty"`
+	// Only report what would change.
+	DryRun bool `protobuf:"varint,3,opt,name=dryRun,proto3" json:"dryRun,omitempty"`
+	// Globs picking what is synced, applied by the side sending the tree as in TreeRequest.
+	Include []string `protobuf:"bytes,4,rep,name=include,proto3" json:"include,omitempty"`
+	Exclude []string `protobuf:"bytes,5,rep,name=exclude,proto3" json:"exclude,omitempty"`
+	// Codec literal data is compressed with.
+	Compression   Compression `protobuf:"varint,6,opt,name=compression,proto3,enum=proto.Compression" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncRequest) Reset() {
+	*x = SyncRequest{}
+	mi := &file_commands_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncRequest) ProtoMessage() {}
+
+func (x *SyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncRequest.ProtoReflect.Descriptor instead.
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SyncRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SyncRequest) GetDirection() SyncDirection {
+	if x != nil {
+		return x.Direction
+	}
+	return SyncDirection_SYNC_PUSH
+}
+
+func (x *SyncRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *SyncRequest) GetInclude() []string {
+	if x != nil {
+		return x.Include
+	}
+	return nil
+}
+
+func (x *SyncRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *SyncRequest) GetCompression() Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return Compression_COMPRESSION_NONE
+}
+
+type BlockSignature struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rolling checksum of the block, cheap to slide along the sender's file one byte at a time.
+	Weak uint32 `protobuf:"varint,1,opt,name=weak,proto3" json:"weak,omitempty"`
+	// Truncated SHA-256 of the block, checked once the weak checksum matches.
+	Strong        []byte `protobuf:"bytes,2,opt,name=strong,proto3" json:"strong,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockSignature) Reset() {
+	*x = BlockSignature{}
+	mi := &file_commands_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockSignature) ProtoMessage() {}
+
+func (x *BlockSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockSignature.ProtoReflect.Descriptor instead.
+func (*BlockSignature) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BlockSignature) GetWeak() uint32 {
+	if x != nil {
+		return x.Weak
+	}
+	return 0
+}
+
+func (x *BlockSignature) GetStrong() []byte {
+	if x != nil {
+		return x.Strong
+	}
+	return nil
+}
+
+// Signatures of the blocks of the receiver's copy of a file, spread over as many messages as it takes.
+type BlockSignatures struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	BlockSize int32                  `protobuf:"varint,1,opt,name=blockSize,proto3" json:"blockSize,omitempty"`
+	// Size of the receiver's copy. The last block is shorter than blockSize when this is not a multiple of it.
+	Size   int64             `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Blocks []*BlockSignature `protobuf:"bytes,3,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	// Set on the last message of the signatures.
+	Last          bool `protobuf:"varint,4,opt,name=last,proto3" json:"last,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockSignatures) Reset() {
+	*x = BlockSignatures{}
+	mi := &file_commands_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockSignatures) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockSignatures) ProtoMessage() {}
+
+func (x *BlockSignatures) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockSignatures.ProtoReflect.Descriptor instead.
+func (*BlockSignatures) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *BlockSignatures) GetBlockSize() int32 {
+	if x != nil {
+		return x.BlockSize
+	}
+	return 0
+}
+
+func (x *BlockSignatures) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *BlockSignatures) GetBlocks() []*BlockSignature {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (x *BlockSignatures) GetLast() bool {
+	if x != nil {
+		return x.Last
+	}
+	return false
+}
+
+// Copy count blocks starting at block index from the receiver's copy of the file.
+type BlockCopy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockCopy) Reset() {
+	*x = BlockCopy{}
+	mi := &file_commands_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockCopy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockCopy) ProtoMessage() {}
+
+func (x *BlockCopy) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockCopy.ProtoReflect.Descriptor instead.
+func (*BlockCopy) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BlockCopy) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BlockCopy) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type SyncChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Relative to the synced path, "." being the path itself.
+	Path          string     `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Type          EntryType  `protobuf:"varint,2,opt,name=type,proto3,enum=proto.EntryType" json:"type,omitempty"`
+	Action        SyncAction `protobuf:"varint,3,opt,name=action,proto3,enum=proto.SyncAction" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncChange) Reset() {
+	*x = SyncChange{}
+	mi := &file_commands_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncChange) ProtoMessage() {}
+
+func (x *SyncChange) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncChange.ProtoReflect.Descriptor instead.
+func (*SyncChange) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SyncChange) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SyncChange) GetType() EntryType {
+	if x != nil {
+		return x.Type
+	}
+	return EntryType_ENTRY_FILE
+}
+
+func (x *SyncChange) GetAction() SyncAction {
+	if x != nil {
+		return x.Action
+	}
+	return SyncAction_SYNC_CREATE
+}
+
+type SyncResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// What changed, or would change on a dry run, in the order the tree was walked.
+	Changes []*SyncChange `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	// Files that were already up to date.
+	Unchanged int32 `protobuf:"varint,2,opt,name=unchanged,proto3" json:"unchanged,omitempty"`
+	// Bytes of file contents sent as literal data, and bytes reused from the receiver's copies.
+	LiteralBytes  int64 `protobuf:"varint,3,opt,name=literalBytes,proto3" json:"literalBytes,omitempty"`
+	MatchedBytes  int64 `protobuf:"varint,4,opt,name=matchedBytes,proto3" json:"matchedBytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncResult) Reset() {
+	*x = SyncResult{}
+	mi := &file_commands_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncResult) ProtoMessage() {}
+
+func (x *SyncResult) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncResult.ProtoReflect.Descriptor instead.
+func (*SyncResult) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SyncResult) GetChanges() []*SyncChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *SyncResult) GetUnchanged() int32 {
+	if x != nil {
+		return x.Unchanged
+	}
+	return 0
+}
+
+func (x *SyncResult) GetLiteralBytes() int64 {
+	if x != nil {
+		return x.LiteralBytes
+	}
+	return 0
+}
+
+func (x *SyncResult) GetMatchedBytes() int64 {
+	if x != nil {
+		return x.MatchedBytes
+	}
+	return 0
+}
+
+// Sync runs as a conversation between the side sending the tree and the side receiving it. The sender sends a message
+// with the entry of everything in the tree, and the receiver answers every file with either skip, when its copy is up
+// to date, or the signatures of its copy. The sender then rebuilds the file out of block copies and literal data, the
+// last data message carrying the SHA-256 of the whole file, and finishes with done. When the server receives, it ends
+// with the result.
+type SyncMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// First message from the client.
+	Request       *SyncRequest     `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Entry         *TreeEntry       `protobuf:"bytes,2,opt,name=entry,proto3" json:"entry,omitempty"`
+	Skip          bool             `protobuf:"varint,3,opt,name=skip,proto3" json:"skip,omitempty"`
+	Signatures    *BlockSignatures `protobuf:"bytes,4,opt,name=signatures,proto3" json:"signatures,omitempty"`
+	Copy          *BlockCopy       `protobuf:"bytes,5,opt,name=copy,proto3" json:"copy,omitempty"`
+	Data          *FileChunk       `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	Done          bool             `protobuf:"varint,7,opt,name=done,proto3" json:"done,omitempty"`
+	Result        *SyncResult      `protobuf:"bytes,8,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncMessage) Reset() {
+	*x = SyncMessage{}
+	mi := &file_commands_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncMessage) ProtoMessage() {}
+
+func (x *SyncMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncMessage.ProtoReflect.Descriptor instead.
+func (*SyncMessage) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SyncMessage) GetRequest() *SyncRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *SyncMessage) GetEntry() *TreeEntry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *SyncMessage) GetSkip() bool {
+	if x != nil {
+		return x.Skip
+	}
+	return false
+}
+
+func (x *SyncMessage) GetSignatures() *BlockSignatures {
+	if x != nil {
+		return x.Signatures
+	}
+	return nil
+}
+
+func (x *SyncMessage) GetCopy() *BlockCopy {
+	if x != nil {
+		return x.Copy
+	}
+	return nil
+}
+
+func (x *SyncMessage) GetData() *FileChunk {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *SyncMessage) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *SyncMessage) GetResult() *SyncResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+// Filesystem
+type FileInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The requested path, or the name of the entry in a ListDir response.
+	Name string    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type EntryType `protobuf:"varint,2,opt,name=type,proto3,enum=proto.EntryType" json:"type,omitempty"`
+	Size int64     `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// Permission bits along with the setuid, setgid and sticky bits, as in chmod.
+	Mode    uint32                 `protobuf:"varint,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	ModTime *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=modTime,proto3" json:"modTime,omitempty"`
+	// Only set for symlinks.
+	LinkTarget string `protobuf:"bytes,6,opt,name=linkTarget,proto3" json:"linkTarget,omitempty"`
+	// Owner and group, with their names when they can be looked up. Not set on Windows.
+	Uid           uint32 `protobuf:"varint,7,opt,name=uid,proto3" json:"uid,omitempty"`
+	Gid           uint32 `protobuf:"varint,8,opt,name=gid,proto3" json:"gid,omitempty"`
+	Owner         string `protobuf:"bytes,9,opt,name=owner,proto3" json:"owner,omitempty"`
+	Group         string `protobuf:"bytes,10,opt,name=group,proto3" json:"group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileInfo) Reset() {
+	*x = FileInfo{}
+	mi := &file_commands_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileInfo) ProtoMessage() {}
+
+func (x *FileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileInfo.ProtoReflect.Descriptor instead.
+func (*FileInfo) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *FileInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FileInfo) GetType() EntryType {
+	if x != nil {
+		return x.Type
+	}
+	return EntryType_ENTRY_FILE
+}
+
+func (x *FileInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileInfo) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *FileInfo) GetModTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModTime
+	}
+	return nil
+}
+
+func (x *FileInfo) GetLinkTarget() string {
+	if x != nil {
+		return x.LinkTarget
+	}
+	return ""
+}
+
+func (x *FileInfo) GetUid() uint32 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *FileInfo) GetGid() uint32 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *FileInfo) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *FileInfo) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+type PathRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathRequest) Reset() {
+	*x = PathRequest{}
+	mi := &file_commands_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathRequest) ProtoMessage() {}
+
+func (x *PathRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathRequest.ProtoReflect.Descriptor instead.
+func (*PathRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PathRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type StatRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Describe what a symlink points to rather than the link itself.
+	Follow        bool `protobuf:"varint,2,opt,name=follow,proto3" json:"follow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatRequest) Reset() {
+	*x = StatRequest{}
+	mi := &file_commands_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatRequest) ProtoMessage() {}
+
+func (x *StatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatRequest.ProtoReflect.Descriptor instead.
+func (*StatRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *StatRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *StatRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+type ListDirRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Defaults to 1000 entries when not set, and is capped at 10000.
+	PageSize int32 `protobuf:"varint,2,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	// nextPageToken of the previous page, empty for the first one.
+	PageToken     string `protobuf:"bytes,3,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConnectionParams) Reset() {
-	*x = ConnectionParams{}
-	mi := &file_commands_proto_msgTypes[0]
+func (x *ListDirRequest) Reset() {
+	*x = ListDirRequest{}
+	mi := &file_commands_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConnectionParams) String() string {
+func (x *ListDirRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConnectionParams) ProtoMessage() {}
+func (*ListDirRequest) ProtoMessage() {}
 
-func (x *ConnectionParams) ProtoReflect() protoreflect.Message {
-	mi := &file_commands_proto_msgTypes[0]
+func (x *ListDirRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -55,42 +2541,112 @@ func (x *ConnectionParams) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConnectionParams.ProtoReflect.Descriptor instead.
-func (*ConnectionParams) Descriptor() ([]byte, []int) {
-	return file_commands_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use ListDirRequest.ProtoReflect.Descriptor instead.
+func (*ListDirRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{28}
 }
 
-func (x *ConnectionParams) GetUuid() []byte {
+func (x *ListDirRequest) GetPath() string {
 	if x != nil {
-		return x.Uuid
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ListDirRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListDirRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListDirResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Sorted by name.
+	Entries []*FileInfo `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirResponse) Reset() {
+	*x = ListDirResponse{}
+	mi := &file_commands_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirResponse) ProtoMessage() {}
+
+func (x *ListDirResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirResponse.ProtoReflect.Descriptor instead.
+func (*ListDirResponse) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListDirResponse) GetEntries() []*FileInfo {
+	if x != nil {
+		return x.Entries
 	}
 	return nil
 }
 
-// Run Executable
-type RunExecutableOptions struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
-	Args          []string               `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+func (x *ListDirResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type MkdirRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Defaults to 0755 when not set.
+	Mode uint32 `protobuf:"varint,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Create missing parents too, and succeed when the directory already exists.
+	Parents       bool `protobuf:"varint,3,opt,name=parents,proto3" json:"parents,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RunExecutableOptions) Reset() {
-	*x = RunExecutableOptions{}
-	mi := &file_commands_proto_msgTypes[1]
+func (x *MkdirRequest) Reset() {
+	*x = MkdirRequest{}
+	mi := &file_commands_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RunExecutableOptions) String() string {
+func (x *MkdirRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RunExecutableOptions) ProtoMessage() {}
+func (*MkdirRequest) ProtoMessage() {}
 
-func (x *RunExecutableOptions) ProtoReflect() protoreflect.Message {
-	mi := &file_commands_proto_msgTypes[1]
+func (x *MkdirRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -101,48 +2657,108 @@ func (x *RunExecutableOptions) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RunExecutableOptions.ProtoReflect.Descriptor instead.
-func (*RunExecutableOptions) Descriptor() ([]byte, []int) {
-	return file_commands_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use MkdirRequest.ProtoReflect.Descriptor instead.
+func (*MkdirRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *RunExecutableOptions) GetCommand() string {
+func (x *MkdirRequest) GetPath() string {
 	if x != nil {
-		return x.Command
+		return x.Path
 	}
 	return ""
 }
 
-func (x *RunExecutableOptions) GetArgs() []string {
+func (x *MkdirRequest) GetMode() uint32 {
 	if x != nil {
-		return x.Args
+		return x.Mode
 	}
-	return nil
+	return 0
 }
 
-type RunExecutableInput struct {
+func (x *MkdirRequest) GetParents() bool {
+	if x != nil {
+		return x.Parents
+	}
+	return false
+}
+
+type RemoveRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Remove a directory along with everything in it.
+	Recursive     bool `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveRequest) Reset() {
+	*x = RemoveRequest{}
+	mi := &file_commands_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveRequest) ProtoMessage() {}
+
+func (x *RemoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveRequest.ProtoReflect.Descriptor instead.
+func (*RemoveRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *RemoveRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RemoveRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+type RenameRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Options       *RunExecutableOptions  `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
-	Stdin         []byte                 `protobuf:"bytes,2,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	OldPath       string                 `protobuf:"bytes,1,opt,name=oldPath,proto3" json:"oldPath,omitempty"`
+	NewPath       string                 `protobuf:"bytes,2,opt,name=newPath,proto3" json:"newPath,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RunExecutableInput) Reset() {
-	*x = RunExecutableInput{}
-	mi := &file_commands_proto_msgTypes[2]
+func (x *RenameRequest) Reset() {
+	*x = RenameRequest{}
+	mi := &file_commands_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RunExecutableInput) String() string {
+func (x *RenameRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RunExecutableInput) ProtoMessage() {}
+func (*RenameRequest) ProtoMessage() {}
 
-func (x *RunExecutableInput) ProtoReflect() protoreflect.Message {
-	mi := &file_commands_proto_msgTypes[2]
+func (x *RenameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -153,49 +2769,48 @@ func (x *RunExecutableInput) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RunExecutableInput.ProtoReflect.Descriptor instead.
-func (*RunExecutableInput) Descriptor() ([]byte, []int) {
-	return file_commands_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use RenameRequest.ProtoReflect.Descriptor instead.
+func (*RenameRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *RunExecutableInput) GetOptions() *RunExecutableOptions {
+func (x *RenameRequest) GetOldPath() string {
 	if x != nil {
-		return x.Options
+		return x.OldPath
 	}
-	return nil
+	return ""
 }
 
-func (x *RunExecutableInput) GetStdin() []byte {
+func (x *RenameRequest) GetNewPath() string {
 	if x != nil {
-		return x.Stdin
+		return x.NewPath
 	}
-	return nil
+	return ""
 }
 
-type RunExecutableResult struct {
+type ChmodRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ReturnCode    int32                  `protobuf:"varint,1,opt,name=returnCode,proto3" json:"returnCode,omitempty"`
-	Stdout        []byte                 `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
-	Stderr        []byte                 `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Mode          uint32                 `protobuf:"varint,2,opt,name=mode,proto3" json:"mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RunExecutableResult) Reset() {
-	*x = RunExecutableResult{}
-	mi := &file_commands_proto_msgTypes[3]
+func (x *ChmodRequest) Reset() {
+	*x = ChmodRequest{}
+	mi := &file_commands_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RunExecutableResult) String() string {
+func (x *ChmodRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RunExecutableResult) ProtoMessage() {}
+func (*ChmodRequest) ProtoMessage() {}
 
-func (x *RunExecutableResult) ProtoReflect() protoreflect.Message {
-	mi := &file_commands_proto_msgTypes[3]
+func (x *ChmodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -206,56 +2821,110 @@ func (x *RunExecutableResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RunExecutableResult.ProtoReflect.Descriptor instead.
-func (*RunExecutableResult) Descriptor() ([]byte, []int) {
-	return file_commands_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use ChmodRequest.ProtoReflect.Descriptor instead.
+func (*ChmodRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *RunExecutableResult) GetReturnCode() int32 {
+func (x *ChmodRequest) GetPath() string {
 	if x != nil {
-		return x.ReturnCode
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ChmodRequest) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
 	}
 	return 0
 }
 
-func (x *RunExecutableResult) GetStdout() []byte {
+type ChownRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// User and group names or numeric ids. Either can be left empty to keep it as it is.
+	Owner         string `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Group         string `protobuf:"bytes,3,opt,name=group,proto3" json:"group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChownRequest) Reset() {
+	*x = ChownRequest{}
+	mi := &file_commands_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChownRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChownRequest) ProtoMessage() {}
+
+func (x *ChownRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[34]
 	if x != nil {
-		return x.Stdout
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *RunExecutableResult) GetStderr() []byte {
+// Deprecated: Use ChownRequest.ProtoReflect.Descriptor instead.
+func (*ChownRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ChownRequest) GetPath() string {
 	if x != nil {
-		return x.Stderr
+		return x.Path
 	}
-	return nil
+	return ""
 }
 
-// Upload/Download File
-type FileChunk struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Chunk         []byte                 `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+func (x *ChownRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *ChownRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+type SymlinkRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// What the link points to, stored as is.
+	Target        string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Path          string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FileChunk) Reset() {
-	*x = FileChunk{}
-	mi := &file_commands_proto_msgTypes[4]
+func (x *SymlinkRequest) Reset() {
+	*x = SymlinkRequest{}
+	mi := &file_commands_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FileChunk) String() string {
+func (x *SymlinkRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FileChunk) ProtoMessage() {}
+func (*SymlinkRequest) ProtoMessage() {}
 
-func (x *FileChunk) ProtoReflect() protoreflect.Message {
-	mi := &file_commands_proto_msgTypes[4]
+func (x *SymlinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commands_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -266,53 +2935,303 @@ func (x *FileChunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
-func (*FileChunk) Descriptor() ([]byte, []int) {
-	return file_commands_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use SymlinkRequest.ProtoReflect.Descriptor instead.
+func (*SymlinkRequest) Descriptor() ([]byte, []int) {
+	return file_commands_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *FileChunk) GetPath() string {
+func (x *SymlinkRequest) GetTarget() string {
 	if x != nil {
-		return x.Path
+		return x.Target
 	}
 	return ""
 }
 
-func (x *FileChunk) GetChunk() []byte {
+func (x *SymlinkRequest) GetPath() string {
 	if x != nil {
-		return x.Chunk
+		return x.Path
 	}
-	return nil
+	return ""
 }
 
 var File_commands_proto protoreflect.FileDescriptor
 
 const file_commands_proto_rawDesc = "" +
 	"\n" +
-	"\x0ecommands.proto\x12\x05proto\x1a\x1bgoogle/protobuf/empty.proto\"&\n" +
+	"\x0ecommands.proto\x12\x05proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xdc\x01\n" +
 	"\x10ConnectionParams\x12\x12\n" +
-	"\x04uuid\x18\x01 \x01(\fR\x04uuid\"D\n" +
+	"\x04uuid\x18\x01 \x01(\fR\x04uuid\x12\x1a\n" +
+	"\bhostname\x18\x02 \x01(\tR\bhostname\x12\x0e\n" +
+	"\x02os\x18\x03 \x01(\tR\x02os\x12\x12\n" +
+	"\x04arch\x18\x04 \x01(\tR\x04arch\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\tR\aversion\x12\"\n" +
+	"\fcapabilities\x18\x06 \x03(\tR\fcapabilities\x126\n" +
+	"\fcompressions\x18\a \x03(\x0e2\x12.proto.CompressionR\fcompressions\"4\n" +
+	"\n" +
+	"WindowSize\x12\x12\n" +
+	"\x04rows\x18\x01 \x01(\rR\x04rows\x12\x12\n" +
+	"\x04cols\x18\x02 \x01(\rR\x04cols\"\xca\x03\n" +
 	"\x14RunExecutableOptions\x12\x18\n" +
 	"\acommand\x18\x01 \x01(\tR\acommand\x12\x12\n" +
-	"\x04args\x18\x02 \x03(\tR\x04args\"a\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\x12\x10\n" +
+	"\x03pty\x18\x03 \x01(\bR\x03pty\x121\n" +
+	"\n" +
+	"windowSize\x18\x04 \x01(\v2\x11.proto.WindowSizeR\n" +
+	"windowSize\x12\x14\n" +
+	"\x05stdin\x18\x05 \x01(\bR\x05stdin\x12\x10\n" +
+	"\x03cwd\x18\x06 \x01(\tR\x03cwd\x126\n" +
+	"\x03env\x18\a \x03(\v2$.proto.RunExecutableOptions.EnvEntryR\x03env\x12(\n" +
+	"\aenvMode\x18\b \x01(\x0e2\x0e.proto.EnvModeR\aenvMode\x123\n" +
+	"\atimeout\x18\t \x01(\v2\x19.google.protobuf.DurationR\atimeout\x12\x12\n" +
+	"\x04user\x18\n" +
+	" \x01(\tR\x04user\x124\n" +
+	"\vcompression\x18\v \x01(\x0e2\x12.proto.CompressionR\vcompression\x1a6\n" +
+	"\bEnvEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"W\n" +
+	"\n" +
+	"SendSignal\x12%\n" +
+	"\x06signal\x18\x01 \x01(\x0e2\r.proto.SignalR\x06signal\x12\"\n" +
+	"\fprocessGroup\x18\x02 \x01(\bR\fprocessGroup\"\xe5\x01\n" +
 	"\x12RunExecutableInput\x125\n" +
 	"\aoptions\x18\x01 \x01(\v2\x1b.proto.RunExecutableOptionsR\aoptions\x12\x14\n" +
-	"\x05stdin\x18\x02 \x01(\fR\x05stdin\"e\n" +
+	"\x05stdin\x18\x02 \x01(\fR\x05stdin\x12)\n" +
+	"\x06resize\x18\x03 \x01(\v2\x11.proto.WindowSizeR\x06resize\x12)\n" +
+	"\x06signal\x18\x04 \x01(\v2\x11.proto.SendSignalR\x06signal\x12\x16\n" +
+	"\x06detach\x18\x05 \x01(\bR\x06detach\x12\x14\n" +
+	"\x05jobId\x18\x06 \x01(\tR\x05jobId\"\xcd\x01\n" +
 	"\x13RunExecutableResult\x12\x1e\n" +
 	"\n" +
 	"returnCode\x18\x01 \x01(\x05R\n" +
 	"returnCode\x12\x16\n" +
 	"\x06stdout\x18\x02 \x01(\fR\x06stdout\x12\x16\n" +
-	"\x06stderr\x18\x03 \x01(\fR\x06stderr\"5\n" +
+	"\x06stderr\x18\x03 \x01(\fR\x06stderr\x12\x14\n" +
+	"\x05jobId\x18\x04 \x01(\tR\x05jobId\x12\x1a\n" +
+	"\bdetached\x18\x05 \x01(\bR\bdetached\x124\n" +
+	"\vcompression\x18\x06 \x01(\x0e2\x12.proto.CompressionR\vcompression\"\x80\x03\n" +
+	"\aJobInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x18\n" +
+	"\acommand\x18\x03 \x01(\tR\acommand\x12\x12\n" +
+	"\x04args\x18\x04 \x03(\tR\x04args\x12\x10\n" +
+	"\x03pty\x18\x05 \x01(\bR\x03pty\x12%\n" +
+	"\x05state\x18\x06 \x01(\x0e2\x0f.proto.JobStateR\x05state\x12\x1e\n" +
+	"\n" +
+	"returnCode\x18\a \x01(\x05R\n" +
+	"returnCode\x128\n" +
+	"\tstartTime\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x124\n" +
+	"\aendTime\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\x12 \n" +
+	"\voutputBytes\x18\n" +
+	" \x01(\x03R\voutputBytes\x12\x1a\n" +
+	"\battached\x18\v \x01(\x05R\battached\x12\x1a\n" +
+	"\bdetached\x18\f \x01(\bR\bdetached\"-\n" +
+	"\aJobList\x12\"\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x0e.proto.JobInfoR\x04jobs\"L\n" +
+	"\tJobSignal\x12\x14\n" +
+	"\x05jobId\x18\x01 \x01(\tR\x05jobId\x12)\n" +
+	"\x06signal\x18\x02 \x01(\v2\x11.proto.SendSignalR\x06signal\"\xaf\x01\n" +
 	"\tFileChunk\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
-	"\x05chunk\x18\x02 \x01(\fR\x05chunk2\x95\x02\n" +
+	"\x05chunk\x18\x02 \x01(\fR\x05chunk\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\fR\x06sha256\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x124\n" +
+	"\vcompression\x18\x06 \x01(\x0e2\x12.proto.CompressionR\vcompression\"L\n" +
+	"\n" +
+	"FileStatus\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x03 \x01(\fR\x06sha256\"\xc3\x01\n" +
+	"\tTreeEntry\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12$\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x10.proto.EntryTypeR\x04type\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\rR\x04mode\x124\n" +
+	"\amodTime\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\amodTime\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x12\x1e\n" +
+	"\n" +
+	"linkTarget\x18\x06 \x01(\tR\n" +
+	"linkTarget\"U\n" +
+	"\vTreeRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\ainclude\x18\x02 \x03(\tR\ainclude\x12\x18\n" +
+	"\aexclude\x18\x03 \x03(\tR\aexclude\"u\n" +
+	"\tTreeChunk\x12,\n" +
+	"\arequest\x18\x01 \x01(\v2\x12.proto.TreeRequestR\arequest\x12&\n" +
+	"\x05entry\x18\x02 \x01(\v2\x10.proto.TreeEntryR\x05entry\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"i\n" +
+	"\vTreeSummary\x12\x14\n" +
+	"\x05files\x18\x01 \x01(\x05R\x05files\x12\x12\n" +
+	"\x04dirs\x18\x02 \x01(\x05R\x04dirs\x12\x1a\n" +
+	"\bsymlinks\x18\x03 \x01(\x05R\bsymlinks\x12\x14\n" +
+	"\x05bytes\x18\x04 \x01(\x03R\x05bytes\"W\n" +
+	"\vHashRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x124\n" +
+	"\n" +
+	"algorithms\x18\x02 \x03(\x0e2\x14.proto.HashAlgorithmR\n" +
+	"algorithms\"N\n" +
+	"\x06Digest\x122\n" +
+	"\talgorithm\x18\x01 \x01(\x0e2\x14.proto.HashAlgorithmR\talgorithm\x12\x10\n" +
+	"\x03sum\x18\x02 \x01(\fR\x03sum\"[\n" +
+	"\bFileHash\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12'\n" +
+	"\adigests\x18\x03 \x03(\v2\r.proto.DigestR\adigests\"\xd7\x01\n" +
+	"\vSyncRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x122\n" +
+	"\tdirection\x18\x02 \x01(\x0e2\x14.proto.SyncDirectionR\tdirection\x12\x16\n" +
+	"\x06dryRun\x18\x03 \x01(\bR\x06dryRun\x12\x18\n" +
+	"\ainclude\x18\x04 \x03(\tR\ainclude\x12\x18\n" +
+	"\aexclude\x18\x05 \x03(\tR\aexclude\x124\n" +
+	"\vcompression\x18\x06 \x01(\x0e2\x12.proto.CompressionR\vcompression\"<\n" +
+	"\x0eBlockSignature\x12\x12\n" +
+	"\x04weak\x18\x01 \x01(\rR\x04weak\x12\x16\n" +
+	"\x06strong\x18\x02 \x01(\fR\x06strong\"\x86\x01\n" +
+	"\x0fBlockSignatures\x12\x1c\n" +
+	"\tblockSize\x18\x01 \x01(\x05R\tblockSize\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12-\n" +
+	"\x06blocks\x18\x03 \x03(\v2\x15.proto.BlockSignatureR\x06blocks\x12\x12\n" +
+	"\x04last\x18\x04 \x01(\bR\x04last\"7\n" +
+	"\tBlockCopy\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"q\n" +
+	"\n" +
+	"SyncChange\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12$\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x10.proto.EntryTypeR\x04type\x12)\n" +
+	"\x06action\x18\x03 \x01(\x0e2\x11.proto.SyncActionR\x06action\"\x9f\x01\n" +
+	"\n" +
+	"SyncResult\x12+\n" +
+	"\achanges\x18\x01 \x03(\v2\x11.proto.SyncChangeR\achanges\x12\x1c\n" +
+	"\tunchanged\x18\x02 \x01(\x05R\tunchanged\x12\"\n" +
+	"\fliteralBytes\x18\x03 \x01(\x03R\fliteralBytes\x12\"\n" +
+	"\fmatchedBytes\x18\x04 \x01(\x03R\fmatchedBytes\"\xba\x02\n" +
+	"\vSyncMessage\x12,\n" +
+	"\arequest\x18\x01 \x01(\v2\x12.proto.SyncRequestR\arequest\x12&\n" +
+	"\x05entry\x18\x02 \x01(\v2\x10.proto.TreeEntryR\x05entry\x12\x12\n" +
+	"\x04skip\x18\x03 \x01(\bR\x04skip\x126\n" +
+	"\n" +
+	"signatures\x18\x04 \x01(\v2\x16.proto.BlockSignaturesR\n" +
+	"signatures\x12$\n" +
+	"\x04copy\x18\x05 \x01(\v2\x10.proto.BlockCopyR\x04copy\x12$\n" +
+	"\x04data\x18\x06 \x01(\v2\x10.proto.FileChunkR\x04data\x12\x12\n" +
+	"\x04done\x18\a \x01(\bR\x04done\x12)\n" +
+	"\x06result\x18\b \x01(\v2\x11.proto.SyncResultR\x06result\"\x92\x02\n" +
+	"\bFileInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12$\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x10.proto.EntryTypeR\x04type\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\rR\x04mode\x124\n" +
+	"\amodTime\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\amodTime\x12\x1e\n" +
+	"\n" +
+	"linkTarget\x18\x06 \x01(\tR\n" +
+	"linkTarget\x12\x10\n" +
+	"\x03uid\x18\a \x01(\rR\x03uid\x12\x10\n" +
+	"\x03gid\x18\b \x01(\rR\x03gid\x12\x14\n" +
+	"\x05owner\x18\t \x01(\tR\x05owner\x12\x14\n" +
+	"\x05group\x18\n" +
+	" \x01(\tR\x05group\"!\n" +
+	"\vPathRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"9\n" +
+	"\vStatRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06follow\x18\x02 \x01(\bR\x06follow\"^\n" +
+	"\x0eListDirRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1a\n" +
+	"\bpageSize\x18\x02 \x01(\x05R\bpageSize\x12\x1c\n" +
+	"\tpageToken\x18\x03 \x01(\tR\tpageToken\"b\n" +
+	"\x0fListDirResponse\x12)\n" +
+	"\aentries\x18\x01 \x03(\v2\x0f.proto.FileInfoR\aentries\x12$\n" +
+	"\rnextPageToken\x18\x02 \x01(\tR\rnextPageToken\"P\n" +
+	"\fMkdirRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\rR\x04mode\x12\x18\n" +
+	"\aparents\x18\x03 \x01(\bR\aparents\"A\n" +
+	"\rRemoveRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1c\n" +
+	"\trecursive\x18\x02 \x01(\bR\trecursive\"C\n" +
+	"\rRenameRequest\x12\x18\n" +
+	"\aoldPath\x18\x01 \x01(\tR\aoldPath\x12\x18\n" +
+	"\anewPath\x18\x02 \x01(\tR\anewPath\"6\n" +
+	"\fChmodRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\rR\x04mode\"N\n" +
+	"\fChownRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x14\n" +
+	"\x05group\x18\x03 \x01(\tR\x05group\"<\n" +
+	"\x0eSymlinkRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path*O\n" +
+	"\vCompression\x12\x14\n" +
+	"\x10COMPRESSION_NONE\x10\x00\x12\x14\n" +
+	"\x10COMPRESSION_GZIP\x10\x01\x12\x14\n" +
+	"\x10COMPRESSION_ZSTD\x10\x02*)\n" +
+	"\aEnvMode\x12\x0f\n" +
+	"\vENV_INHERIT\x10\x00\x12\r\n" +
+	"\tENV_CLEAR\x10\x01*\xb0\x01\n" +
+	"\x06Signal\x12\x0f\n" +
+	"\vSIGNAL_NONE\x10\x00\x12\x0e\n" +
+	"\n" +
+	"SIGNAL_HUP\x10\x01\x12\x0e\n" +
+	"\n" +
+	"SIGNAL_INT\x10\x02\x12\x0f\n" +
+	"\vSIGNAL_QUIT\x10\x03\x12\x0f\n" +
+	"\vSIGNAL_KILL\x10\t\x12\x0f\n" +
+	"\vSIGNAL_USR1\x10\n" +
+	"\x12\x0f\n" +
+	"\vSIGNAL_USR2\x10\f\x12\x0f\n" +
+	"\vSIGNAL_TERM\x10\x0f\x12\x0f\n" +
+	"\vSIGNAL_CONT\x10\x12\x12\x0f\n" +
+	"\vSIGNAL_STOP\x10\x13*+\n" +
+	"\bJobState\x12\x0f\n" +
+	"\vJOB_RUNNING\x10\x00\x12\x0e\n" +
+	"\n" +
+	"JOB_EXITED\x10\x01*N\n" +
+	"\tEntryType\x12\x0e\n" +
+	"\n" +
+	"ENTRY_FILE\x10\x00\x12\r\n" +
+	"\tENTRY_DIR\x10\x01\x12\x11\n" +
+	"\rENTRY_SYMLINK\x10\x02\x12\x0f\n" +
+	"\vENTRY_OTHER\x10\x03*O\n" +
+	"\rHashAlgorithm\x12\x0f\n" +
+	"\vHASH_SHA256\x10\x00\x12\r\n" +
+	"\tHASH_SHA1\x10\x01\x12\f\n" +
+	"\bHASH_MD5\x10\x02\x12\x10\n" +
+	"\fHASH_BLAKE2B\x10\x03*-\n" +
+	"\rSyncDirection\x12\r\n" +
+	"\tSYNC_PUSH\x10\x00\x12\r\n" +
+	"\tSYNC_PULL\x10\x01*.\n" +
+	"\n" +
+	"SyncAction\x12\x0f\n" +
+	"\vSYNC_CREATE\x10\x00\x12\x0f\n" +
+	"\vSYNC_UPDATE\x10\x012\xa8\x04\n" +
 	"\aCommand\x12H\n" +
 	"\x13GetConnectionParams\x12\x16.google.protobuf.Empty\x1a\x17.proto.ConnectionParams\"\x00\x12L\n" +
-	"\rRunExecutable\x12\x19.proto.RunExecutableInput\x1a\x1a.proto.RunExecutableResult\"\x00(\x010\x01\x12:\n" +
+	"\rRunExecutable\x12\x19.proto.RunExecutableInput\x1a\x1a.proto.RunExecutableResult\"\x00(\x010\x01\x129\n" +
+	"\x10FileUploadStatus\x12\x10.proto.FileChunk\x1a\x11.proto.FileStatus\"\x00\x125\n" +
+	"\n" +
+	"FileUpload\x12\x10.proto.FileChunk\x1a\x11.proto.FileStatus\"\x00(\x01\x126\n" +
+	"\fFileDownload\x12\x10.proto.FileChunk\x1a\x10.proto.FileChunk\"\x000\x01\x126\n" +
+	"\n" +
+	"TreeUpload\x12\x10.proto.TreeChunk\x1a\x12.proto.TreeSummary\"\x00(\x01\x128\n" +
+	"\fTreeDownload\x12\x12.proto.TreeRequest\x1a\x10.proto.TreeChunk\"\x000\x01\x123\n" +
+	"\bHashFile\x12\x12.proto.HashRequest\x1a\x0f.proto.FileHash\"\x000\x01\x124\n" +
+	"\x04Sync\x12\x12.proto.SyncMessage\x1a\x12.proto.SyncMessage\"\x00(\x010\x012\xbf\x01\n" +
+	"\x04Jobs\x124\n" +
+	"\bListJobs\x12\x16.google.protobuf.Empty\x1a\x0e.proto.JobList\"\x00\x12H\n" +
+	"\tAttachJob\x12\x19.proto.RunExecutableInput\x1a\x1a.proto.RunExecutableResult\"\x00(\x010\x01\x127\n" +
+	"\tSignalJob\x12\x10.proto.JobSignal\x1a\x16.google.protobuf.Empty\"\x002\xdf\x03\n" +
 	"\n" +
-	"FileUpload\x12\x10.proto.FileChunk\x1a\x16.google.protobuf.Empty\"\x00(\x01\x126\n" +
-	"\fFileDownload\x12\x10.proto.FileChunk\x1a\x10.proto.FileChunk\"\x000\x01B\tZ\a./protob\x06proto3"
+	"Filesystem\x12-\n" +
+	"\x04Stat\x12\x12.proto.StatRequest\x1a\x0f.proto.FileInfo\"\x00\x12:\n" +
+	"\aListDir\x12\x15.proto.ListDirRequest\x1a\x16.proto.ListDirResponse\"\x00\x12/\n" +
+	"\x05Mkdir\x12\x13.proto.MkdirRequest\x1a\x0f.proto.FileInfo\"\x00\x128\n" +
+	"\x06Remove\x12\x14.proto.RemoveRequest\x1a\x16.google.protobuf.Empty\"\x00\x121\n" +
+	"\x06Rename\x12\x14.proto.RenameRequest\x1a\x0f.proto.FileInfo\"\x00\x12/\n" +
+	"\x05Chmod\x12\x13.proto.ChmodRequest\x1a\x0f.proto.FileInfo\"\x00\x12/\n" +
+	"\x05Chown\x12\x13.proto.ChownRequest\x1a\x0f.proto.FileInfo\"\x00\x123\n" +
+	"\aSymlink\x12\x15.proto.SymlinkRequest\x1a\x0f.proto.FileInfo\"\x00\x121\n" +
+	"\bReadlink\x12\x12.proto.PathRequest\x1a\x0f.proto.FileInfo\"\x00B\tZ\a./protob\x06proto3"
 
 var (
 	file_commands_proto_rawDescOnce sync.Once
@@ -326,30 +3245,145 @@ func file_commands_proto_rawDescGZIP() []byte {
 	return file_commands_proto_rawDescData
 }
 
-var file_commands_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_commands_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_commands_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_commands_proto_goTypes = []any{
-	(*ConnectionParams)(nil),     // 0: proto.ConnectionParams
-	(*RunExecutableOptions)(nil), // 1: proto.RunExecutableOptions
-	(*RunExecutableInput)(nil),   // 2: proto.RunExecutableInput
-	(*RunExecutableResult)(nil),  // 3: proto.RunExecutableResult
-	(*FileChunk)(nil),            // 4: proto.FileChunk
-	(*emptypb.Empty)(nil),        // 5: google.protobuf.Empty
+	(Compression)(0),              // 0: proto.Compression
+	(EnvMode)(0),                  // 1: proto.EnvMode
+	(Signal)(0),                   // 2: proto.Signal
+	(JobState)(0),                 // 3: proto.JobState
+	(EntryType)(0),                // 4: proto.EntryType
+	(HashAlgorithm)(0),            // 5: proto.HashAlgorithm
+	(SyncDirection)(0),            // 6: proto.SyncDirection
+	(SyncAction)(0),               // 7: proto.SyncAction
+	(*ConnectionParams)(nil),      // 8: proto.ConnectionParams
+	(*WindowSize)(nil),            // 9: proto.WindowSize
+	(*RunExecutableOptions)(nil),  // 10: proto.RunExecutableOptions
+	(*SendSignal)(nil),            // 11: proto.SendSignal
+	(*RunExecutableInput)(nil),    // 12: proto.RunExecutableInput
+	(*RunExecutableResult)(nil),   // 13: proto.RunExecutableResult
+	(*JobInfo)(nil),               // 14: proto.JobInfo
+	(*JobList)(nil),               // 15: proto.JobList
+	(*JobSignal)(nil),             // 16: proto.JobSignal
+	(*FileChunk)(nil),             // 17: proto.FileChunk
+	(*FileStatus)(nil),            // 18: proto.FileStatus
+	(*TreeEntry)(nil),             // 19: proto.TreeEntry
+	(*TreeRequest)(nil),           // 20: proto.TreeRequest
+	(*TreeChunk)(nil),             // 21: proto.TreeChunk
+	(*TreeSummary)(nil),           // 22: proto.TreeSummary
+	(*HashRequest)(nil),           // 23: proto.HashRequest
+	(*Digest)(nil),                // 24: proto.Digest
+	(*FileHash)(nil),              // 25: proto.FileHash
+	(*SyncRequest)(nil),           // 26: proto.SyncRequest
+	(*BlockSignature)(nil),        // 27: proto.BlockSignature
+	(*BlockSignatures)(nil),       // 28: proto.BlockSignatures
+	(*BlockCopy)(nil),             // 29: proto.BlockCopy
+	(*SyncChange)(nil),            // 30: proto.SyncChange
+	(*SyncResult)(nil),            // 31: proto.SyncResult
+	(*SyncMessage)(nil),           // 32: proto.SyncMessage
+	(*FileInfo)(nil),              // 33: proto.FileInfo
+	(*PathRequest)(nil),           // 34: proto.PathRequest
+	(*StatRequest)(nil),           // 35: proto.StatRequest
+	(*ListDirRequest)(nil),        // 36: proto.ListDirRequest
+	(*ListDirResponse)(nil),       // 37: proto.ListDirResponse
+	(*MkdirRequest)(nil),          // 38: proto.MkdirRequest
+	(*RemoveRequest)(nil),         // 39: proto.RemoveRequest
+	(*RenameRequest)(nil),         // 40: proto.RenameRequest
+	(*ChmodRequest)(nil),          // 41: proto.ChmodRequest
+	(*ChownRequest)(nil),          // 42: proto.ChownRequest
+	(*SymlinkRequest)(nil),        // 43: proto.SymlinkRequest
+	nil,                           // 44: proto.RunExecutableOptions.EnvEntry
+	(*durationpb.Duration)(nil),   // 45: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 46: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 47: google.protobuf.Empty
 }
 var file_commands_proto_depIdxs = []int32{
-	1, // 0: proto.RunExecutableInput.options:type_name -> proto.RunExecutableOptions
-	5, // 1: proto.Command.GetConnectionParams:input_type -> google.protobuf.Empty
-	2, // 2: proto.Command.RunExecutable:input_type -> proto.RunExecutableInput
-	4, // 3: proto.Command.FileUpload:input_type -> proto.FileChunk
-	4, // 4: proto.Command.FileDownload:input_type -> proto.FileChunk
-	0, // 5: proto.Command.GetConnectionParams:output_type -> proto.ConnectionParams
-	3, // 6: proto.Command.RunExecutable:output_type -> proto.RunExecutableResult
-	5, // 7: proto.Command.FileUpload:output_type -> google.protobuf.Empty
-	4, // 8: proto.Command.FileDownload:output_type -> proto.FileChunk
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	0,  // 0: proto.ConnectionParams.compressions:type_name -> proto.Compression
+	9,  // 1: proto.RunExecutableOptions.windowSize:type_name -> proto.WindowSize
+	44, // 2: proto.RunExecutableOptions.env:type_name -> proto.RunExecutableOptions.EnvEntry
+	1,  // 3: proto.RunExecutableOptions.envMode:type_name -> proto.EnvMode
+	45, // 4: proto.RunExecutableOptions.timeout:type_name -> google.protobuf.Duration
+	0,  // 5: proto.RunExecutableOptions.compression:type_name -> proto.Compression
+	2,  // 6: proto.SendSignal.signal:type_name -> proto.Signal
+	10, // 7: proto.RunExecutableInput.options:type_name -> proto.RunExecutableOptions
+	9,  // 8: proto.RunExecutableInput.resize:type_name -> proto.WindowSize
+	11, // 9: proto.RunExecutableInput.signal:type_name -> proto.SendSignal
+	0,  // 10: proto.RunExecutableResult.compression:type_name -> proto.Compression
+	3,  // 11: proto.JobInfo.state:type_name -> proto.JobState
+	46, // 12: proto.JobInfo.startTime:type_name -> google.protobuf.Timestamp
+	46, // 13: proto.JobInfo.endTime:type_name -> google.protobuf.Timestamp
+	14, // 14: proto.JobList.jobs:type_name -> proto.JobInfo
+	11, // 15: proto.JobSignal.signal:type_name -> proto.SendSignal
+	0,  // 16: proto.FileChunk.compression:type_name -> proto.Compression
+	4,  // 17: proto.TreeEntry.type:type_name -> proto.EntryType
+	46, // 18: proto.TreeEntry.modTime:type_name -> google.protobuf.Timestamp
+	20, // 19: proto.TreeChunk.request:type_name -> proto.TreeRequest
+	19, // 20: proto.TreeChunk.entry:type_name -> proto.TreeEntry
+	5,  // 21: proto.HashRequest.algorithms:type_name -> proto.HashAlgorithm
+	5,  // 22: proto.Digest.algorithm:type_name -> proto.HashAlgorithm
+	24, // 23: proto.FileHash.digests:type_name -> proto.Digest
+	6,  // 24: proto.SyncRequest.direction:type_name -> proto.SyncDirection
+	0,  // 25: proto.SyncRequest.compression:type_name -> proto.Compression
+	27, // 26: proto.BlockSignatures.blocks:type_name -> proto.BlockSignature
+	4,  // 27: proto.SyncChange.type:type_name -> proto.EntryType
+	7,  // 28: proto.SyncChange.action:type_name -> proto.SyncAction
+	30, // 29: proto.SyncResult.changes:type_name -> proto.SyncChange
+	26, // 30: proto.SyncMessage.request:type_name -> proto.SyncRequest
+	19, // 31: proto.SyncMessage.entry:type_name -> proto.TreeEntry
+	28, // 32: proto.SyncMessage.signatures:type_name -> proto.BlockSignatures
+	29, // 33: proto.SyncMessage.copy:type_name -> proto.BlockCopy
+	17, // 34: proto.SyncMessage.data:type_name -> proto.FileChunk
+	31, // 35: proto.SyncMessage.result:type_name -> proto.SyncResult
+	4,  // 36: proto.FileInfo.type:type_name -> proto.EntryType
+	46, // 37: proto.FileInfo.modTime:type_name -> google.protobuf.Timestamp
+	33, // 38: proto.ListDirResponse.entries:type_name -> proto.FileInfo
+	47, // 39: proto.Command.GetConnectionParams:input_type -> google.protobuf.Empty
+	12, // 40: proto.Command.RunExecutable:input_type -> proto.RunExecutableInput
+	17, // 41: proto.Command.FileUploadStatus:input_type -> proto.FileChunk
+	17, // 42: proto.Command.FileUpload:input_type -> proto.FileChunk
+	17, // 43: proto.Command.FileDownload:input_type -> proto.FileChunk
+	21, // 44: proto.Command.TreeUpload:input_type -> proto.TreeChunk
+	20, // 45: proto.Command.TreeDownload:input_type -> proto.TreeRequest
+	23, // 46: proto.Command.HashFile:input_type -> proto.HashRequest
+	32, // 47: proto.Command.Sync:input_type -> proto.SyncMessage
+	47, // 48: proto.Jobs.ListJobs:input_type -> google.protobuf.Empty
+	12, // 49: proto.Jobs.AttachJob:input_type -> proto.RunExecutableInput
+	16, // 50: proto.Jobs.SignalJob:input_type -> proto.JobSignal
+	35, // 51: proto.Filesystem.Stat:input_type -> proto.StatRequest
+	36, // 52: proto.Filesystem.ListDir:input_type -> proto.ListDirRequest
+	38, // 53: proto.Filesystem.Mkdir:input_type -> proto.MkdirRequest
+	39, // 54: proto.Filesystem.Remove:input_type -> proto.RemoveRequest
+	40, // 55: proto.Filesystem.Rename:input_type -> proto.RenameRequest
+	41, // 56: proto.Filesystem.Chmod:input_type -> proto.ChmodRequest
+	42, // 57: proto.Filesystem.Chown:input_type -> proto.ChownRequest
+	43, // 58: proto.Filesystem.Symlink:input_type -> proto.SymlinkRequest
+	34, // 59: proto.Filesystem.Readlink:input_type -> proto.PathRequest
+	8,  // 60: proto.Command.GetConnectionParams:output_type -> proto.ConnectionParams
+	13, // 61: proto.Command.RunExecutable:output_type -> proto.RunExecutableResult
+	18, // 62: proto.Command.FileUploadStatus:output_type -> proto.FileStatus
+	18, // 63: proto.Command.FileUpload:output_type -> proto.FileStatus
+	17, // 64: proto.Command.FileDownload:output_type -> proto.FileChunk
+	22, // 65: proto.Command.TreeUpload:output_type -> proto.TreeSummary
+	21, // 66: proto.Command.TreeDownload:output_type -> proto.TreeChunk
+	25, // 67: proto.Command.HashFile:output_type -> proto.FileHash
+	32, // 68: proto.Command.Sync:output_type -> proto.SyncMessage
+	15, // 69: proto.Jobs.ListJobs:output_type -> proto.JobList
+	13, // 70: proto.Jobs.AttachJob:output_type -> proto.RunExecutableResult
+	47, // 71: proto.Jobs.SignalJob:output_type -> google.protobuf.Empty
+	33, // 72: proto.Filesystem.Stat:output_type -> proto.FileInfo
+	37, // 73: proto.Filesystem.ListDir:output_type -> proto.ListDirResponse
+	33, // 74: proto.Filesystem.Mkdir:output_type -> proto.FileInfo
+	47, // 75: proto.Filesystem.Remove:output_type -> google.protobuf.Empty
+	33, // 76: proto.Filesystem.Rename:output_type -> proto.FileInfo
+	33, // 77: proto.Filesystem.Chmod:output_type -> proto.FileInfo
+	33, // 78: proto.Filesystem.Chown:output_type -> proto.FileInfo
+	33, // 79: proto.Filesystem.Symlink:output_type -> proto.FileInfo
+	33, // 80: proto.Filesystem.Readlink:output_type -> proto.FileInfo
+	60, // [60:81] is the sub-list for method output_type
+	39, // [39:60] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
 }
 
 func init() { file_commands_proto_init() }
@@ -362,13 +3396,14 @@ func file_commands_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_commands_proto_rawDesc), len(file_commands_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   5,
+			NumEnums:      8,
+			NumMessages:   37,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   3,
 		},
 		GoTypes:           file_commands_proto_goTypes,
 		DependencyIndexes: file_commands_proto_depIdxs,
+		EnumInfos:         file_commands_proto_enumTypes,
 		MessageInfos:      file_commands_proto_msgTypes,
 	}.Build()
 	File_commands_proto = out.File
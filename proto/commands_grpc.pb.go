@@ -22,8 +22,13 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	Command_GetConnectionParams_FullMethodName = "/proto.Command/GetConnectionParams"
 	Command_RunExecutable_FullMethodName       = "/proto.Command/RunExecutable"
+	Command_FileUploadStatus_FullMethodName    = "/proto.Command/FileUploadStatus"
 	Command_FileUpload_FullMethodName          = "/proto.Command/FileUpload"
 	Command_FileDownload_FullMethodName        = "/proto.Command/FileDownload"
+	Command_TreeUpload_FullMethodName          = "/proto.Command/TreeUpload"
+	Command_TreeDownload_FullMethodName        = "/proto.Command/TreeDownload"
+	Command_HashFile_FullMethodName            = "/proto.Command/HashFile"
+	Command_Sync_FullMethodName                = "/proto.Command/Sync"
 )
 
 // CommandClient is the client API for Command service.
@@ -32,8 +37,17 @@ const (
 type CommandClient interface {
 	GetConnectionParams(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ConnectionParams, error)
 	RunExecutable(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RunExecutableInput, RunExecutableResult], error)
-	FileUpload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileChunk, emptypb.Empty], error)
+	// Report how much of an interrupted upload to path the server already has, so the next upload can resume from there.
+	FileUploadStatus(ctx context.Context, in *FileChunk, opts ...grpc.CallOption) (*FileStatus, error)
+	FileUpload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileChunk, FileStatus], error)
 	FileDownload(ctx context.Context, in *FileChunk, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error)
+	TreeUpload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[TreeChunk, TreeSummary], error)
+	TreeDownload(ctx context.Context, in *TreeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TreeChunk], error)
+	// Hash a file on the server without transferring it. A directory is walked and every regular file in it is sent,
+	// sorted by path, which makes a manifest of the tree.
+	HashFile(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileHash], error)
+	// Bring a file or directory tree up to date in either direction, only sending the blocks of files that changed.
+	Sync(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SyncMessage, SyncMessage], error)
 }
 
 type commandClient struct {
@@ -67,18 +81,28 @@ func (c *commandClient) RunExecutable(ctx context.Context, opts ...grpc.CallOpti
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Command_RunExecutableClient = grpc.BidiStreamingClient[RunExecutableInput, RunExecutableResult]
 
-func (c *commandClient) FileUpload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileChunk, emptypb.Empty], error) {
+func (c *commandClient) FileUploadStatus(ctx context.Context, in *FileChunk, opts ...grpc.CallOption) (*FileStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileStatus)
+	err := c.cc.Invoke(ctx, Command_FileUploadStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commandClient) FileUpload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileChunk, FileStatus], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &Command_ServiceDesc.Streams[1], Command_FileUpload_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &grpc.GenericClientStream[FileChunk, emptypb.Empty]{ClientStream: stream}
+	x := &grpc.GenericClientStream[FileChunk, FileStatus]{ClientStream: stream}
 	return x, nil
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type Command_FileUploadClient = grpc.ClientStreamingClient[FileChunk, emptypb.Empty]
+type Command_FileUploadClient = grpc.ClientStreamingClient[FileChunk, FileStatus]
 
 func (c *commandClient) FileDownload(ctx context.Context, in *FileChunk, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
@@ -99,14 +123,87 @@ func (c *commandClient) FileDownload(ctx context.Context, in *FileChunk, opts ..
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Command_FileDownloadClient = grpc.ServerStreamingClient[FileChunk]
 
+func (c *commandClient) TreeUpload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[TreeChunk, TreeSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Command_ServiceDesc.Streams[3], Command_TreeUpload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TreeChunk, TreeSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Command_TreeUploadClient = grpc.ClientStreamingClient[TreeChunk, TreeSummary]
+
+func (c *commandClient) TreeDownload(ctx context.Context, in *TreeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TreeChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Command_ServiceDesc.Streams[4], Command_TreeDownload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TreeRequest, TreeChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Command_TreeDownloadClient = grpc.ServerStreamingClient[TreeChunk]
+
+func (c *commandClient) HashFile(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileHash], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Command_ServiceDesc.Streams[5], Command_HashFile_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[HashRequest, FileHash]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Command_HashFileClient = grpc.ServerStreamingClient[FileHash]
+
+func (c *commandClient) Sync(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SyncMessage, SyncMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Command_ServiceDesc.Streams[6], Command_Sync_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SyncMessage, SyncMessage]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Command_SyncClient = grpc.BidiStreamingClient[SyncMessage, SyncMessage]
+
 // CommandServer is the server API for Command service.
 // All implementations must embed UnimplementedCommandServer
 // for forward compatibility.
 type CommandServer interface {
 	GetConnectionParams(context.Context, *emptypb.Empty) (*ConnectionParams, error)
 	RunExecutable(grpc.BidiStreamingServer[RunExecutableInput, RunExecutableResult]) error
-	FileUpload(grpc.ClientStreamingServer[FileChunk, emptypb.Empty]) error
+	// Report how much of an interrupted upload to path the server already has, so the next upload can resume from there.
+	FileUploadStatus(context.Context, *FileChunk) (*FileStatus, error)
+	FileUpload(grpc.ClientStreamingServer[FileChunk, FileStatus]) error
 	FileDownload(*FileChunk, grpc.ServerStreamingServer[FileChunk]) error
+	TreeUpload(grpc.ClientStreamingServer[TreeChunk, TreeSummary]) error
+	TreeDownload(*TreeRequest, grpc.ServerStreamingServer[TreeChunk]) error
+	// Hash a file on the server without transferring it. A directory is walked and every regular file in it is sent,
+	// sorted by path, which makes a manifest of the tree.
+	HashFile(*HashRequest, grpc.ServerStreamingServer[FileHash]) error
+	// Bring a file or directory tree up to date in either direction, only sending the blocks of files that changed.
+	Sync(grpc.BidiStreamingServer[SyncMessage, SyncMessage]) error
 	mustEmbedUnimplementedCommandServer()
 }
 
@@ -123,12 +220,27 @@ func (UnimplementedCommandServer) GetConnectionParams(context.Context, *emptypb.
 func (UnimplementedCommandServer) RunExecutable(grpc.BidiStreamingServer[RunExecutableInput, RunExecutableResult]) error {
 	return status.Errorf(codes.Unimplemented, "method RunExecutable not implemented")
 }
-func (UnimplementedCommandServer) FileUpload(grpc.ClientStreamingServer[FileChunk, emptypb.Empty]) error {
+func (UnimplementedCommandServer) FileUploadStatus(context.Context, *FileChunk) (*FileStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileUploadStatus not implemented")
+}
+func (UnimplementedCommandServer) FileUpload(grpc.ClientStreamingServer[FileChunk, FileStatus]) error {
 	return status.Errorf(codes.Unimplemented, "method FileUpload not implemented")
 }
 func (UnimplementedCommandServer) FileDownload(*FileChunk, grpc.ServerStreamingServer[FileChunk]) error {
 	return status.Errorf(codes.Unimplemented, "method FileDownload not implemented")
 }
+func (UnimplementedCommandServer) TreeUpload(grpc.ClientStreamingServer[TreeChunk, TreeSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method TreeUpload not implemented")
+}
+func (UnimplementedCommandServer) TreeDownload(*TreeRequest, grpc.ServerStreamingServer[TreeChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method TreeDownload not implemented")
+}
+func (UnimplementedCommandServer) HashFile(*HashRequest, grpc.ServerStreamingServer[FileHash]) error {
+	return status.Errorf(codes.Unimplemented, "method HashFile not implemented")
+}
+func (UnimplementedCommandServer) Sync(grpc.BidiStreamingServer[SyncMessage, SyncMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
 func (UnimplementedCommandServer) mustEmbedUnimplementedCommandServer() {}
 func (UnimplementedCommandServer) testEmbeddedByValue()                 {}
 
@@ -175,12 +287,30 @@ func _Command_RunExecutable_Handler(srv interface{}, stream grpc.ServerStream) e
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Command_RunExecutableServer = grpc.BidiStreamingServer[RunExecutableInput, RunExecutableResult]
 
+func _Command_FileUploadStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileChunk)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommandServer).FileUploadStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Command_FileUploadStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommandServer).FileUploadStatus(ctx, req.(*FileChunk))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Command_FileUpload_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(CommandServer).FileUpload(&grpc.GenericServerStream[FileChunk, emptypb.Empty]{ServerStream: stream})
+	return srv.(CommandServer).FileUpload(&grpc.GenericServerStream[FileChunk, FileStatus]{ServerStream: stream})
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type Command_FileUploadServer = grpc.ClientStreamingServer[FileChunk, emptypb.Empty]
+type Command_FileUploadServer = grpc.ClientStreamingServer[FileChunk, FileStatus]
 
 func _Command_FileDownload_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(FileChunk)
@@ -193,6 +323,42 @@ func _Command_FileDownload_Handler(srv interface{}, stream grpc.ServerStream) er
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Command_FileDownloadServer = grpc.ServerStreamingServer[FileChunk]
 
+func _Command_TreeUpload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CommandServer).TreeUpload(&grpc.GenericServerStream[TreeChunk, TreeSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Command_TreeUploadServer = grpc.ClientStreamingServer[TreeChunk, TreeSummary]
+
+func _Command_TreeDownload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TreeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CommandServer).TreeDownload(m, &grpc.GenericServerStream[TreeRequest, TreeChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Command_TreeDownloadServer = grpc.ServerStreamingServer[TreeChunk]
+
+func _Command_HashFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HashRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CommandServer).HashFile(m, &grpc.GenericServerStream[HashRequest, FileHash]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Command_HashFileServer = grpc.ServerStreamingServer[FileHash]
+
+func _Command_Sync_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CommandServer).Sync(&grpc.GenericServerStream[SyncMessage, SyncMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Command_SyncServer = grpc.BidiStreamingServer[SyncMessage, SyncMessage]
+
 // Command_ServiceDesc is the grpc.ServiceDesc for Command service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -204,6 +370,10 @@ var Command_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetConnectionParams",
 			Handler:    _Command_GetConnectionParams_Handler,
 		},
+		{
+			MethodName: "FileUploadStatus",
+			Handler:    _Command_FileUploadStatus_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -222,6 +392,620 @@ var Command_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Command_FileDownload_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "TreeUpload",
+			Handler:       _Command_TreeUpload_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "TreeDownload",
+			Handler:       _Command_TreeDownload_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "HashFile",
+			Handler:       _Command_HashFile_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Sync",
+			Handler:       _Command_Sync_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "commands.proto",
+}
+
+const (
+	Jobs_ListJobs_FullMethodName  = "/proto.Jobs/ListJobs"
+	Jobs_AttachJob_FullMethodName = "/proto.Jobs/AttachJob"
+	Jobs_SignalJob_FullMethodName = "/proto.Jobs/SignalJob"
+)
+
+// JobsClient is the client API for Jobs service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type JobsClient interface {
+	ListJobs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*JobList, error)
+	// Replay the buffered output of a job and keep streaming it. The first message names the job with jobId, and later
+	// messages carry stdin, resizes, signals or a detach just like RunExecutable.
+	AttachJob(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RunExecutableInput, RunExecutableResult], error)
+	SignalJob(ctx context.Context, in *JobSignal, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type jobsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJobsClient(cc grpc.ClientConnInterface) JobsClient {
+	return &jobsClient{cc}
+}
+
+func (c *jobsClient) ListJobs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*JobList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobList)
+	err := c.cc.Invoke(ctx, Jobs_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobsClient) AttachJob(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RunExecutableInput, RunExecutableResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Jobs_ServiceDesc.Streams[0], Jobs_AttachJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RunExecutableInput, RunExecutableResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Jobs_AttachJobClient = grpc.BidiStreamingClient[RunExecutableInput, RunExecutableResult]
+
+func (c *jobsClient) SignalJob(ctx context.Context, in *JobSignal, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Jobs_SignalJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JobsServer is the server API for Jobs service.
+// All implementations must embed UnimplementedJobsServer
+// for forward compatibility.
+type JobsServer interface {
+	ListJobs(context.Context, *emptypb.Empty) (*JobList, error)
+	// Replay the buffered output of a job and keep streaming it. The first message names the job with jobId, and later
+	// messages carry stdin, resizes, signals or a detach just like RunExecutable.
+	AttachJob(grpc.BidiStreamingServer[RunExecutableInput, RunExecutableResult]) error
+	SignalJob(context.Context, *JobSignal) (*emptypb.Empty, error)
+	mustEmbedUnimplementedJobsServer()
+}
+
+// UnimplementedJobsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedJobsServer struct{}
+
+func (UnimplementedJobsServer) ListJobs(context.Context, *emptypb.Empty) (*JobList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedJobsServer) AttachJob(grpc.BidiStreamingServer[RunExecutableInput, RunExecutableResult]) error {
+	return status.Errorf(codes.Unimplemented, "method AttachJob not implemented")
+}
+func (UnimplementedJobsServer) SignalJob(context.Context, *JobSignal) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignalJob not implemented")
+}
+func (UnimplementedJobsServer) mustEmbedUnimplementedJobsServer() {}
+func (UnimplementedJobsServer) testEmbeddedByValue()              {}
+
+// UnsafeJobsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JobsServer will
+// result in compilation errors.
+type UnsafeJobsServer interface {
+	mustEmbedUnimplementedJobsServer()
+}
+
+func RegisterJobsServer(s grpc.ServiceRegistrar, srv JobsServer) {
+	// If the following call pancis, it indicates UnimplementedJobsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Jobs_ServiceDesc, srv)
+}
+
+func _Jobs_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobsServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Jobs_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobsServer).ListJobs(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Jobs_AttachJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(JobsServer).AttachJob(&grpc.GenericServerStream[RunExecutableInput, RunExecutableResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Jobs_AttachJobServer = grpc.BidiStreamingServer[RunExecutableInput, RunExecutableResult]
+
+func _Jobs_SignalJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobSignal)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobsServer).SignalJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Jobs_SignalJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobsServer).SignalJob(ctx, req.(*JobSignal))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Jobs_ServiceDesc is the grpc.ServiceDesc for Jobs service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Jobs_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.Jobs",
+	HandlerType: (*JobsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListJobs",
+			Handler:    _Jobs_ListJobs_Handler,
+		},
+		{
+			MethodName: "SignalJob",
+			Handler:    _Jobs_SignalJob_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AttachJob",
+			Handler:       _Jobs_AttachJob_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "commands.proto",
+}
+
+const (
+	Filesystem_Stat_FullMethodName     = "/proto.Filesystem/Stat"
+	Filesystem_ListDir_FullMethodName  = "/proto.Filesystem/ListDir"
+	Filesystem_Mkdir_FullMethodName    = "/proto.Filesystem/Mkdir"
+	Filesystem_Remove_FullMethodName   = "/proto.Filesystem/Remove"
+	Filesystem_Rename_FullMethodName   = "/proto.Filesystem/Rename"
+	Filesystem_Chmod_FullMethodName    = "/proto.Filesystem/Chmod"
+	Filesystem_Chown_FullMethodName    = "/proto.Filesystem/Chown"
+	Filesystem_Symlink_FullMethodName  = "/proto.Filesystem/Symlink"
+	Filesystem_Readlink_FullMethodName = "/proto.Filesystem/Readlink"
+)
+
+// FilesystemClient is the client API for Filesystem service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Filesystem operations on the server that would otherwise need exec and a shell. Reads need the download permission in
+// the policy for the path, and changes need the upload permission.
+type FilesystemClient interface {
+	Stat(ctx context.Context, in *StatRequest, opts ...grpc.CallOption) (*FileInfo, error)
+	ListDir(ctx context.Context, in *ListDirRequest, opts ...grpc.CallOption) (*ListDirResponse, error)
+	Mkdir(ctx context.Context, in *MkdirRequest, opts ...grpc.CallOption) (*FileInfo, error)
+	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Returns the entry at its new path.
+	Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*FileInfo, error)
+	Chmod(ctx context.Context, in *ChmodRequest, opts ...grpc.CallOption) (*FileInfo, error)
+	Chown(ctx context.Context, in *ChownRequest, opts ...grpc.CallOption) (*FileInfo, error)
+	Symlink(ctx context.Context, in *SymlinkRequest, opts ...grpc.CallOption) (*FileInfo, error)
+	// Returns the link itself, with its target in linkTarget.
+	Readlink(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*FileInfo, error)
+}
+
+type filesystemClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFilesystemClient(cc grpc.ClientConnInterface) FilesystemClient {
+	return &filesystemClient{cc}
+}
+
+func (c *filesystemClient) Stat(ctx context.Context, in *StatRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileInfo)
+	err := c.cc.Invoke(ctx, Filesystem_Stat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemClient) ListDir(ctx context.Context, in *ListDirRequest, opts ...grpc.CallOption) (*ListDirResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDirResponse)
+	err := c.cc.Invoke(ctx, Filesystem_ListDir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemClient) Mkdir(ctx context.Context, in *MkdirRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileInfo)
+	err := c.cc.Invoke(ctx, Filesystem_Mkdir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Filesystem_Remove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemClient) Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileInfo)
+	err := c.cc.Invoke(ctx, Filesystem_Rename_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemClient) Chmod(ctx context.Context, in *ChmodRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileInfo)
+	err := c.cc.Invoke(ctx, Filesystem_Chmod_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemClient) Chown(ctx context.Context, in *ChownRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileInfo)
+	err := c.cc.Invoke(ctx, Filesystem_Chown_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemClient) Symlink(ctx context.Context, in *SymlinkRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileInfo)
+	err := c.cc.Invoke(ctx, Filesystem_Symlink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filesystemClient) Readlink(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileInfo)
+	err := c.cc.Invoke(ctx, Filesystem_Readlink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FilesystemServer is the server API for Filesystem service.
+// All implementations must embed UnimplementedFilesystemServer
+// for forward compatibility.
+//
+// Filesystem operations on the server that would otherwise need exec and a shell. Reads need the download permission in
+// the policy for the path, and changes need the upload permission.
+type FilesystemServer interface {
+	Stat(context.Context, *StatRequest) (*FileInfo, error)
+	ListDir(context.Context, *ListDirRequest) (*ListDirResponse, error)
+	Mkdir(context.Context, *MkdirRequest) (*FileInfo, error)
+	Remove(context.Context, *RemoveRequest) (*emptypb.Empty, error)
+	// Returns the entry at its new path.
+	Rename(context.Context, *RenameRequest) (*FileInfo, error)
+	Chmod(context.Context, *ChmodRequest) (*FileInfo, error)
+	Chown(context.Context, *ChownRequest) (*FileInfo, error)
+	Symlink(context.Context, *SymlinkRequest) (*FileInfo, error)
+	// Returns the link itself, with its target in linkTarget.
+	Readlink(context.Context, *PathRequest) (*FileInfo, error)
+	mustEmbedUnimplementedFilesystemServer()
+}
+
+// UnimplementedFilesystemServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFilesystemServer struct{}
+
+func (UnimplementedFilesystemServer) Stat(context.Context, *StatRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stat not implemented")
+}
+func (UnimplementedFilesystemServer) ListDir(context.Context, *ListDirRequest) (*ListDirResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDir not implemented")
+}
+func (UnimplementedFilesystemServer) Mkdir(context.Context, *MkdirRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Mkdir not implemented")
+}
+func (UnimplementedFilesystemServer) Remove(context.Context, *RemoveRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Remove not implemented")
+}
+func (UnimplementedFilesystemServer) Rename(context.Context, *RenameRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rename not implemented")
+}
+func (UnimplementedFilesystemServer) Chmod(context.Context, *ChmodRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Chmod not implemented")
+}
+func (UnimplementedFilesystemServer) Chown(context.Context, *ChownRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Chown not implemented")
+}
+func (UnimplementedFilesystemServer) Symlink(context.Context, *SymlinkRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Symlink not implemented")
+}
+func (UnimplementedFilesystemServer) Readlink(context.Context, *PathRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Readlink not implemented")
+}
+func (UnimplementedFilesystemServer) mustEmbedUnimplementedFilesystemServer() {}
+func (UnimplementedFilesystemServer) testEmbeddedByValue()                    {}
+
+// UnsafeFilesystemServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FilesystemServer will
+// result in compilation errors.
+type UnsafeFilesystemServer interface {
+	mustEmbedUnimplementedFilesystemServer()
+}
+
+func RegisterFilesystemServer(s grpc.ServiceRegistrar, srv FilesystemServer) {
+	// If the following call pancis, it indicates UnimplementedFilesystemServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Filesystem_ServiceDesc, srv)
+}
+
+func _Filesystem_Stat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).Stat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_Stat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).Stat(ctx, req.(*StatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filesystem_ListDir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDirRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).ListDir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_ListDir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).ListDir(ctx, req.(*ListDirRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filesystem_Mkdir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MkdirRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).Mkdir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_Mkdir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).Mkdir(ctx, req.(*MkdirRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filesystem_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_Remove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).Remove(ctx, req.(*RemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filesystem_Rename_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).Rename(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_Rename_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).Rename(ctx, req.(*RenameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filesystem_Chmod_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChmodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).Chmod(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_Chmod_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).Chmod(ctx, req.(*ChmodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filesystem_Chown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).Chown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_Chown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).Chown(ctx, req.(*ChownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filesystem_Symlink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SymlinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).Symlink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_Symlink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).Symlink(ctx, req.(*SymlinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filesystem_Readlink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilesystemServer).Readlink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Filesystem_Readlink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilesystemServer).Readlink(ctx, req.(*PathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Filesystem_ServiceDesc is the grpc.ServiceDesc for Filesystem service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Filesystem_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.Filesystem",
+	HandlerType: (*FilesystemServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Stat",
+			Handler:    _Filesystem_Stat_Handler,
+		},
+		{
+			MethodName: "ListDir",
+			Handler:    _Filesystem_ListDir_Handler,
+		},
+		{
+			MethodName: "Mkdir",
+			Handler:    _Filesystem_Mkdir_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _Filesystem_Remove_Handler,
+		},
+		{
+			MethodName: "Rename",
+			Handler:    _Filesystem_Rename_Handler,
+		},
+		{
+			MethodName: "Chmod",
+			Handler:    _Filesystem_Chmod_Handler,
+		},
+		{
+			MethodName: "Chown",
+			Handler:    _Filesystem_Chown_Handler,
+		},
+		{
+			MethodName: "Symlink",
+			Handler:    _Filesystem_Symlink_Handler,
+		},
+		{
+			MethodName: "Readlink",
+			Handler:    _Filesystem_Readlink_Handler,
+		},
 	},
+	Streams:  []grpc.StreamDesc{},
 	Metadata: "commands.proto",
 }
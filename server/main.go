@@ -1,27 +1,136 @@
 package main
 
 import (
+	"flag"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/apoindevster/bitwarp/audit"
 	"github.com/apoindevster/bitwarp/commandserver"
 	"github.com/apoindevster/bitwarp/proto"
 	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
+	address := flag.String("listen", ":8090", "address to listen on")
+	connect := flag.String("connect", "", "dial out to an operator listening at this address instead of listening, reconnecting whenever the connection drops")
+	certFile := flag.String("cert", "", "path to the server certificate (PEM)")
+	keyFile := flag.String("key", "", "path to the server private key (PEM)")
+	caFile := flag.String("ca", "", "path to the CA bundle used to verify client certificates (PEM)")
+	crlFile := flag.String("crl", "", "path to a certificate revocation list consulted on every handshake")
+	tokenFile := flag.String("tokens", "", "path to a JSON file of static bearer tokens")
+	secretFile := flag.String("token-secret", "", "path to the secret used to verify HMAC signed bearer tokens")
+	policyFile := flag.String("policy", "", "path to a YAML or JSON authorization policy, reloaded on SIGHUP")
+	auditFile := flag.String("audit", "", "path to the append-only audit log of every remote action")
+	idFile := flag.String("id-file", commandserver.DefaultIDFile(), "path to the file holding the identity this server reports to clients, created on first start")
+	insecure := flag.Bool("insecure", false, "serve without TLS. Anyone who can reach the listener can run commands")
+	flag.Parse()
+
 	if commandserver.SetupLogger("", false) != nil {
 		commandserver.Logger.Fatal("failed to setup the proper logger")
 		return
 	}
 
-	lis, err := net.Listen("tcp", ":8090")
+	var opts []grpc.ServerOption
+	if *insecure {
+		commandserver.Logger.Warn("serving without TLS, any client that can reach the listener will be accepted")
+	} else {
+		tlsConfig, err := commandserver.LoadServerTLSConfig(*certFile, *keyFile, *caFile, *crlFile)
+		if err != nil {
+			commandserver.Logger.Fatalf("failed to load TLS configuration (use -insecure to run without TLS): %v", err)
+			return
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	var auth commandserver.ChainAuthenticator
+	if *tokenFile != "" {
+		static, err := commandserver.LoadStaticTokens(*tokenFile)
+		if err != nil {
+			commandserver.Logger.Fatalf("failed to load tokens: %v", err)
+			return
+		}
+		auth = append(auth, static)
+	}
+	if *secretFile != "" {
+		secret, err := commandserver.LoadHMACSecret(*secretFile)
+		if err != nil {
+			commandserver.Logger.Fatalf("failed to load token secret: %v", err)
+			return
+		}
+		auth = append(auth, &commandserver.HMACAuthenticator{Secret: secret})
+	}
+
+	id, err := commandserver.LoadOrCreateID(*idFile)
+	if err != nil {
+		commandserver.Logger.Fatalf("failed to load server identity: %v", err)
+		return
+	}
+	server := &commandserver.Server{ID: id}
+	if *policyFile != "" {
+		policy, err := commandserver.LoadPolicy(*policyFile)
+		if err != nil {
+			commandserver.Logger.Fatalf("failed to load policy: %v", err)
+			return
+		}
+		server.Policy = policy
+
+		// Reload the policy on SIGHUP. Checks happen when an RPC starts, so in-flight streams are not interrupted.
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if err := policy.Reload(); err != nil {
+					commandserver.Logger.Warnf("keeping the previous policy: %v", err)
+					continue
+				}
+				commandserver.Logger.Infof("reloaded policy from %s", *policyFile)
+			}
+		}()
+	} else {
+		commandserver.Logger.Warn("no authorization policy configured, every caller may run anything")
+	}
+
+	if *auditFile != "" {
+		auditLog, err := audit.Open(*auditFile)
+		if err != nil {
+			commandserver.Logger.Fatalf("failed to open audit log: %v", err)
+			return
+		}
+		defer auditLog.Close()
+		server.Audit = auditLog
+	}
+	if len(auth) > 0 {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(server.UnaryAuthInterceptor(auth)),
+			grpc.ChainStreamInterceptor(server.StreamAuthInterceptor(auth)),
+		)
+	} else {
+		commandserver.Logger.Warn("no bearer token authentication configured, callers will not be identified")
+	}
+	opts = append(opts, grpc.StatsHandler(commandserver.ConnectionTagger{}))
+
+	s := grpc.NewServer(opts...)
+	proto.RegisterCommandServer(s, server)
+	proto.RegisterJobsServer(s, server.JobsServer())
+	proto.RegisterFilesystemServer(s, server.FilesystemServer())
+
+	if *connect != "" {
+		if err := commandserver.ServeReverse(s, *connect); err != nil {
+			commandserver.Logger.Fatalf("failed to serve: %v", err)
+		}
+		return
+	}
+
+	lis, err := net.Listen("tcp", *address)
 	if err != nil {
 		commandserver.Logger.Fatalf("failed to listen: %v", err)
 		return
 	}
 	defer lis.Close()
-	s := grpc.NewServer()
-	proto.RegisterCommandServer(s, &commandserver.Server{})
 	commandserver.Logger.Infof("server listening at %v", lis.Addr())
 	if err := s.Serve(lis); err != nil {
 		commandserver.Logger.Fatalf("failed to serve: %v", err)
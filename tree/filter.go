@@ -0,0 +1,46 @@
+package tree
+
+import (
+	"path"
+	"strings"
+)
+
+// Filter picks which entries of a tree are sent. A pattern without a slash matches the name of an entry anywhere in the
+// tree, and one with a slash matches its whole slash separated path relative to the root. When Include is set only the
+// files and symlinks matching it are sent, along with the directories leading to them. Exclude always wins, and
+// excluding a directory skips everything under it.
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+// Validate reports the first malformed pattern in f.
+func (f Filter) Validate() error {
+	for _, pattern := range append(f.Include, f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) excluded(rel string) bool {
+	return rel != "." && matchAny(f.Exclude, rel)
+}
+
+func (f Filter) included(rel string) bool {
+	return len(f.Include) == 0 || matchAny(f.Include, rel)
+}
@@ -0,0 +1,18 @@
+module tree
+
+go 1.23.2
+
+replace github.com/apoindevster/bitwarp => ../
+
+require (
+	github.com/apoindevster/bitwarp v0.0.0-unpublished
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
+	google.golang.org/grpc v1.73.0 // indirect
+)
@@ -0,0 +1,158 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Size of the data messages file contents are split into.
+const chunkSize = 1024 * 1000
+
+// Report whether the directory dir is rel or one of its parents.
+func contains(dir string, rel string) bool {
+	return dir == "." || dir == rel || strings.HasPrefix(rel, dir+"/")
+}
+
+// Visit calls visit with the local path and entry of everything under root that filter lets through, starting with root
+// itself as ".". Entries come in lexical order, directories before what is in them, and symlinks are visited as links
+// rather than followed. Anything that is not a regular file, directory or symlink is skipped. A root that is a single
+// file is visited as one file entry.
+func Visit(root string, filter Filter, visit func(local string, entry *proto.TreeEntry) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	// With include patterns a directory is only visited once something under it is, so these are held back until then.
+	type dir struct {
+		local string
+		entry *proto.TreeEntry
+	}
+	var pending []dir
+	flush := func() error {
+		for _, d := range pending {
+			if err := visit(d.local, d.entry); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if filter.excluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for len(pending) > 0 && !contains(pending[len(pending)-1].entry.GetPath(), rel) {
+			pending = pending[:len(pending)-1]
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := &proto.TreeEntry{Path: rel, Mode: uint32(info.Mode().Perm()), ModTime: timestamppb.New(info.ModTime())}
+
+		switch {
+		case d.IsDir():
+			entry.Type = proto.EntryType_ENTRY_DIR
+			pending = append(pending, dir{p, entry})
+			if len(filter.Include) == 0 {
+				return flush()
+			}
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			entry.Type = proto.EntryType_ENTRY_SYMLINK
+			entry.LinkTarget, err = os.Readlink(p)
+			if err != nil {
+				return err
+			}
+		case d.Type().IsRegular():
+			entry.Type = proto.EntryType_ENTRY_FILE
+			entry.Size = info.Size()
+		default:
+			return nil
+		}
+
+		if !filter.included(rel) {
+			return nil
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		return visit(p, entry)
+	})
+}
+
+// Walk sends the directory tree under root through send, entry by entry in the order Visit goes through them, with the
+// contents of each file following its entry.
+func Walk(root string, filter Filter, send func(*proto.TreeChunk) error) (*proto.TreeSummary, error) {
+	summary := &proto.TreeSummary{}
+	buf := make([]byte, chunkSize)
+	err := Visit(root, filter, func(local string, entry *proto.TreeEntry) error {
+		switch entry.GetType() {
+		case proto.EntryType_ENTRY_DIR:
+			summary.Dirs++
+		case proto.EntryType_ENTRY_SYMLINK:
+			summary.Symlinks++
+		case proto.EntryType_ENTRY_FILE:
+			summary.Files++
+			return sendFile(local, entry, buf, send, summary)
+		}
+		return send(&proto.TreeChunk{Entry: entry})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// Send the entry for a file followed by its contents. The first message carries the entry and the first chunk of data.
+func sendFile(p string, entry *proto.TreeEntry, buf []byte, send func(*proto.TreeChunk) error, summary *proto.TreeSummary) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := io.LimitReader(f, entry.GetSize())
+	var sent int64
+	for first := true; first || sent < entry.GetSize(); first = false {
+		n, err := io.ReadFull(r, buf[:min(chunkSize, entry.GetSize()-sent)])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		if n == 0 && !first {
+			return fmt.Errorf("%s shrank while it was being sent", p)
+		}
+
+		chunk := &proto.TreeChunk{Data: buf[:n]}
+		if first {
+			chunk.Entry = entry
+		}
+		if err := send(chunk); err != nil {
+			return err
+		}
+		sent += int64(n)
+		summary.Bytes += int64(n)
+	}
+	return nil
+}
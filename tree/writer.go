@@ -0,0 +1,192 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// Writer recreates a tree sent by Walk under a root directory.
+type Writer struct {
+	root string
+
+	// The file currently being written and its entry.
+	f       *os.File
+	entry   *proto.TreeEntry
+	written int64
+
+	// Directories get their mode and modification time once everything in them has been written.
+	dirs []*proto.TreeEntry
+
+	summary proto.TreeSummary
+}
+
+// NewWriter creates root if needed and returns a Writer that writes a tree into it.
+func NewWriter(root string) (*Writer, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &Writer{root: root}, nil
+}
+
+// LocalPath turns the slash separated path rel of an entry into a path under root, refusing anything that would land
+// outside of it, including through a symlink already under root.
+func LocalPath(root string, rel string) (string, error) {
+	clean := path.Clean(rel)
+	if rel == "" || path.IsAbs(clean) || filepath.IsAbs(filepath.FromSlash(clean)) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("entry %q is outside of the tree", rel)
+	}
+	if clean == "." {
+		return root, nil
+	}
+
+	parts := strings.Split(clean, "/")
+	dir := root
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("entry %q is behind the symlink %s", rel, dir)
+		}
+	}
+	return filepath.Join(root, filepath.FromSlash(clean)), nil
+}
+
+// Remove whatever is at p unless it is a directory, so a file or link can take its place without writing through an
+// existing symlink.
+func removeExisting(p string) error {
+	info, err := os.Lstat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", p)
+	}
+	return os.Remove(p)
+}
+
+// Write applies the next message of a tree.
+func (w *Writer) Write(chunk *proto.TreeChunk) error {
+	if entry := chunk.GetEntry(); entry != nil {
+		if err := w.finishFile(); err != nil {
+			return err
+		}
+		if err := w.start(entry); err != nil {
+			return err
+		}
+	}
+
+	if len(chunk.GetData()) == 0 {
+		return nil
+	}
+	if w.f == nil {
+		return errors.New("file data arrived without a file entry")
+	}
+	if w.written+int64(len(chunk.GetData())) > w.entry.GetSize() {
+		return fmt.Errorf("%s is larger than the %d bytes announced", w.entry.GetPath(), w.entry.GetSize())
+	}
+	n, err := w.f.Write(chunk.GetData())
+	w.written += int64(n)
+	w.summary.Bytes += int64(n)
+	return err
+}
+
+func (w *Writer) start(entry *proto.TreeEntry) error {
+	local, err := LocalPath(w.root, entry.GetPath())
+	if err != nil {
+		return err
+	}
+
+	switch entry.GetType() {
+	case proto.EntryType_ENTRY_DIR:
+		if info, err := os.Lstat(local); err == nil && info.Mode()&fs.ModeSymlink != 0 && local != w.root {
+			return fmt.Errorf("entry %q is a symlink on this side", entry.GetPath())
+		}
+		if err := os.MkdirAll(local, 0o755); err != nil {
+			return err
+		}
+		w.dirs = append(w.dirs, entry)
+		w.summary.Dirs++
+	case proto.EntryType_ENTRY_SYMLINK:
+		if err := removeExisting(local); err != nil {
+			return err
+		}
+		if err := os.Symlink(entry.GetLinkTarget(), local); err != nil {
+			return err
+		}
+		w.summary.Symlinks++
+	case proto.EntryType_ENTRY_FILE:
+		if err := os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+			return err
+		}
+		if err := removeExisting(local); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(local, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		w.f, w.entry, w.written = f, entry, 0
+		w.summary.Files++
+	default:
+		return fmt.Errorf("unknown type %v for entry %q", entry.GetType(), entry.GetPath())
+	}
+	return nil
+}
+
+// Close the file being written, checking it got all of its contents, and give it its mode and modification time.
+func (w *Writer) finishFile() error {
+	if w.f == nil {
+		return nil
+	}
+	f, entry := w.f, w.entry
+	w.f, w.entry = nil, nil
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if w.written != entry.GetSize() {
+		return fmt.Errorf("%s ended after %d of %d bytes", entry.GetPath(), w.written, entry.GetSize())
+	}
+	return SetAttributes(f.Name(), entry)
+}
+
+// SetAttributes gives local the permission bits and modification time of entry.
+func SetAttributes(local string, entry *proto.TreeEntry) error {
+	if err := os.Chmod(local, fs.FileMode(entry.GetMode()).Perm()); err != nil {
+		return err
+	}
+	mtime := entry.GetModTime().AsTime()
+	return os.Chtimes(local, mtime, mtime)
+}
+
+// Close finishes the tree and returns what was written. Directories get their mode and modification time last, deepest
+// first, so writing into them does not undo it.
+func (w *Writer) Close() (*proto.TreeSummary, error) {
+	if err := w.finishFile(); err != nil {
+		return nil, err
+	}
+	for i := len(w.dirs) - 1; i >= 0; i-- {
+		local, err := LocalPath(w.root, w.dirs[i].GetPath())
+		if err != nil {
+			return nil, err
+		}
+		if err := SetAttributes(local, w.dirs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return &w.summary, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+	"github.com/apoindevster/bitwarp/proto"
+	connlist "github.com/apoindevster/bitwarp/ui/connlist"
+	"github.com/apoindevster/bitwarp/ui/results"
+	connshell "github.com/apoindevster/bitwarp/ui/shell"
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// The most connections a broadcast runs its command on at the same time.
+const broadcastConcurrency = 32
+
+// The following Types are the possible custom tea.Msg types
+// objects of these types get propagated back up to NotificationChan
+type BroadcastOutput struct {
+	id   int
+	name string
+	con  *grpc.ClientConn
+	text string
+}
+type BroadcastHostDone struct {
+	id      int
+	con     *grpc.ClientConn
+	command string
+	result  commandclient.HostResult
+}
+type BroadcastFinished struct {
+	command string
+	results []commandclient.HostResult
+}
+
+// End
+
+// Passes the output of the command on one connection back to the app, to be added to the history of the connection and
+// the results page.
+type broadcastWriter struct {
+	id   int
+	name string
+	con  *grpc.ClientConn
+}
+
+func (w broadcastWriter) Write(p []byte) (int, error) {
+	NotificationChan <- BroadcastOutput{id: w.id, name: w.name, con: w.con, text: string(p)}
+	return len(p), nil
+}
+
+// Broadcast runs options on every connection in cons, each named by the address it was reached through, reporting the
+// output and result of each to NotificationChan as it comes in. id tells the messages of one broadcast apart from
+// those of another. Cancelling ctx kills the command everywhere it is still running.
+func Broadcast(ctx context.Context, id int, command string, cons map[string]*grpc.ClientConn, options *proto.RunExecutableOptions, failFast bool) {
+	targets := make([]commandclient.Target, 0, len(cons))
+	for name, con := range cons {
+		targets = append(targets, commandclient.Target{Name: name, Client: commandclient.NewClient(con)})
+	}
+
+	done := commandclient.FanOut(ctx, targets, options, &commandclient.FanOutOptions{
+		Concurrency: broadcastConcurrency,
+		Timeout:     options.GetTimeout().AsDuration(),
+		FailFast:    failFast,
+		Output: func(name string) (io.Writer, io.Writer) {
+			writer := broadcastWriter{id: id, name: name, con: cons[name]}
+			return writer, writer
+		},
+		Done: func(result commandclient.HostResult) {
+			NotificationChan <- BroadcastHostDone{id: id, con: cons[result.Name], command: command, result: result}
+		},
+	})
+	NotificationChan <- BroadcastFinished{command: command, results: done}
+}
+
+// Start running command on every connection that is up, with the flags exec takes in the shell, and show the results
+// page for it.
+func (m *Model) broadcast(command string) tea.Cmd {
+	options, failFast, err := connshell.ParseBroadcastOptions(command)
+	if err != nil {
+		return m.conns.Status(fmt.Sprintf("broadcast: %v", err))
+	}
+
+	cons := map[string]*grpc.ClientConn{}
+	var hosts []results.Host
+	var cmds []tea.Cmd
+	for i := range clients {
+		if clients[i].con == nil || clients[i].disconnected || clients[i].con.GetState() == connectivity.Shutdown {
+			continue
+		}
+		// Results are reported by address, so only the first connection to reach an address runs the command.
+		if _, ok := cons[clients[i].addr]; ok {
+			continue
+		}
+		hosts = append(hosts, results.Host{Name: clients[i].addr, Item: clients[i].item()})
+		cons[clients[i].addr] = clients[i].con
+		clients[i].history = append(clients[i].history, "broadcast "+command+"\n")
+		clients[i].broadcast = "running"
+		cmds = append(cmds, m.updateItem(i))
+	}
+	if len(cons) == 0 {
+		return m.conns.Status("broadcast: no connections to run on")
+	}
+
+	// Only the broadcast on the results page can be cancelled, earlier ones run to the end.
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelBroadcast = cancel
+	m.broadcasts++
+	m.results.Start(m.broadcasts, command, hosts)
+	m.currMod = Results
+	go func(id int) {
+		defer cancel()
+		Broadcast(ctx, id, command, cons, options, failFast)
+	}(m.broadcasts)
+	return tea.Batch(cmds...)
+}
+
+// Add text to the history of the connection behind con, showing it straight away if the shell is on it. It returns the
+// index of the connection, or -1 when it is gone.
+func (m *Model) addHistory(con *grpc.ClientConn, text string) int {
+	for i := range clients {
+		if clients[i].con != con {
+			continue
+		}
+		clients[i].history = append(clients[i].history, text)
+		if m.currMod == Shell && m.curr == i {
+			m.shell.Refresh()
+		}
+		return i
+	}
+	return -1
+}
+
+// Open the shell of the connection reached through addr, as picked on the results page.
+func (m *Model) openShell(addr string) {
+	for i := range clients {
+		if clients[i].addr != addr || clients[i].con == nil || clients[i].con.GetState() == connectivity.Shutdown {
+			continue
+		}
+		m.currMod = Shell
+		m.curr = i
+		m.shell.SetCon(clients[i].comcon, &clients[i].history)
+		m.shell.Refresh()
+		return
+	}
+}
+
+// Show the latest state of clients[i] in the connection list.
+func (m *Model) updateItem(i int) tea.Cmd {
+	var cmd tea.Cmd
+	m.conns, cmd = m.conns.Update(connlist.UpdateConnReq{Id: i, Item: clients[i].item()})
+	return cmd
+}
+
+// How the broadcast went on a connection, as added to its history.
+func describeBroadcast(command string, result commandclient.HostResult) string {
+	var failed *commandclient.CommandFailedError
+	if result.Err == nil || errors.As(result.Err, &failed) {
+		return fmt.Sprintf("broadcast %s exited with code %d\n", command, result.Code)
+	}
+	return fmt.Sprintf("broadcast %s %s: %v\n", command, result.Status(), result.Err)
+}
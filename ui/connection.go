@@ -1,23 +1,78 @@
 package main
 
 import (
+	"crypto/tls"
 	"errors"
 	"strconv"
 
 	"github.com/apoindevster/bitwarp/commandclient"
+	"github.com/apoindevster/bitwarp/proto"
 	newconn "github.com/apoindevster/bitwarp/ui/newconn"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 )
 
+// Load the mutual TLS configuration of a connection, or nil if it has none.
+func loadTLSConfig(params newconn.NewConnParams) (*tls.Config, error) {
+	if params.CertFile == "" && params.KeyFile == "" && params.CAFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := commandclient.LoadClientTLSConfig(params.CertFile, params.KeyFile, params.CAFile)
+	if err != nil {
+		return nil, errors.New("failed to load TLS configuration")
+	}
+	return tlsConfig, nil
+}
+
+// The following Types are the possible custom tea.Msg types
+// objects of these types get propagated back up to NotificationChan
+type ConnectionIdentified struct {
+	con   *grpc.ClientConn
+	conid uuid.UUID
+	info  *proto.ConnectionParams
+}
+
+// End
+
 func CreateNewConnection(params newconn.NewConnParams) (*grpc.ClientConn, error) {
 	if params.Ip == "" || params.Port < 0 || params.Port > 65535 {
 		return nil, errors.New("invalid input params for new connection")
 	}
 
-	conn, err := commandclient.ConnectToServer(params.Ip + ":" + strconv.Itoa(params.Port))
+	tlsConfig, err := loadTLSConfig(params)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := commandclient.ConnectToServer(params.Ip+":"+strconv.Itoa(params.Port), tlsConfig, params.Token)
 	if err != nil {
 		return nil, errors.New("failed to connect to server")
 	}
 
 	return conn, nil
 }
+
+// Ask a server who it is.
+func identify(client *proto.CommandClient) (uuid.UUID, *proto.ConnectionParams, error) {
+	info, err := commandclient.GetConnectionParams(client)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	conid, err := uuid.FromBytes(info.GetUuid())
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return conid, info, nil
+}
+
+// IdentifyConnection asks the server behind con who it is and reports the answer to NotificationChan. Servers that cannot
+// be reached or do not answer are left unidentified.
+func IdentifyConnection(con *grpc.ClientConn, client *proto.CommandClient) {
+	conid, info, err := identify(client)
+	if err != nil {
+		return
+	}
+	NotificationChan <- ConnectionIdentified{con: con, conid: conid, info: info}
+}
@@ -2,10 +2,12 @@ package connlist
 
 import (
 	"math"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -14,22 +16,24 @@ var docStyle = lipgloss.NewStyle().Margin(1, 2)
 var NotificationChan chan tea.Msg
 
 type keyMap struct {
-	AddConn  key.Binding
-	DelConn  key.Binding
-	Interact key.Binding
+	AddConn   key.Binding
+	DelConn   key.Binding
+	Interact  key.Binding
+	Broadcast key.Binding
+	Results   key.Binding
 }
 
 // ShortHelp returns keybindings to be shown in the mini help view. It's part
 // of the key.Map interface.
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.AddConn, k.DelConn, k.Interact}
+	return []key.Binding{k.AddConn, k.DelConn, k.Interact, k.Broadcast, k.Results}
 }
 
 // FullHelp returns keybindings for the expanded help view. It's part of the
 // key.Map interface.
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.AddConn, k.DelConn, k.Interact}, // first column
+		{k.AddConn, k.DelConn, k.Interact, k.Broadcast, k.Results}, // first column
 	}
 }
 
@@ -46,6 +50,14 @@ var keys = keyMap{
 		key.WithKeys("i", "enter"),
 		key.WithHelp("i/enter", "Interact with current Connection"),
 	),
+	Broadcast: key.NewBinding(
+		key.WithKeys("b", "B"),
+		key.WithHelp("b/B", "Run a command on every Connection"),
+	),
+	Results: key.NewBinding(
+		key.WithKeys("r", "R"),
+		key.WithHelp("r/R", "Show the latest broadcast"),
+	),
 }
 
 type Item struct {
@@ -60,9 +72,17 @@ type NewConnReq struct {
 type DelConnReq struct {
 	Id int
 }
+type UpdateConnReq struct {
+	Id   int
+	Item Item
+}
 type InteractConnReq struct {
 	Id int
 }
+type BroadcastReq struct {
+	Command string
+}
+type ResultsReq struct{}
 
 // End
 
@@ -75,6 +95,12 @@ type Model struct {
 	Help  help.Model
 	List  list.Model
 	Items []Item
+
+	// The command to broadcast, shown under the list once the broadcast key is pressed.
+	prompt    textinput.Model
+	prompting bool
+	width     int
+	height    int
 }
 
 func New(notif chan tea.Msg) Model {
@@ -82,11 +108,17 @@ func New(notif chan tea.Msg) Model {
 
 	h := help.New()
 	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	l.StatusMessageLifetime = 10 * time.Second
+
+	p := textinput.New()
+	p.Prompt = "broadcast> "
+	p.Placeholder = "[-cwd dir] [-timeout 30s] command, enter to run on every connection"
 
 	return Model{
-		keys: keys,
-		Help: h,
-		List: l,
+		keys:   keys,
+		Help:   h,
+		List:   l,
+		prompt: p,
 	}
 }
 
@@ -102,6 +134,40 @@ func Interact(idx int) {
 	NotificationChan <- InteractConnReq{Id: idx}
 }
 
+func Broadcast(command string) {
+	NotificationChan <- BroadcastReq{Command: command}
+}
+
+func ShowResults() {
+	NotificationChan <- ResultsReq{}
+}
+
+// Back closes the broadcast prompt, returning false when it was not open.
+func (m *Model) Back() bool {
+	if !m.prompting {
+		return false
+	}
+	m.prompting = false
+	m.prompt.Blur()
+	m.prompt.Reset()
+	m.resize()
+	return true
+}
+
+// Status shows text under the title of the list for a while, such as how a broadcast went.
+func (m *Model) Status(text string) tea.Cmd {
+	return m.List.NewStatusMessage(text)
+}
+
+// Leave room under the list for the help and the broadcast prompt when it is open.
+func (m *Model) resize() {
+	height := m.height - lipgloss.Height(m.Help.View(m.keys))
+	if m.prompting {
+		height -= lipgloss.Height(m.prompt.View())
+	}
+	m.List.SetSize(m.width, height)
+}
+
 func (m Model) Init() tea.Cmd {
 	return nil
 }
@@ -109,6 +175,9 @@ func (m Model) Init() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.prompting {
+			return m.updatePrompt(msg)
+		}
 		switch {
 		case key.Matches(msg, m.keys.AddConn):
 			go AddItemReq()
@@ -116,13 +185,23 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			go DeleteItem(m.List.GlobalIndex())
 		case key.Matches(msg, m.keys.Interact):
 			go Interact(m.List.GlobalIndex())
+		case key.Matches(msg, m.keys.Broadcast):
+			m.prompting = true
+			m.resize()
+			return m, m.prompt.Focus()
+		case key.Matches(msg, m.keys.Results):
+			go ShowResults()
 		}
 	case tea.WindowSizeMsg:
-		m.List.SetSize(msg.Width, msg.Height-lipgloss.Height(m.Help.View(m.keys)))
+		m.width, m.height = msg.Width, msg.Height
+		m.prompt.Width = msg.Width - lipgloss.Width(m.prompt.Prompt) - 1
+		m.resize()
 	case NewConnReq:
 		m.List.InsertItem(math.MaxInt32, msg.Item)
 	case DelConnReq:
 		m.List.RemoveItem(msg.Id)
+	case UpdateConnReq:
+		m.List.SetItem(msg.Id, msg.Item)
 	}
 
 	help, hCmd := m.Help.Update(msg)
@@ -137,6 +216,24 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	)
 }
 
+// While the prompt is open every key goes to it, and enter sends the command off to run.
+func (m Model) updatePrompt(msg tea.KeyMsg) (Model, tea.Cmd) {
+	if msg.Type == tea.KeyEnter {
+		if command := m.prompt.Value(); command != "" {
+			go Broadcast(command)
+		}
+		m.Back()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
 func (m Model) View() string {
+	if m.prompting {
+		return m.List.View() + "\n" + m.prompt.View() + "\n" + m.Help.View(m.keys)
+	}
 	return m.List.View() + "\n" + m.Help.View(m.keys)
 }
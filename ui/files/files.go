@@ -0,0 +1,720 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/ui/units"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"google.golang.org/grpc/status"
+)
+
+var NotificationChan chan tea.Msg
+
+// Largest file shown in a preview. Remote files have to be downloaded to be previewed, so bigger ones are not.
+const previewLimit = 256 << 10
+
+// How many transfers are listed below the panes, most recent last.
+const shownTransfers = 4
+
+type keyMap struct {
+	Switch   key.Binding
+	Open     key.Binding
+	Parent   key.Binding
+	Preview  key.Binding
+	Upload   key.Binding
+	Download key.Binding
+	Refresh  key.Binding
+	Back     key.Binding
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Switch, k.Open, k.Parent, k.Preview, k.Upload, k.Download, k.Refresh, k.Back}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+var keys = keyMap{
+	Switch: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "Switch side"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "Open"),
+	),
+	Parent: key.NewBinding(
+		key.WithKeys("backspace"),
+		key.WithHelp("backspace", "Parent"),
+	),
+	Preview: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "Preview"),
+	),
+	Upload: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "Upload"),
+	),
+	Download: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "Download"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "Refresh"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "Back"),
+	),
+}
+
+var (
+	focusedTitle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	blurredTitle = lipgloss.NewStyle().Faint(true)
+)
+
+// The following Types are the possible custom tea.Msg types
+// objects of these types get propagated back up to NotificationChan
+type TransferProgress struct {
+	id    int
+	done  int64
+	total int64
+}
+type TransferFinished struct {
+	id     int
+	result string
+	err    error
+}
+
+// End
+
+// The following Types are returned by the tea.Cmds of this page
+type listing struct {
+	side    side
+	dir     string
+	entries []entry
+	err     error
+}
+type remoteResolved struct {
+	path string
+	dir  bool
+	size int64
+	err  error
+}
+type previewLoaded struct {
+	name string
+	text string
+	err  error
+}
+
+// End
+
+type side int
+
+const (
+	local side = iota
+	remote
+)
+
+type entry struct {
+	name string
+	dir  bool
+	link bool
+	size int64
+}
+
+// One side of the browser.
+type pane struct {
+	table   table.Model
+	dir     string
+	entries []entry
+	err     string
+}
+
+func (p *pane) selected() *entry {
+	i := p.table.Cursor()
+	if i < 0 || i >= len(p.entries) {
+		return nil
+	}
+	return &p.entries[i]
+}
+
+func (p *pane) setEntries(entries []entry) {
+	p.entries = entries
+	rows := make([]table.Row, 0, len(entries))
+	for _, e := range entries {
+		name, size := e.name, units.Bytes(e.size)
+		if e.dir {
+			name, size = name+"/", ""
+		}
+		if e.link {
+			name += "@"
+		}
+		rows = append(rows, table.Row{name, size})
+	}
+	p.table.SetRows(rows)
+	p.table.SetCursor(0)
+}
+
+type transfer struct {
+	id     int
+	conn   *proto.CommandClient
+	upload bool
+	dir    bool
+	src    string
+	dest   string
+	done   int64
+	total  int64
+	state  string
+	result string
+}
+
+type Model struct {
+	Help     help.Model
+	panes    [2]pane
+	focus    side
+	viewPort viewport.Model
+	bar      progress.Model
+	Conn     *proto.CommandClient
+	FsConn   *proto.FilesystemClient
+	status   string
+	width    int
+	height   int
+
+	// Set while a preview is open.
+	previewing string
+
+	// Transfers run one at a time in the order they were queued, and stay listed once finished.
+	transfers []*transfer
+	running   bool
+}
+
+func New(notif chan tea.Msg) Model {
+	NotificationChan = notif
+
+	m := Model{
+		Help:     help.New(),
+		viewPort: viewport.New(0, 0),
+		bar:      progress.New(progress.WithDefaultGradient()),
+	}
+	for i := range m.panes {
+		m.panes[i].table = table.New()
+	}
+	m.panes[local].table.Focus()
+	m.panes[local].dir, _ = os.Getwd()
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// SetCon browses the server behind conn and fsConn next to the local filesystem. The remote side starts in the home
+// directory of the server, or at its root when it has none.
+func (m *Model) SetCon(conn *proto.CommandClient, fsConn *proto.FilesystemClient) tea.Cmd {
+	m.closePreview()
+	if m.FsConn != fsConn {
+		m.panes[remote] = pane{table: m.panes[remote].table}
+		m.panes[remote].setEntries(nil)
+	}
+	m.Conn = conn
+	m.FsConn = fsConn
+	m.status = ""
+	return tea.Batch(listLocal(m.panes[local].dir), listRemote(m.FsConn, m.panes[remote].dir))
+}
+
+// Back closes the preview if it is open. It reports false when the panes were showing, so the caller should leave the page.
+func (m *Model) Back() bool {
+	if m.previewing == "" {
+		return false
+	}
+	m.closePreview()
+	return true
+}
+
+func (m *Model) closePreview() {
+	m.previewing = ""
+	m.viewPort.SetContent("")
+}
+
+// Directories first, then by name.
+func sortEntries(entries []entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].dir != entries[j].dir {
+			return entries[i].dir
+		}
+		return entries[i].name < entries[j].name
+	})
+}
+
+func listLocal(dir string) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return listing{side: local, err: err}
+		}
+		dirEntries, err := os.ReadDir(dir)
+		if err != nil {
+			return listing{side: local, dir: dir, err: err}
+		}
+
+		var entries []entry
+		for _, d := range dirEntries {
+			e := entry{name: d.Name(), link: d.Type()&os.ModeSymlink != 0}
+			// Follow symlinks so linked directories can be opened like any other.
+			info, err := os.Stat(filepath.Join(dir, d.Name()))
+			if err != nil {
+				info, err = d.Info()
+			}
+			if err != nil {
+				continue
+			}
+			e.dir, e.size = info.IsDir(), info.Size()
+			entries = append(entries, e)
+		}
+		sortEntries(entries)
+		if filepath.Dir(dir) != dir {
+			entries = append([]entry{{name: "..", dir: true}}, entries...)
+		}
+		return listing{side: local, dir: dir, entries: entries}
+	}
+}
+
+func listRemote(client *proto.FilesystemClient, dir string) tea.Cmd {
+	return func() tea.Msg {
+		if dir == "" {
+			dir = "/"
+			if _, err := commandclient.Stat("$HOME", true, client); err == nil {
+				dir = "$HOME"
+			}
+		}
+		// Let the server expand variables in the path before listing it.
+		info, err := commandclient.Stat(dir, true, client)
+		if err != nil {
+			return listing{side: remote, dir: dir, err: err}
+		}
+		dir = strings.ReplaceAll(info.GetName(), "\\", "/")
+		infos, err := commandclient.ListDir(dir, client)
+		if err != nil {
+			return listing{side: remote, dir: dir, err: err}
+		}
+
+		var entries []entry
+		for _, info := range infos {
+			entries = append(entries, entry{
+				name: info.GetName(),
+				dir:  info.GetType() == proto.EntryType_ENTRY_DIR,
+				link: info.GetType() == proto.EntryType_ENTRY_SYMLINK,
+				size: info.GetSize(),
+			})
+		}
+		sortEntries(entries)
+		if path.Dir(dir) != dir {
+			entries = append([]entry{{name: "..", dir: true}}, entries...)
+		}
+		return listing{side: remote, dir: dir, entries: entries}
+	}
+}
+
+// Find out whether a remote symlink leads to a directory, which the listing does not say.
+func resolveRemote(client *proto.FilesystemClient, p string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := commandclient.Stat(p, true, client)
+		if err != nil {
+			return remoteResolved{path: p, err: err}
+		}
+		return remoteResolved{path: p, dir: info.GetType() == proto.EntryType_ENTRY_DIR, size: info.GetSize()}
+	}
+}
+
+// Turn the start of a file into something that can be shown, refusing binary files.
+func previewText(name string, data []byte, truncated bool) previewLoaded {
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return previewLoaded{name: name, text: "Binary file, not shown."}
+	}
+	text := string(data)
+	if truncated {
+		text += fmt.Sprintf("\n[only the first %s are shown]\n", units.Bytes(previewLimit))
+	}
+	return previewLoaded{name: name, text: text}
+}
+
+func previewLocal(p string) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.Open(p)
+		if err != nil {
+			return previewLoaded{name: p, err: err}
+		}
+		defer f.Close()
+
+		data, err := io.ReadAll(io.LimitReader(f, previewLimit+1))
+		if err != nil {
+			return previewLoaded{name: p, err: err}
+		}
+		truncated := len(data) > previewLimit
+		if truncated {
+			// Cut at the limit without splitting a character in two.
+			data = data[:previewLimit]
+			for len(data) > 0 && !utf8.Valid(data[max(len(data)-utf8.UTFMax, 0):]) {
+				data = data[:len(data)-1]
+			}
+		}
+		return previewText(p, data, truncated)
+	}
+}
+
+func previewRemote(conn *proto.CommandClient, size int64, p string) tea.Cmd {
+	return func() tea.Msg {
+		if size > previewLimit {
+			return previewLoaded{name: p, text: fmt.Sprintf("%s is too large to preview, download it instead.", units.Bytes(size))}
+		}
+
+		tmp, err := os.CreateTemp("", "bitwarp-preview-*")
+		if err != nil {
+			return previewLoaded{name: p, err: err}
+		}
+		tmp.Close()
+		defer func() {
+			os.Remove(tmp.Name())
+			// FileDownload keeps what arrived of a failed download to resume from later, which a preview never does.
+			os.Remove(tmp.Name() + ".bitwarp-part")
+		}()
+
+		if err := commandclient.FileDownload(p, tmp.Name(), conn); err != nil {
+			return previewLoaded{name: p, err: err}
+		}
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return previewLoaded{name: p, err: err}
+		}
+		return previewText(p, data, false)
+	}
+}
+
+// Run a transfer on the connection it was queued for, reporting its progress and then its result to NotificationChan.
+func runTransfer(t transfer) {
+	last := time.Now()
+	options := &commandclient.TransferOptions{Progress: func(done int64, total int64) {
+		if now := time.Now(); now.Sub(last) >= units.ProgressInterval || done == total {
+			last = now
+			NotificationChan <- TransferProgress{id: t.id, done: done, total: total}
+		}
+	}}
+
+	var err error
+	var summary *proto.TreeSummary
+	var result *commandclient.TransferResult
+	switch {
+	case t.upload && t.dir:
+		summary, err = commandclient.TreeUpload(t.src, t.dest, nil, nil, t.conn)
+	case t.upload:
+		result, err = commandclient.FileUploadWithOptions(t.src, t.dest, options, t.conn)
+	case t.dir:
+		summary, err = commandclient.TreeDownload(t.src, t.dest, nil, nil, t.conn)
+	default:
+		result, err = commandclient.FileDownloadWithOptions(t.src, t.dest, options, t.conn)
+	}
+
+	finished := TransferFinished{id: t.id, err: err}
+	if summary != nil {
+		finished.result = fmt.Sprintf("%d files, %s", summary.GetFiles(), units.Bytes(summary.GetBytes()))
+	} else if result != nil {
+		finished.result = units.Bytes(result.Size)
+	}
+	NotificationChan <- finished
+}
+
+// Queue a copy of the entry selected on from to the directory shown on the other side.
+func (m *Model) queue(from side) {
+	p := &m.panes[from]
+	e := p.selected()
+	if e == nil || e.name == ".." {
+		return
+	}
+	if m.Conn == nil || m.panes[remote].err != "" {
+		m.status = "No remote directory to copy to or from"
+		return
+	}
+
+	t := &transfer{id: len(m.transfers), conn: m.Conn, upload: from == local, dir: e.dir, total: e.size, state: "queued"}
+	if t.upload {
+		t.src, t.dest = filepath.Join(p.dir, e.name), path.Join(m.panes[remote].dir, e.name)
+	} else {
+		t.src, t.dest = path.Join(p.dir, e.name), filepath.Join(m.panes[local].dir, e.name)
+	}
+	m.transfers = append(m.transfers, t)
+	m.status = fmt.Sprintf("Queued %s", t.src)
+	m.startNext()
+}
+
+func (m *Model) startNext() {
+	if m.running {
+		return
+	}
+	for _, t := range m.transfers {
+		if t.state == "queued" {
+			t.state = "running"
+			m.running = true
+			go runTransfer(*t)
+			return
+		}
+	}
+}
+
+// Open the selected entry, going into directories and previewing files.
+func (m *Model) open(preview bool) tea.Cmd {
+	p := &m.panes[m.focus]
+	e := p.selected()
+	if e == nil {
+		return nil
+	}
+
+	if m.focus == local {
+		target := filepath.Join(p.dir, e.name)
+		if e.dir && !preview {
+			return listLocal(target)
+		}
+		if !e.dir {
+			m.previewing = target
+			m.viewPort.SetContent("Loading...")
+			return previewLocal(target)
+		}
+		return nil
+	}
+
+	target := path.Join(p.dir, e.name)
+	if e.name == ".." {
+		target = path.Dir(p.dir)
+	}
+	switch {
+	case e.link && !preview:
+		return resolveRemote(m.FsConn, target)
+	case e.dir && !preview:
+		return listRemote(m.FsConn, target)
+	case !e.dir:
+		m.previewing = target
+		m.viewPort.SetContent("Loading...")
+		return previewRemote(m.Conn, e.size, target)
+	}
+	return nil
+}
+
+func (m *Model) parent() tea.Cmd {
+	p := &m.panes[m.focus]
+	if m.focus == local {
+		return listLocal(filepath.Dir(p.dir))
+	}
+	return listRemote(m.FsConn, path.Dir(p.dir))
+}
+
+// Lines taken up by the transfer list, its title included.
+func (m *Model) transferLines() int {
+	if len(m.transfers) == 0 {
+		return 0
+	}
+	return min(len(m.transfers), shownTransfers) + 1
+}
+
+func (m *Model) resize() {
+	half := m.width / 2
+	height := max(m.height-m.transferLines()-lipgloss.Height(m.Help.View(keys))-3, 3)
+	for i := range m.panes {
+		width := half
+		if i == int(remote) {
+			width = m.width - half
+		}
+		// Every column is padded by one cell on each side.
+		m.panes[i].table.SetColumns([]table.Column{
+			{Title: "Name", Width: max(width-10-4, 8)},
+			{Title: "Size", Width: 10},
+		})
+		m.panes[i].table.SetWidth(width)
+		m.panes[i].table.SetHeight(height)
+	}
+
+	m.viewPort.Width = m.width
+	m.viewPort.Height = max(m.height-lipgloss.Height(m.Help.View(keys))-2, 1)
+	m.bar.Width = max(m.width/4, 10)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.resize()
+		return m, nil
+	case listing:
+		p := &m.panes[msg.side]
+		if msg.err != nil {
+			m.status = "Failed to list " + msg.dir + ": " + status.Convert(msg.err).Message()
+			if p.entries == nil {
+				p.err = m.status
+			}
+			return m, nil
+		}
+		p.dir, p.err = msg.dir, ""
+		p.setEntries(msg.entries)
+		return m, nil
+	case remoteResolved:
+		if msg.err != nil {
+			m.status = "Failed to open " + msg.path + ": " + status.Convert(msg.err).Message()
+			return m, nil
+		}
+		if msg.dir {
+			return m, listRemote(m.FsConn, msg.path)
+		}
+		m.previewing = msg.path
+		m.viewPort.SetContent("Loading...")
+		return m, previewRemote(m.Conn, msg.size, msg.path)
+	case previewLoaded:
+		if msg.name != m.previewing {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.viewPort.SetContent("Failed to preview: " + status.Convert(msg.err).Message())
+		} else {
+			m.viewPort.SetContent(msg.text)
+		}
+		m.viewPort.GotoTop()
+		return m, nil
+	case TransferProgress:
+		// The goroutine running the transfer passes its progress back to the app so we can display it here.
+		if msg.id < len(m.transfers) {
+			m.transfers[msg.id].done, m.transfers[msg.id].total = msg.done, msg.total
+		}
+		return m, nil
+	case TransferFinished:
+		if msg.id < len(m.transfers) {
+			t := m.transfers[msg.id]
+			if msg.err != nil {
+				t.state, t.result = "failed", status.Convert(msg.err).Message()
+			} else {
+				t.state, t.result, t.done = "done", msg.result, t.total
+			}
+		}
+		m.running = false
+		m.startNext()
+		m.resize()
+		// Show what the transfer added.
+		return m, tea.Batch(listLocal(m.panes[local].dir), listRemote(m.FsConn, m.panes[remote].dir))
+	case tea.KeyMsg:
+		if m.previewing != "" {
+			m.viewPort, cmd = m.viewPort.Update(msg)
+			return m, cmd
+		}
+		switch {
+		case key.Matches(msg, keys.Switch):
+			m.panes[m.focus].table.Blur()
+			m.focus = 1 - m.focus
+			m.panes[m.focus].table.Focus()
+			return m, nil
+		case key.Matches(msg, keys.Open):
+			return m, m.open(false)
+		case key.Matches(msg, keys.Preview):
+			return m, m.open(true)
+		case key.Matches(msg, keys.Parent):
+			return m, m.parent()
+		case key.Matches(msg, keys.Upload):
+			m.queue(local)
+			m.resize()
+			return m, nil
+		case key.Matches(msg, keys.Download):
+			m.queue(remote)
+			m.resize()
+			return m, nil
+		case key.Matches(msg, keys.Refresh):
+			return m, tea.Batch(listLocal(m.panes[local].dir), listRemote(m.FsConn, m.panes[remote].dir))
+		}
+	}
+
+	if m.previewing != "" {
+		m.viewPort, cmd = m.viewPort.Update(msg)
+		return m, cmd
+	}
+	m.panes[m.focus].table, cmd = m.panes[m.focus].table.Update(msg)
+	return m, cmd
+}
+
+func (m Model) transfersView() string {
+	if len(m.transfers) == 0 {
+		return ""
+	}
+	lines := []string{"Transfers"}
+	for _, t := range m.transfers[max(len(m.transfers)-shownTransfers, 0):] {
+		arrow := "↓"
+		if t.upload {
+			arrow = "↑"
+		}
+		line := fmt.Sprintf("%s %s → %s ", arrow, t.src, t.dest)
+		switch {
+		case t.state == "running" && t.dir:
+			line += "copying directory..."
+		case t.state == "running":
+			fraction := 0.0
+			if t.total > 0 {
+				fraction = float64(t.done) / float64(t.total)
+			}
+			line += m.bar.ViewAs(fraction) + fmt.Sprintf(" %s / %s", units.Bytes(t.done), units.Bytes(t.total))
+		case t.result != "":
+			line += t.state + ": " + t.result
+		default:
+			line += t.state
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) paneView(s side) string {
+	p := m.panes[s]
+	title := "Local: " + p.dir
+	if s == remote {
+		title = "Remote: " + p.dir
+	}
+	style := blurredTitle
+	if s == m.focus {
+		style = focusedTitle
+	}
+	body := p.table.View()
+	if p.err != "" {
+		body = p.err
+	}
+	width := m.width / 2
+	if s == remote {
+		width = m.width - width
+	}
+	return lipgloss.NewStyle().Width(width).MaxWidth(width).Render(style.Render(title) + "\n" + body)
+}
+
+func (m Model) View() string {
+	if m.previewing != "" {
+		return m.previewing + "\n" + m.viewPort.View() + "\n" + m.Help.View(keys)
+	}
+
+	view := lipgloss.JoinHorizontal(lipgloss.Top, m.paneView(local), m.paneView(remote)) + "\n"
+	if transfers := m.transfersView(); transfers != "" {
+		view += transfers + "\n"
+	}
+	return view + m.status + "\n" + m.Help.View(keys)
+}
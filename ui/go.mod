@@ -6,7 +6,10 @@ require (
 	github.com/apoindevster/bitwarp v0.0.0-unpublished
 	github.com/apoindevster/bitwarp/commandclient v0.0.0-unpublished
 	github.com/apoindevster/bitwarp/ui/connlist v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/ui/files v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/ui/jobs v0.0.0-unpublished
 	github.com/apoindevster/bitwarp/ui/newconn v0.0.0-unpublished
+	github.com/apoindevster/bitwarp/ui/results v0.0.0-unpublished
 	github.com/apoindevster/bitwarp/ui/shell v0.0.0-unpublished
 	github.com/charmbracelet/bubbletea v1.3.6
 	github.com/google/uuid v1.6.0
@@ -14,15 +17,22 @@ require (
 )
 
 require (
+	github.com/apoindevster/bitwarp/codec v0.0.0-unpublished // indirect
+	github.com/apoindevster/bitwarp/delta v0.0.0-unpublished // indirect
+	github.com/apoindevster/bitwarp/tree v0.0.0-unpublished // indirect
+	github.com/apoindevster/bitwarp/ui/units v0.0.0-unpublished // indirect
 	github.com/atotto/clipboard v0.1.4 // indirect
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
 	github.com/charmbracelet/bubbles v0.21.0 // indirect
 	github.com/charmbracelet/colorprofile v0.2.3-0.20250311203215-f60798e515dc // indirect
+	github.com/charmbracelet/harmonica v0.2.0 // indirect
 	github.com/charmbracelet/lipgloss v1.1.0 // indirect
 	github.com/charmbracelet/x/ansi v0.9.3 // indirect
 	github.com/charmbracelet/x/cellbuf v0.0.13-0.20250311204145-2c3ea96c31dd // indirect
 	github.com/charmbracelet/x/term v0.2.1 // indirect
 	github.com/erikgeiser/coninput v0.0.0-20211004153227-1c3628e74d0f // indirect
+	github.com/hashicorp/yamux v0.1.2 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-localereader v0.0.1 // indirect
@@ -49,6 +59,20 @@ replace github.com/apoindevster/bitwarp/ui/shell => ./shell
 
 replace github.com/apoindevster/bitwarp/ui/connlist => ./connlist
 
+replace github.com/apoindevster/bitwarp/ui/jobs => ./jobs
+
 replace github.com/apoindevster/bitwarp/ui/newconn => ./newconn
 
 replace github.com/apoindevster/bitwarp/ui/db => ./db
+
+replace github.com/apoindevster/bitwarp/tree => ../tree
+
+replace github.com/apoindevster/bitwarp/codec => ../codec
+
+replace github.com/apoindevster/bitwarp/delta => ../delta
+
+replace github.com/apoindevster/bitwarp/ui/files => ./files
+
+replace github.com/apoindevster/bitwarp/ui/results => ./results
+
+replace github.com/apoindevster/bitwarp/ui/units => ./units
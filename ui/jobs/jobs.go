@@ -0,0 +1,466 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/ui/units"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"google.golang.org/grpc/status"
+)
+
+var NotificationChan chan tea.Msg
+
+// How often the job list is refreshed while the page is open.
+const refreshInterval = time.Second
+
+type listKeyMap struct {
+	Open      key.Binding
+	Interrupt key.Binding
+	Terminate key.Binding
+	Kill      key.Binding
+	Refresh   key.Binding
+	Back      key.Binding
+}
+
+func (k listKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Open, k.Interrupt, k.Terminate, k.Kill, k.Refresh, k.Back}
+}
+
+func (k listKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+type outputKeyMap struct {
+	Send      key.Binding
+	Interrupt key.Binding
+	Terminate key.Binding
+	Kill      key.Binding
+	Back      key.Binding
+}
+
+func (k outputKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Send, k.Interrupt, k.Terminate, k.Kill, k.Back}
+}
+
+func (k outputKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+var listKeys = listKeyMap{
+	Open: key.NewBinding(
+		key.WithKeys("enter", "o"),
+		key.WithHelp("o/enter", "View output"),
+	),
+	Interrupt: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "SIGINT"),
+	),
+	Terminate: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "SIGTERM"),
+	),
+	Kill: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "Kill"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "Refresh"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "Back to shell"),
+	),
+}
+
+var outputKeys = outputKeyMap{
+	Send: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "Send line to stdin"),
+	),
+	Interrupt: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "SIGINT"),
+	),
+	Terminate: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "SIGTERM"),
+	),
+	Kill: key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "Kill"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "Back to jobs"),
+	),
+}
+
+// The following Types are the possible custom tea.Msg types
+// objects of these types get propagated back up to NotificationChan
+type JobOutput struct {
+	session int
+	data    string
+}
+type JobAttachFinished struct {
+	session int
+	code    int32
+}
+
+// End
+
+// The following Types are returned by the tea.Cmds of this page
+type jobsTick struct {
+	generation int
+}
+type jobsUpdate struct {
+	generation int
+	jobs       []*proto.JobInfo
+	err        error
+}
+type signalSent struct {
+	id     string
+	signal proto.Signal
+	err    error
+}
+
+// End
+
+type Model struct {
+	Help      help.Model
+	table     table.Model
+	viewPort  viewport.Model
+	textInput textinput.Model
+	Conn      *proto.JobsClient
+	jobs      []*proto.JobInfo
+	status    string
+	width     int
+	height    int
+
+	// Bumped whenever the page is opened so refresh loops from an earlier visit die out.
+	generation int
+
+	// The job whose output is open, if any. Bumped session numbers tell output of an earlier attach apart.
+	viewing  *proto.JobInfo
+	session  int
+	output   string
+	dataChan *commandclient.ExecutableDataChan
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func New(notif chan tea.Msg) Model {
+	NotificationChan = notif
+
+	t := table.New(table.WithFocused(true))
+	ti := textinput.New()
+	ti.Placeholder = "stdin"
+
+	return Model{
+		Help:      help.New(),
+		table:     t,
+		viewPort:  viewport.New(0, 0),
+		textInput: ti,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// SetCon shows the jobs of conn and starts refreshing them.
+func (m *Model) SetCon(conn *proto.JobsClient) tea.Cmd {
+	m.closeOutput()
+	m.Conn = conn
+	m.jobs = nil
+	m.status = ""
+	m.table.SetRows(nil)
+	m.generation++
+	return fetchJobs(m.Conn, m.generation)
+}
+
+// Back closes the output view if it is open. It reports false when the list was showing, so the caller should leave the page.
+func (m *Model) Back() bool {
+	if m.viewing == nil {
+		m.generation++
+		return false
+	}
+	m.closeOutput()
+	return true
+}
+
+func (m *Model) closeOutput() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.viewing = nil
+	m.dataChan = nil
+	m.ctx = nil
+	m.cancel = nil
+	m.output = ""
+	m.textInput.Reset()
+	m.textInput.Blur()
+}
+
+func fetchJobs(conn *proto.JobsClient, generation int) tea.Cmd {
+	return func() tea.Msg {
+		jobs, err := commandclient.ListJobs(conn)
+		return jobsUpdate{generation: generation, jobs: jobs, err: err}
+	}
+}
+
+func sendSignal(conn *proto.JobsClient, id string, signal proto.Signal) tea.Cmd {
+	return func() tea.Msg {
+		// Signal the whole process group, the way a terminal does, so children of the command stop with it.
+		err := commandclient.SignalJob(id, signal, true, conn)
+		return signalSent{id: id, signal: signal, err: err}
+	}
+}
+
+// Stream the output of a job to NotificationChan until it finishes or ctx is cancelled.
+func attachOutput(ctx context.Context, session int, id string, dataChan *commandclient.ExecutableDataChan, client *proto.JobsClient) {
+	completed := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case out := <-dataChan.Stdout:
+				NotificationChan <- JobOutput{session: session, data: string(out)}
+			case err := <-dataChan.Stderr:
+				NotificationChan <- JobOutput{session: session, data: string(err)}
+			case <-completed:
+				return
+			}
+		}
+	}()
+
+	code := commandclient.AttachJobContext(ctx, id, dataChan, client)
+	completed <- struct{}{}
+	if ctx.Err() == nil {
+		NotificationChan <- JobAttachFinished{session: session, code: code}
+	}
+}
+
+func (m *Model) openOutput(job *proto.JobInfo) {
+	m.closeOutput()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dataChan := commandclient.MakeExecutableDataChan()
+	m.session++
+	m.viewing = job
+	m.dataChan = &dataChan
+	m.ctx = ctx
+	m.cancel = cancel
+	m.viewPort.SetContent("")
+	m.textInput.Focus()
+	go attachOutput(ctx, m.session, job.GetId(), m.dataChan, m.Conn)
+}
+
+func (m *Model) selected() *proto.JobInfo {
+	row := m.table.SelectedRow()
+	if row == nil {
+		return nil
+	}
+	for _, job := range m.jobs {
+		if job.GetId() == row[0] {
+			return job
+		}
+	}
+	return nil
+}
+
+func jobDuration(job *proto.JobInfo) time.Duration {
+	end := time.Now()
+	if job.GetState() == proto.JobState_JOB_EXITED {
+		end = job.GetEndTime().AsTime()
+	}
+	return end.Sub(job.GetStartTime().AsTime()).Round(time.Second)
+}
+
+func jobState(job *proto.JobInfo) string {
+	if job.GetState() == proto.JobState_JOB_EXITED {
+		return "exited"
+	}
+	if job.GetDetached() {
+		return "detached"
+	}
+	return "running"
+}
+
+func (m *Model) setRows() {
+	rows := make([]table.Row, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		code := "-"
+		if job.GetState() == proto.JobState_JOB_EXITED {
+			code = fmt.Sprint(job.GetReturnCode())
+		}
+		rows = append(rows, table.Row{
+			job.GetId(),
+			strings.TrimSpace(job.GetCommand() + " " + strings.Join(job.GetArgs(), " ")),
+			jobState(job),
+			code,
+			jobDuration(job).String(),
+			units.Bytes(job.GetOutputBytes()),
+		})
+	}
+	m.table.SetRows(rows)
+}
+
+func (m *Model) resize() {
+	fixed := []table.Column{
+		{Title: "ID", Width: 4},
+		{Title: "State", Width: 9},
+		{Title: "Exit", Width: 5},
+		{Title: "Duration", Width: 9},
+		{Title: "Output", Width: 10},
+	}
+	command := m.width
+	for _, c := range fixed {
+		// Every column is padded by one cell on each side.
+		command -= c.Width + 2
+	}
+	m.table.SetColumns([]table.Column{fixed[0], {Title: "Command", Width: max(command-2, 10)}, fixed[1], fixed[2], fixed[3], fixed[4]})
+	m.table.SetWidth(m.width)
+	m.table.SetHeight(max(m.height-lipgloss.Height(m.Help.View(listKeys))-2, 3))
+
+	m.viewPort.Width = m.width
+	m.viewPort.Height = max(m.height-lipgloss.Height(m.Help.View(outputKeys))-lipgloss.Height(m.textInput.View())-3, 1)
+	m.textInput.Width = m.width
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.resize()
+		return m, nil
+	case jobsTick:
+		if msg.generation != m.generation || m.Conn == nil {
+			return m, nil
+		}
+		return m, fetchJobs(m.Conn, m.generation)
+	case jobsUpdate:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.status = "Failed to list jobs: " + status.Convert(msg.err).Message()
+		} else {
+			m.jobs = msg.jobs
+			m.setRows()
+		}
+		generation := m.generation
+		return m, tea.Tick(refreshInterval, func(time.Time) tea.Msg { return jobsTick{generation: generation} })
+	case signalSent:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Failed to send %v to job %s: %s", msg.signal, msg.id, status.Convert(msg.err).Message())
+		} else {
+			m.status = fmt.Sprintf("Sent %v to job %s", msg.signal, msg.id)
+		}
+		return m, nil
+	case JobOutput:
+		// The goroutine that attaches to the job passes its output back to the app so we can display it here.
+		if msg.session == m.session && m.viewing != nil {
+			m.output += msg.data
+			m.viewPort.SetContent(m.output)
+			m.viewPort.GotoBottom()
+		}
+		return m, nil
+	case JobAttachFinished:
+		if msg.session == m.session && m.viewing != nil {
+			m.output += fmt.Sprintf("\n[job %s exited with code %d]\n", m.viewing.GetId(), msg.code)
+			m.viewPort.SetContent(m.output)
+			m.viewPort.GotoBottom()
+			m.cancel()
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.viewing != nil {
+			return m.updateOutput(msg)
+		}
+		switch {
+		case key.Matches(msg, listKeys.Open):
+			if job := m.selected(); job != nil {
+				m.openOutput(job)
+			}
+			return m, nil
+		case key.Matches(msg, listKeys.Interrupt):
+			if job := m.selected(); job != nil {
+				return m, sendSignal(m.Conn, job.GetId(), proto.Signal_SIGNAL_INT)
+			}
+		case key.Matches(msg, listKeys.Terminate):
+			if job := m.selected(); job != nil {
+				return m, sendSignal(m.Conn, job.GetId(), proto.Signal_SIGNAL_TERM)
+			}
+		case key.Matches(msg, listKeys.Kill):
+			if job := m.selected(); job != nil {
+				return m, sendSignal(m.Conn, job.GetId(), proto.Signal_SIGNAL_KILL)
+			}
+		case key.Matches(msg, listKeys.Refresh):
+			return m, fetchJobs(m.Conn, m.generation)
+		}
+	}
+
+	if m.viewing != nil {
+		m.viewPort, cmd = m.viewPort.Update(msg)
+		return m, cmd
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateOutput(msg tea.KeyMsg) (Model, tea.Cmd) {
+	id := m.viewing.GetId()
+	switch {
+	case key.Matches(msg, outputKeys.Send):
+		line := []byte(m.textInput.Value() + "\n")
+		m.textInput.Reset()
+		// Nothing reads stdin once the job has finished, so give up when the output view stops streaming.
+		dataChan := m.dataChan
+		done := m.ctx.Done()
+		go func() {
+			select {
+			case dataChan.Stdin <- line:
+			case <-done:
+			}
+		}()
+		return m, nil
+	case key.Matches(msg, outputKeys.Interrupt):
+		return m, sendSignal(m.Conn, id, proto.Signal_SIGNAL_INT)
+	case key.Matches(msg, outputKeys.Terminate):
+		return m, sendSignal(m.Conn, id, proto.Signal_SIGNAL_TERM)
+	case key.Matches(msg, outputKeys.Kill):
+		return m, sendSignal(m.Conn, id, proto.Signal_SIGNAL_KILL)
+	}
+
+	var cmds []tea.Cmd
+	var cmd tea.Cmd
+	m.viewPort, cmd = m.viewPort.Update(msg)
+	cmds = append(cmds, cmd)
+	m.textInput, cmd = m.textInput.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	if m.viewing != nil {
+		header := fmt.Sprintf("Job %s: %s %s", m.viewing.GetId(), m.viewing.GetCommand(), strings.Join(m.viewing.GetArgs(), " "))
+		if m.status != "" {
+			header += "  " + m.status
+		}
+		return header + "\n" + m.viewPort.View() + "\n" + m.textInput.View() + "\n" + m.Help.View(outputKeys)
+	}
+	return m.table.View() + "\n" + m.status + "\n" + m.Help.View(listKeys)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+	"github.com/apoindevster/bitwarp/proto"
+	newconn "github.com/apoindevster/bitwarp/ui/newconn"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+)
+
+// The following Types are the possible custom tea.Msg types
+// objects of these types get propagated back up to NotificationChan
+type AgentCheckin struct {
+	conid    uuid.UUID
+	con      *grpc.ClientConn
+	info     *proto.ConnectionParams
+	remote   string
+	listener string
+	certFile string
+}
+type AgentGone struct {
+	con *grpc.ClientConn
+}
+
+// End
+
+// Listen for agents on the address in params. Agents that dial in are reported to NotificationChan until the listener is
+// closed.
+func StartListener(params newconn.NewConnParams) (net.Listener, error) {
+	if params.Ip == "" || params.Port < 0 || params.Port > 65535 {
+		return nil, errors.New("invalid input params for new listener")
+	}
+
+	tlsConfig, err := loadTLSConfig(params)
+	if err != nil {
+		return nil, err
+	}
+
+	lis, err := net.Listen("tcp", net.JoinHostPort(params.Ip, strconv.Itoa(params.Port)))
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			con, closed, err := commandclient.AcceptAgent(lis, tlsConfig, params.Token)
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				continue
+			}
+			go checkinAgent(con, closed, params.Desc, params.CertFile)
+		}
+	}()
+	return lis, nil
+}
+
+// Ask a new agent who it is and report it, then report when it goes away again.
+func checkinAgent(con *grpc.ClientConn, closed <-chan struct{}, listener string, certFile string) {
+	client := proto.NewCommandClient(con)
+	conid, info, err := identify(&client)
+	if err != nil {
+		con.Close()
+		return
+	}
+
+	NotificationChan <- AgentCheckin{conid: conid, con: con, info: info, remote: strings.TrimPrefix(con.Target(), "passthrough:///"), listener: listener, certFile: certFile}
+	<-closed
+	NotificationChan <- AgentGone{con: con}
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"strconv"
 
 	"github.com/apoindevster/bitwarp/proto"
 	connlist "github.com/apoindevster/bitwarp/ui/connlist"
+	"github.com/apoindevster/bitwarp/ui/files"
+	"github.com/apoindevster/bitwarp/ui/jobs"
 	newconn "github.com/apoindevster/bitwarp/ui/newconn"
+	"github.com/apoindevster/bitwarp/ui/results"
 	connshell "github.com/apoindevster/bitwarp/ui/shell"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/google/uuid"
@@ -17,10 +22,50 @@ import (
 // Global but keeps track of all the connection in the client list.
 // TODO: Find a better way to track con and comcon simultaneously
 type Connection struct {
-	conid   uuid.UUID
-	con     *grpc.ClientConn
-	comcon  *proto.CommandClient
-	history []string
+	conid    uuid.UUID
+	con      *grpc.ClientConn
+	comcon   *proto.CommandClient
+	jobcon   *proto.JobsClient
+	fscon    *proto.FilesystemClient
+	history  []string
+	certFile string
+	keyFile  string
+	caFile   string
+	// Set for a listener waiting for agents, which has no connection of its own.
+	listener net.Listener
+	// What the server reported about itself through GetConnectionParams, once it has answered.
+	info         *proto.ConnectionParams
+	title        string
+	addr         string
+	disconnected bool
+	// How the latest broadcast went on the connection, empty before the first.
+	broadcast string
+}
+
+// The entry shown for a connection in the connection list.
+func (c Connection) item() connlist.Item {
+	title := c.title
+	if title == "" {
+		title = c.info.GetHostname()
+	}
+	if title == "" {
+		title = c.conid.String()
+	}
+
+	desc := c.addr
+	if c.certFile != "" {
+		desc += " (mTLS)"
+	}
+	if c.info != nil {
+		desc += fmt.Sprintf(" - %s %s/%s %s", c.info.GetHostname(), c.info.GetOs(), c.info.GetArch(), c.info.GetVersion())
+	}
+	if c.disconnected {
+		desc += " (disconnected)"
+	}
+	if c.broadcast != "" {
+		desc += " - broadcast " + c.broadcast
+	}
+	return connlist.Item{T: title, Desc: desc}
 }
 
 var clients []Connection
@@ -34,6 +79,9 @@ const (
 	Conns State = iota
 	NewCon
 	Shell
+	Jobs
+	Files
+	Results
 )
 
 // The model that contains the current state as well as all of the sub-models for the pages intended to be shown.
@@ -42,6 +90,15 @@ type Model struct {
 	conns   connlist.Model
 	newCon  newconn.Model
 	shell   connshell.Model
+	jobs    jobs.Model
+	files   files.Model
+	results results.Model
+	// The connection the shell, jobs and files pages are showing.
+	curr int
+	// How many broadcasts have been started, which numbers the latest one.
+	broadcasts int
+	// Kills the latest broadcast everywhere it is still running.
+	cancelBroadcast context.CancelFunc
 }
 
 // New function to return the ELM architecture model.
@@ -51,12 +108,18 @@ func New(notif chan tea.Msg) Model {
 	connl := connlist.New(NotificationChan)
 	nc := newconn.New(NotificationChan)
 	sh := connshell.New(NotificationChan)
+	jb := jobs.New(NotificationChan)
+	fl := files.New(NotificationChan)
+	rs := results.New(NotificationChan)
 
 	return Model{
 		currMod: Conns,
 		conns:   connl,
 		newCon:  nc,
 		shell:   sh,
+		jobs:    jb,
+		files:   fl,
+		results: rs,
 	}
 
 }
@@ -64,6 +127,20 @@ func New(notif chan tea.Msg) Model {
 // Go to the previous page in the BitWarp application
 func (m *Model) decrementPage() {
 	switch m.currMod {
+	case Jobs:
+		if !m.jobs.Back() {
+			m.currMod = Shell
+		}
+	case Files:
+		if !m.files.Back() {
+			m.currMod = Shell
+		}
+	case Results:
+		if !m.results.Back() {
+			m.currMod = Conns
+		}
+	case Conns:
+		m.conns.Back()
 	default:
 		m.currMod = Conns
 	}
@@ -71,15 +148,64 @@ func (m *Model) decrementPage() {
 
 // Call the ELM Architecture update function for all the sub-models in this model
 func (m *Model) updateAllModels(msg tea.Msg) tea.Cmd {
-	var concmd, newcmd, shcmd tea.Cmd
+	var concmd, newcmd, shcmd, jobcmd, filecmd, rescmd tea.Cmd
 	m.conns, concmd = m.conns.Update(msg)
 	m.newCon, newcmd = m.newCon.Update(msg)
 	m.shell, shcmd = m.shell.Update(msg)
+	m.jobs, jobcmd = m.jobs.Update(msg)
+	m.files, filecmd = m.files.Update(msg)
+	m.results, rescmd = m.results.Update(msg)
 
-	return tea.Batch(concmd, newcmd, shcmd)
+	return tea.Batch(concmd, newcmd, shcmd, jobcmd, filecmd, rescmd)
 
 }
 
+// Record who the server behind clients[i] is. If the same host is already listed, reached through another address or an
+// earlier agent connection, the older entry keeps its history and takes over the new connection, and the new entry goes.
+// The clients are replaced in place so pages already showing the host pick up the new connection.
+func (m *Model) identified(i int, conid uuid.UUID, info *proto.ConnectionParams) tea.Cmd {
+	for j := range clients {
+		if j == i || clients[j].conid != conid {
+			continue
+		}
+
+		if clients[j].con != nil {
+			clients[j].con.Close()
+		}
+		clients[j].con = clients[i].con
+		*clients[j].comcon = *clients[i].comcon
+		*clients[j].jobcon = *clients[i].jobcon
+		*clients[j].fscon = *clients[i].fscon
+		clients[j].certFile = clients[i].certFile
+		clients[j].keyFile = clients[i].keyFile
+		clients[j].caFile = clients[i].caFile
+		clients[j].addr = clients[i].addr
+		clients[j].info = info
+		clients[j].disconnected = false
+
+		clients = append(clients[:i], clients[i+1:]...)
+		if j > i {
+			j--
+		}
+		if m.curr == i {
+			m.curr = j
+		} else if m.curr > i {
+			m.curr--
+		}
+
+		var delcmd, updcmd tea.Cmd
+		m.conns, delcmd = m.conns.Update(connlist.DelConnReq{Id: i})
+		m.conns, updcmd = m.conns.Update(connlist.UpdateConnReq{Id: j, Item: clients[j].item()})
+		return tea.Batch(delcmd, updcmd)
+	}
+
+	clients[i].conid = conid
+	clients[i].info = info
+	var cmd tea.Cmd
+	m.conns, cmd = m.conns.Update(connlist.UpdateConnReq{Id: i, Item: clients[i].item()})
+	return cmd
+}
+
 // This function serves as a way to pass custom tea.Msg types between the models. This also makes it much more event driven.
 func waitForResponse(sub chan tea.Msg) tea.Cmd {
 	return func() tea.Msg {
@@ -101,7 +227,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.currMod = NewCon
 		return m, waitForResponse(NotificationChan)
 	case connlist.DelConnReq:
-		clients[msg.Id].con.Close()
+		if clients[msg.Id].con != nil {
+			clients[msg.Id].con.Close()
+		}
+		if clients[msg.Id].listener != nil {
+			clients[msg.Id].listener.Close()
+		}
 		clients = append(clients[:msg.Id], clients[msg.Id+1:]...)
 		newconns, concmd := m.conns.Update(msg)
 		m.conns = newconns
@@ -114,16 +245,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		if clients[msg.Id].con.GetState() == connectivity.Shutdown {
+		if clients[msg.Id].con == nil || clients[msg.Id].con.GetState() == connectivity.Shutdown {
 			// Connection has been or is shutting down.
 			return m, waitForResponse(NotificationChan)
 		}
 
 		m.currMod = Shell
+		m.curr = msg.Id
 		m.shell.SetCon(clients[msg.Id].comcon, &clients[msg.Id].history)
 		return m, waitForResponse(NotificationChan)
 	case newconn.NewConnParams:
 		m.currMod = Conns
+		if msg.Listen {
+			lis, err := StartListener(msg)
+			if err != nil {
+				return m, waitForResponse(NotificationChan)
+			}
+
+			listener := Connection{listener: lis, certFile: msg.CertFile, keyFile: msg.KeyFile, caFile: msg.CAFile, title: msg.Desc, addr: "listening on " + lis.Addr().String()}
+			clients = append(clients, listener)
+			newconns, concmd := m.conns.Update(connlist.NewConnReq{Item: listener.item()})
+			m.conns = newconns
+			return m, tea.Batch(
+				concmd,
+				waitForResponse(NotificationChan),
+			)
+		}
+
 		con, err := CreateNewConnection(msg)
 		if err != nil {
 			return m, waitForResponse(NotificationChan)
@@ -131,22 +279,134 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// We can go ahead and create the command client
 		client := proto.NewCommandClient(con)
+		jobClient := proto.NewJobsClient(con)
+		fsClient := proto.NewFilesystemClient(con)
 
-		newCon := Connection{con: con, comcon: &client, history: []string{}}
+		newCon := Connection{con: con, comcon: &client, jobcon: &jobClient, fscon: &fsClient, history: []string{}, certFile: msg.CertFile, keyFile: msg.KeyFile, caFile: msg.CAFile, title: msg.Desc, addr: msg.Ip + ":" + strconv.Itoa(msg.Port)}
 		clients = append(clients, newCon)
-		newconns, concmd := m.conns.Update(connlist.NewConnReq{Item: connlist.Item{T: msg.Desc, Desc: msg.Ip + ":" + strconv.Itoa(msg.Port)}})
+		go IdentifyConnection(con, &client)
+		newconns, concmd := m.conns.Update(connlist.NewConnReq{Item: newCon.item()})
+		m.conns = newconns
+		return m, tea.Batch(
+			concmd,
+			waitForResponse(NotificationChan),
+		)
+	case ConnectionIdentified:
+		for i := range clients {
+			if clients[i].con == msg.con {
+				return m, tea.Batch(
+					m.identified(i, msg.conid, msg.info),
+					waitForResponse(NotificationChan),
+				)
+			}
+		}
+		return m, waitForResponse(NotificationChan)
+	case AgentCheckin:
+		client := proto.NewCommandClient(msg.con)
+		jobClient := proto.NewJobsClient(msg.con)
+		fsClient := proto.NewFilesystemClient(msg.con)
+		agent := Connection{con: msg.con, comcon: &client, jobcon: &jobClient, fscon: &fsClient, history: []string{}, certFile: msg.certFile, addr: msg.remote + " via " + msg.listener}
+		clients = append(clients, agent)
+		newconns, concmd := m.conns.Update(connlist.NewConnReq{Item: agent.item()})
 		m.conns = newconns
 		return m, tea.Batch(
 			concmd,
+			m.identified(len(clients)-1, msg.conid, msg.info),
 			waitForResponse(NotificationChan),
 		)
-	case connshell.RunExecutableUpdate:
+	case AgentGone:
+		for i := range clients {
+			if clients[i].con == msg.con {
+				clients[i].disconnected = true
+				newconns, concmd := m.conns.Update(connlist.UpdateConnReq{Id: i, Item: clients[i].item()})
+				m.conns = newconns
+				return m, tea.Batch(
+					concmd,
+					waitForResponse(NotificationChan),
+				)
+			}
+		}
+		return m, waitForResponse(NotificationChan)
+	case connlist.BroadcastReq:
+		return m, tea.Batch(
+			m.broadcast(msg.Command),
+			waitForResponse(NotificationChan),
+		)
+	case connlist.ResultsReq:
+		if m.results.Started() {
+			m.currMod = Results
+		}
+		return m, waitForResponse(NotificationChan)
+	case results.ShellReq:
+		m.openShell(msg.Name)
+		return m, waitForResponse(NotificationChan)
+	case results.CancelReq:
+		if m.cancelBroadcast != nil {
+			m.cancelBroadcast()
+		}
+		return m, waitForResponse(NotificationChan)
+	case BroadcastOutput:
+		m.addHistory(msg.con, msg.text)
+		m.results.Output(msg.id, msg.name, msg.text)
+		return m, waitForResponse(NotificationChan)
+	case BroadcastHostDone:
+		m.results.Done(msg.id, msg.result)
+		i := m.addHistory(msg.con, describeBroadcast(msg.command, msg.result))
+		if i < 0 {
+			return m, waitForResponse(NotificationChan)
+		}
+		clients[i].broadcast = msg.result.Status()
+		if msg.result.Code > 0 {
+			clients[i].broadcast += fmt.Sprintf(" with code %d", msg.result.Code)
+		}
+		return m, tea.Batch(
+			m.updateItem(i),
+			waitForResponse(NotificationChan),
+		)
+	case BroadcastFinished:
+		return m, tea.Batch(
+			m.conns.Status(fmt.Sprintf("broadcast %s: %s", msg.command, results.Summarize(msg.results))),
+			waitForResponse(NotificationChan),
+		)
+	case connshell.RunExecutableUpdate, connshell.TransferStarted, connshell.TransferProgress, connshell.TransferFinished:
 		newshell, shcmd := m.shell.Update(msg)
 		m.shell = newshell
 		return m, tea.Batch(
 			shcmd,
 			waitForResponse(NotificationChan),
 		)
+	case connshell.JobsReq:
+		if m.currMod != Shell || m.curr > len(clients)-1 {
+			return m, waitForResponse(NotificationChan)
+		}
+		m.currMod = Jobs
+		return m, tea.Batch(
+			m.jobs.SetCon(clients[m.curr].jobcon),
+			waitForResponse(NotificationChan),
+		)
+	case connshell.FilesReq:
+		if m.currMod != Shell || m.curr > len(clients)-1 {
+			return m, waitForResponse(NotificationChan)
+		}
+		m.currMod = Files
+		return m, tea.Batch(
+			m.files.SetCon(clients[m.curr].comcon, clients[m.curr].fscon),
+			waitForResponse(NotificationChan),
+		)
+	case files.TransferProgress, files.TransferFinished:
+		newfiles, filecmd := m.files.Update(msg)
+		m.files = newfiles
+		return m, tea.Batch(
+			filecmd,
+			waitForResponse(NotificationChan),
+		)
+	case jobs.JobOutput, jobs.JobAttachFinished:
+		newjobs, jobcmd := m.jobs.Update(msg)
+		m.jobs = newjobs
+		return m, tea.Batch(
+			jobcmd,
+			waitForResponse(NotificationChan),
+		)
 	// The following are built-in tea messages from BubbleTea.
 	case tea.KeyMsg:
 		switch msg.Type {
@@ -170,6 +430,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.newCon, cmd = m.newCon.Update(msg)
 	case Shell:
 		m.shell, cmd = m.shell.Update(msg)
+	case Jobs:
+		m.jobs, cmd = m.jobs.Update(msg)
+	case Files:
+		m.files, cmd = m.files.Update(msg)
+	case Results:
+		m.results, cmd = m.results.Update(msg)
 	}
 
 	return m, cmd
@@ -184,6 +450,12 @@ func (m Model) View() string {
 		return m.newCon.View()
 	case Shell:
 		return m.shell.View()
+	case Jobs:
+		return m.jobs.View()
+	case Files:
+		return m.files.View()
+	case Results:
+		return m.results.View()
 	default:
 		return m.conns.View()
 	}
@@ -13,9 +13,15 @@ var NotificationChan chan tea.Msg
 // The following Types are the possible custom tea.Msg types
 // objects of these types get propagated back up to NotificationChan
 type NewConnParams struct {
-	Desc string
-	Ip   string
-	Port int
+	Desc     string
+	Ip       string
+	Port     int
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	Token    string
+	// Listen waits for agents to dial in on Ip and Port instead of connecting to a server there.
+	Listen bool
 }
 
 // End
@@ -24,16 +30,26 @@ type Focus int
 
 const (
 	Desc Focus = iota
+	Type
 	Ip
 	Port
+	Cert
+	Key
+	CA
+	Token
 	Max
 )
 
 type Model struct {
 	focus Focus
 	desc  textinput.Model
+	typ   textinput.Model
 	ip    textinput.Model
 	port  textinput.Model
+	cert  textinput.Model
+	key   textinput.Model
+	ca    textinput.Model
+	token textinput.Model
 }
 
 func ValidateParams(ip string, port int) error {
@@ -48,7 +64,40 @@ func ValidateParams(ip string, port int) error {
 	return nil
 }
 
-func SendNewConnection(desc string, ip string, port string) {
+// Mutual TLS needs all three paths. Leaving all of them empty makes an insecure connection.
+func ValidateTLSParams(certFile string, keyFile string, caFile string) error {
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil
+	}
+
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return errors.New("certificate, key and CA paths must all be provided for mutual TLS")
+	}
+
+	return nil
+}
+
+// The connection type is either connect, the default, or listen.
+func ValidateType(typ string) (bool, error) {
+	switch typ {
+	case "", "connect":
+		return false, nil
+	case "listen":
+		return true, nil
+	default:
+		return false, errors.New("connection type must be connect or listen")
+	}
+}
+
+func SendNewConnection(desc string, typ string, ip string, port string, certFile string, keyFile string, caFile string, token string) {
+	listen, err := ValidateType(typ)
+	if err != nil {
+		// Unknown connection type
+		// TODO: Error case emit an error instead of empty NewConnParam
+		NotificationChan <- NewConnParams{}
+		return
+	}
+
 	p, err := strconv.Atoi(port)
 	if err != nil {
 		// Failed to parse the port
@@ -65,23 +114,48 @@ func SendNewConnection(desc string, ip string, port string) {
 		return
 	}
 
-	NotificationChan <- NewConnParams{Desc: desc, Ip: ip, Port: p}
+	err = ValidateTLSParams(certFile, keyFile, caFile)
+	if err != nil {
+		// Partial TLS configuration
+		// TODO: Error case emit an error instead of empty NewConnParam
+		NotificationChan <- NewConnParams{}
+		return
+	}
+
+	NotificationChan <- NewConnParams{Desc: desc, Listen: listen, Ip: ip, Port: p, CertFile: certFile, KeyFile: keyFile, CAFile: caFile, Token: token}
 }
 
 func New(notif chan tea.Msg) Model {
 	NotificationChan = notif
 
 	d := textinput.New()
+	y := textinput.New()
 	i := textinput.New()
 	p := textinput.New()
+	c := textinput.New()
+	k := textinput.New()
+	a := textinput.New()
+	t := textinput.New()
+
+	y.Placeholder = "connect, or listen to wait for agents to dial in"
+	c.Placeholder = "optional, path to client certificate"
+	k.Placeholder = "optional, path to client key"
+	a.Placeholder = "optional, path to CA bundle"
+	t.Placeholder = "optional, bearer token"
+	t.EchoMode = textinput.EchoPassword
 
 	d.Focus()
 
 	return Model{
 		focus: Desc,
 		desc:  d,
+		typ:   y,
 		ip:    i,
 		port:  p,
+		cert:  c,
+		key:   k,
+		ca:    a,
+		token: t,
 	}
 }
 
@@ -89,6 +163,10 @@ func (m *Model) IncFocus() tea.Cmd {
 	switch m.focus {
 	case Desc:
 		m.desc.Blur()
+		m.focus = Type
+		return m.typ.Focus()
+	case Type:
+		m.typ.Blur()
 		m.focus = Ip
 		return m.ip.Focus()
 	case Ip:
@@ -97,6 +175,22 @@ func (m *Model) IncFocus() tea.Cmd {
 		return m.port.Focus()
 	case Port:
 		m.port.Blur()
+		m.focus = Cert
+		return m.cert.Focus()
+	case Cert:
+		m.cert.Blur()
+		m.focus = Key
+		return m.key.Focus()
+	case Key:
+		m.key.Blur()
+		m.focus = CA
+		return m.ca.Focus()
+	case CA:
+		m.ca.Blur()
+		m.focus = Token
+		return m.token.Focus()
+	case Token:
+		m.token.Blur()
 		m.focus = Desc
 		return m.desc.Focus()
 	}
@@ -113,38 +207,64 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			go SendNewConnection(m.desc.Value(), m.ip.Value(), m.port.Value())
+			go SendNewConnection(m.desc.Value(), m.typ.Value(), m.ip.Value(), m.port.Value(), m.cert.Value(), m.key.Value(), m.ca.Value(), m.token.Value())
 			// TODO: Implement more elegant way of doing this
 			m.desc.Reset()
+			m.typ.Reset()
 			m.ip.Reset()
 			m.port.Reset()
+			m.cert.Reset()
+			m.key.Reset()
+			m.ca.Reset()
+			m.token.Reset()
 			m.focus = Desc
 			m.desc.Focus()
+			m.typ.Blur()
 			m.ip.Blur()
 			m.port.Blur()
+			m.cert.Blur()
+			m.key.Blur()
+			m.ca.Blur()
+			m.token.Blur()
 			return m, nil
 		case "tab":
 			return m, m.IncFocus()
 		}
 	case tea.WindowSizeMsg:
 		m.desc.Width = msg.Width
+		m.typ.Width = msg.Width
 		m.ip.Width = msg.Width
 		m.port.Width = msg.Width
+		m.cert.Width = msg.Width
+		m.key.Width = msg.Width
+		m.ca.Width = msg.Width
+		m.token.Width = msg.Width
 	}
 
-	var dcmd, icmd, pcmd tea.Cmd
+	var dcmd, ycmd, icmd, pcmd, ccmd, kcmd, acmd, tcmd tea.Cmd
 	m.desc, dcmd = m.desc.Update(msg)
+	m.typ, ycmd = m.typ.Update(msg)
 	m.ip, icmd = m.ip.Update(msg)
 	m.port, pcmd = m.port.Update(msg)
+	m.cert, ccmd = m.cert.Update(msg)
+	m.key, kcmd = m.key.Update(msg)
+	m.ca, acmd = m.ca.Update(msg)
+	m.token, tcmd = m.token.Update(msg)
 
 	return m, tea.Batch(
 		dcmd,
+		ycmd,
 		icmd,
 		pcmd,
+		ccmd,
+		kcmd,
+		acmd,
+		tcmd,
 	)
 }
 
 // TODO: Could update this so that the fields are much nicer looking instead of just using an input box
 func (m Model) View() string {
-	return "Description " + m.desc.View() + "\nIP " + m.ip.View() + "\nPort " + m.port.View()
+	return "Description " + m.desc.View() + "\nType " + m.typ.View() + "\nIP " + m.ip.View() + "\nPort " + m.port.View() +
+		"\nCertificate " + m.cert.View() + "\nKey " + m.key.View() + "\nCA " + m.ca.View() + "\nToken " + m.token.View()
 }
@@ -0,0 +1,444 @@
+package results
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+	connlist "github.com/apoindevster/bitwarp/ui/connlist"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var NotificationChan chan tea.Msg
+
+type listKeyMap struct {
+	Open   key.Binding
+	Group  key.Binding
+	Shell  key.Binding
+	Cancel key.Binding
+	Back   key.Binding
+}
+
+func (k listKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Open, k.Group, k.Shell, k.Cancel, k.Back}
+}
+
+func (k listKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+type outputKeyMap struct {
+	Shell key.Binding
+	Back  key.Binding
+}
+
+func (k outputKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Shell, k.Back}
+}
+
+func (k outputKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+var listKeys = listKeyMap{
+	Open: key.NewBinding(
+		key.WithKeys("enter", "o"),
+		key.WithHelp("o/enter", "View output"),
+	),
+	Group: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "Group identical output"),
+	),
+	Shell: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "Open shell"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "Cancel broadcast"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "Back to connections"),
+	),
+}
+
+var groupKeys = listKeyMap{
+	Group: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "Show every host"),
+	),
+	Back: outputKeys.Back,
+}
+
+var outputKeys = outputKeyMap{
+	Shell: listKeys.Shell,
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "Back to results"),
+	),
+}
+
+// The following Types are the possible custom tea.Msg types
+// objects of these types get propagated back up to NotificationChan
+type ShellReq struct {
+	Name string
+}
+type CancelReq struct{}
+
+// End
+
+// Host is a connection a broadcast runs on.
+type Host struct {
+	// The name the broadcast reports the host by.
+	Name string
+	// How the connection shows in the connection list.
+	Item connlist.Item
+}
+
+type host struct {
+	Host
+	// Appended to as output arrives, which may be a lot of small pieces from many hosts at once.
+	output strings.Builder
+	// Nil while the command is still running.
+	result *commandclient.HostResult
+}
+
+// Summarize counts results by status, such as "3 ok, 1 failed".
+func Summarize(results []commandclient.HostResult) string {
+	var order []string
+	counts := map[string]int{}
+	for _, result := range results {
+		status := result.Status()
+		if counts[status] == 0 {
+			order = append(order, status)
+		}
+		counts[status]++
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, status := range order {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+	}
+	return strings.Join(parts, ", ")
+}
+
+type Model struct {
+	Help     help.Model
+	table    table.Model
+	viewPort viewport.Model
+	width    int
+	height   int
+
+	// The broadcast shown, which output and results of earlier broadcasts are told apart from.
+	id      int
+	command string
+	hosts   []*host
+	// Hosts with the same output share a group, numbered from 1 with the largest group first.
+	groups  [][]*host
+	grouped bool
+	viewing *host
+}
+
+func New(notif chan tea.Msg) Model {
+	NotificationChan = notif
+
+	return Model{
+		Help:     help.New(),
+		table:    table.New(table.WithFocused(true)),
+		viewPort: viewport.New(0, 0),
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Start shows the broadcast id of command as it starts running on hosts, replacing whatever was shown before.
+func (m *Model) Start(id int, command string, hosts []Host) {
+	m.id = id
+	m.command = command
+	m.hosts = make([]*host, 0, len(hosts))
+	for _, h := range hosts {
+		m.hosts = append(m.hosts, &host{Host: h})
+	}
+	m.grouped = false
+	m.viewing = nil
+	m.table.SetCursor(0)
+	m.group()
+	m.refresh()
+}
+
+// Started reports whether there is a broadcast to show.
+func (m *Model) Started() bool {
+	return m.id != 0
+}
+
+// Output adds text to what the host called name printed during broadcast id. Nothing else on the page depends on the
+// output of a running host, so only its own output view is brought up to date.
+func (m *Model) Output(id int, name string, text string) {
+	if h := m.find(id, name); h != nil {
+		h.output.WriteString(text)
+		if m.viewing == h {
+			m.showOutput()
+		}
+	}
+}
+
+// Done records how the broadcast id went on the host the result names.
+func (m *Model) Done(id int, result commandclient.HostResult) {
+	if h := m.find(id, result.Name); h != nil {
+		h.result = &result
+		m.group()
+		m.refresh()
+	}
+}
+
+// Back closes the output of a host or the groups, reporting false when the table was showing so the caller should
+// leave the page.
+func (m *Model) Back() bool {
+	switch {
+	case m.viewing != nil:
+		m.viewing = nil
+	case m.grouped:
+		m.grouped = false
+	default:
+		return false
+	}
+	m.refresh()
+	return true
+}
+
+func (m *Model) find(id int, name string) *host {
+	if id != m.id {
+		return nil
+	}
+	for _, h := range m.hosts {
+		if h.Name == name {
+			return h
+		}
+	}
+	return nil
+}
+
+// Group the hosts that finished by their output, the way dshbak -c does. Output only stops changing once a host
+// finishes, so this is needed whenever one does.
+func (m *Model) group() {
+	byOutput := map[string][]*host{}
+	var order []string
+	for _, h := range m.hosts {
+		if h.result == nil {
+			continue
+		}
+		output := h.output.String()
+		if _, ok := byOutput[output]; !ok {
+			order = append(order, output)
+		}
+		byOutput[output] = append(byOutput[output], h)
+	}
+
+	m.groups = m.groups[:0]
+	for _, output := range order {
+		m.groups = append(m.groups, byOutput[output])
+	}
+	sort.SliceStable(m.groups, func(i, j int) bool { return len(m.groups[i]) > len(m.groups[j]) })
+}
+
+// The number of the group h is in, or 0 while it is still running.
+func (m *Model) groupOf(h *host) int {
+	for i, group := range m.groups {
+		for _, member := range group {
+			if member == h {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// Show the output of the host being viewed, following it as it grows unless scrolled up.
+func (m *Model) showOutput() {
+	atBottom := m.viewPort.AtBottom()
+	m.viewPort.SetContent(m.viewing.output.String())
+	if atBottom {
+		m.viewPort.GotoBottom()
+	}
+}
+
+// Bring the table, and whichever view is open, up to date with the hosts and their groups.
+func (m *Model) refresh() {
+	rows := make([]table.Row, 0, len(m.hosts))
+	for _, h := range m.hosts {
+		status, code, duration, group := "running", "-", "-", "-"
+		if h.result != nil {
+			status = h.result.Status()
+			duration = h.result.Duration.Round(time.Millisecond).String()
+			var failed *commandclient.CommandFailedError
+			if h.result.Err == nil || errors.As(h.result.Err, &failed) {
+				code = fmt.Sprint(h.result.Code)
+			}
+			group = fmt.Sprint(m.groupOf(h))
+		}
+		rows = append(rows, table.Row{h.Item.Title(), h.Name, status, code, duration, group})
+	}
+	m.table.SetRows(rows)
+
+	switch {
+	case m.viewing != nil:
+		m.showOutput()
+	case m.grouped:
+		m.viewPort.SetContent(m.groupsView())
+	}
+}
+
+// Each group of identical output once, under a banner listing the hosts that printed it.
+func (m *Model) groupsView() string {
+	var b strings.Builder
+	for _, group := range m.groups {
+		names := make([]string, 0, len(group))
+		for _, h := range group {
+			names = append(names, h.Item.Title())
+		}
+		banner := strings.Join(names, ",")
+		rule := strings.Repeat("-", min(max(lipgloss.Width(banner), 16), max(m.width, 16)))
+		fmt.Fprintf(&b, "%s\n%s\n%s\n", rule, banner, rule)
+
+		output := group[0].output.String()
+		if output == "" {
+			output = "(no output)\n"
+		} else if !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		b.WriteString(output)
+	}
+
+	var running []string
+	for _, h := range m.hosts {
+		if h.result == nil {
+			running = append(running, h.Item.Title())
+		}
+	}
+	if len(running) > 0 {
+		fmt.Fprintf(&b, "\nStill running on %s\n", strings.Join(running, ","))
+	}
+	return b.String()
+}
+
+// The broadcast and how far it has got, shown above the table.
+func (m *Model) header() string {
+	var finished []commandclient.HostResult
+	for _, h := range m.hosts {
+		if h.result != nil {
+			finished = append(finished, *h.result)
+		}
+	}
+	summary := Summarize(finished)
+	if running := len(m.hosts) - len(finished); running > 0 {
+		if summary != "" {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%d running", running)
+	}
+	return fmt.Sprintf("broadcast %s: %s", m.command, summary)
+}
+
+func (m *Model) selected() *host {
+	if i := m.table.Cursor(); i >= 0 && i < len(m.hosts) {
+		return m.hosts[i]
+	}
+	return nil
+}
+
+func (m *Model) resize() {
+	fixed := []table.Column{
+		{Title: "Address", Width: 22},
+		{Title: "Status", Width: 10},
+		{Title: "Exit", Width: 5},
+		{Title: "Duration", Width: 10},
+		{Title: "Group", Width: 5},
+	}
+	name := m.width
+	for _, c := range fixed {
+		// Every column is padded by one cell on each side.
+		name -= c.Width + 2
+	}
+	m.table.SetColumns([]table.Column{{Title: "Host", Width: max(name-2, 10)}, fixed[0], fixed[1], fixed[2], fixed[3], fixed[4]})
+	m.table.SetWidth(m.width)
+	m.table.SetHeight(max(m.height-lipgloss.Height(m.Help.View(listKeys))-3, 3))
+
+	m.viewPort.Width = m.width
+	m.viewPort.Height = max(m.height-lipgloss.Height(m.Help.View(outputKeys))-2, 1)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.resize()
+		m.refresh()
+		return m, nil
+	case tea.KeyMsg:
+		switch {
+		case m.viewing != nil:
+			if key.Matches(msg, outputKeys.Shell) {
+				name := m.viewing.Name
+				go func() { NotificationChan <- ShellReq{Name: name} }()
+				return m, nil
+			}
+		case m.grouped:
+			if key.Matches(msg, groupKeys.Group) {
+				m.Back()
+				return m, nil
+			}
+		case key.Matches(msg, listKeys.Open):
+			if h := m.selected(); h != nil {
+				m.viewing = h
+				m.viewPort.SetContent(h.output.String())
+				m.viewPort.GotoBottom()
+			}
+			return m, nil
+		case key.Matches(msg, listKeys.Group):
+			m.grouped = true
+			m.viewPort.SetContent(m.groupsView())
+			m.viewPort.GotoTop()
+			return m, nil
+		case key.Matches(msg, listKeys.Shell):
+			if h := m.selected(); h != nil {
+				name := h.Name
+				go func() { NotificationChan <- ShellReq{Name: name} }()
+			}
+			return m, nil
+		case key.Matches(msg, listKeys.Cancel):
+			go func() { NotificationChan <- CancelReq{} }()
+			return m, nil
+		}
+	}
+
+	if m.viewing != nil || m.grouped {
+		m.viewPort, cmd = m.viewPort.Update(msg)
+		return m, cmd
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	switch {
+	case m.viewing != nil:
+		header := fmt.Sprintf("%s (%s): %s", m.viewing.Item.Title(), m.viewing.Name, m.command)
+		return header + "\n" + m.viewPort.View() + "\n" + m.Help.View(outputKeys)
+	case m.grouped:
+		return m.header() + "\n" + m.viewPort.View() + "\n" + m.Help.View(groupKeys)
+	}
+	return m.header() + "\n" + m.table.View() + "\n" + m.Help.View(listKeys)
+}
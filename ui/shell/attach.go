@@ -0,0 +1,141 @@
+package shell
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+	"github.com/apoindevster/bitwarp/proto"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/cancelreader"
+)
+
+// The following Types are the possible custom tea.Msg types
+// objects of these types get returned to the shell once an attach session ends
+type AttachFinished struct {
+	command string
+	code    int32
+	err     error
+}
+
+// End
+
+// An attach session runs a remote command on a pseudo-terminal and hands it the whole local terminal. It implements
+// tea.ExecCommand so BubbleTea releases the terminal for the duration of the session and restores the ui afterwards.
+type attachSession struct {
+	options *proto.RunExecutableOptions
+	client  *proto.CommandClient
+	code    int32
+	stdin   io.Reader
+	stdout  io.Writer
+}
+
+func (a *attachSession) SetStdin(r io.Reader) {
+	a.stdin = r
+}
+
+func (a *attachSession) SetStdout(w io.Writer) {
+	a.stdout = w
+}
+
+func (a *attachSession) SetStderr(w io.Writer) {}
+
+// The size of the local terminal as a remote window size.
+func windowSize(out *os.File) (*proto.WindowSize, error) {
+	width, height, err := term.GetSize(out.Fd())
+	if err != nil {
+		return nil, err
+	}
+	return &proto.WindowSize{Rows: uint32(height), Cols: uint32(width)}, nil
+}
+
+func (a *attachSession) Run() error {
+	in, ok := a.stdin.(*os.File)
+	if !ok || !term.IsTerminal(in.Fd()) {
+		return errors.New("attach needs an interactive terminal")
+	}
+
+	out, ok := a.stdout.(*os.File)
+	if !ok {
+		out = os.Stdout
+	}
+
+	// Every keystroke goes to the remote terminal untouched, including ctrl+c.
+	state, err := term.MakeRaw(in.Fd())
+	if err != nil {
+		return err
+	}
+	defer term.Restore(in.Fd(), state)
+
+	if size, err := windowSize(out); err == nil {
+		a.options.WindowSize = size
+	}
+
+	reader, err := cancelreader.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer reader.Cancel()
+
+	dataChan := commandclient.MakeExecutableDataChan()
+	done := make(chan int32)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		done <- commandclient.RunExecutableWithOptions(a.options, &dataChan, a.client)
+	}()
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := reader.Read(buf)
+			if err != nil {
+				return
+			}
+
+			input := make([]byte, n)
+			copy(input, buf[:n])
+			select {
+			case dataChan.Stdin <- input:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	go watchResize(out, dataChan.Resize, stop)
+
+	for {
+		select {
+		case output := <-dataChan.Stdout:
+			a.stdout.Write(output)
+		case output := <-dataChan.Stderr:
+			a.stdout.Write(output)
+		case code := <-done:
+			a.code = code
+			return nil
+		}
+	}
+}
+
+// Attach runs command on a pseudo-terminal on the server and takes over the screen until it exits. It takes the same flags as
+// exec.
+func Attach(command string, client *proto.CommandClient) tea.Cmd {
+	options, err := ParseExecOptions(command)
+	if err != nil {
+		return func() tea.Msg {
+			return AttachFinished{command: command, code: -1, err: err}
+		}
+	}
+	options.Pty = true
+	options.Stdin = true
+
+	session := &attachSession{options: options, client: client}
+
+	return tea.Exec(session, func(err error) tea.Msg {
+		return AttachFinished{command: command, code: session.code, err: err}
+	})
+}
@@ -4,10 +4,14 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"strings"
 
+	"github.com/apoindevster/bitwarp/codec"
 	"github.com/apoindevster/bitwarp/commandclient"
 	"github.com/apoindevster/bitwarp/proto"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // The following Types are the possible custom tea.Msg types
@@ -15,20 +19,100 @@ import (
 type RunExecutableUpdate struct {
 	appstring string
 }
+type JobsReq struct{}
+type FilesReq struct{}
+
+// A repeatable -env KEY=VALUE flag.
+type envFlag map[string]string
+
+func (e envFlag) String() string {
+	return fmt.Sprint(map[string]string(e))
+}
+
+func (e envFlag) Set(value string) error {
+	name, val, found := strings.Cut(value, "=")
+	if !found || name == "" {
+		return fmt.Errorf("environment variable %q must look like KEY=VALUE", value)
+	}
+	e[name] = val
+	return nil
+}
+
+// A repeatable flag collecting every value it is given.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
 
 // For commands and flags to commands, use the flag package along with flagsets. This will allow for the subcommands that I am trying to accomplish
-func RunExecutableCommand(command string, client *proto.CommandClient) error {
-	cmdSet := flag.NewFlagSet("ExecCommandSet", -1)
-	cmdSet.Parse(strings.Split(command, " "))
+
+// ParseExecOptions reads the flags and command of exec.
+func ParseExecOptions(command string) (*proto.RunExecutableOptions, error) {
+	return parseExecOptions(flag.NewFlagSet("ExecCommandSet", flag.ContinueOnError), command)
+}
+
+// ParseBroadcastOptions reads the flags and command of a broadcast from the connection list. It takes the flags of exec,
+// where -timeout applies to each connection, along with -fail-fast to stop every connection once the command fails on one.
+func ParseBroadcastOptions(command string) (options *proto.RunExecutableOptions, failFast bool, err error) {
+	cmdSet := flag.NewFlagSet("BroadcastCommandSet", flag.ContinueOnError)
+	stop := cmdSet.Bool("fail-fast", false, "stop every connection as soon as the command fails on one")
+	options, err = parseExecOptions(cmdSet, command)
+	return options, *stop, err
+}
+
+// Add the flags of exec to cmdSet and parse command with it.
+func parseExecOptions(cmdSet *flag.FlagSet, command string) (*proto.RunExecutableOptions, error) {
+	env := envFlag{}
+	cmdSet.SetOutput(io.Discard)
+	cwd := cmdSet.String("cwd", "", "working directory of the command")
+	cmdSet.Var(env, "env", "set an environment variable, KEY=VALUE (repeatable)")
+	clearEnv := cmdSet.Bool("clearenv", false, "start from an empty environment instead of the server's")
+	timeout := cmdSet.Duration("timeout", 0, "kill the command after this long")
+	user := cmdSet.String("user", "", "run the command as this user")
+	stdin := cmdSet.Bool("stdin", false, "keep stdin open so input can be sent from the jobs page")
+	compress := cmdSet.String("compress", "", "compress output with zstd or gzip")
+	if err := cmdSet.Parse(strings.Fields(command)); err != nil {
+		return nil, err
+	}
 
 	if cmdSet.NArg() == 0 {
-		return errors.New("failed to get command to run")
+		return nil, errors.New("failed to get command to run")
+	}
+	compression, err := codec.Parse(*compress)
+	if err != nil {
+		return nil, err
 	}
 
-	execpath := cmdSet.Arg(0)
-	args := []string{}
-	if cmdSet.NArg() > 1 {
-		args = cmdSet.Args()[1:]
+	options := &proto.RunExecutableOptions{
+		Command:     cmdSet.Arg(0),
+		Args:        cmdSet.Args()[1:],
+		Cwd:         *cwd,
+		Env:         env,
+		User:        *user,
+		Stdin:       *stdin,
+		Compression: compression,
+	}
+	if *clearEnv {
+		options.EnvMode = proto.EnvMode_ENV_CLEAR
+	}
+	if *timeout > 0 {
+		options.Timeout = durationpb.New(*timeout)
+	}
+
+	return options, nil
+}
+
+func RunExecutableCommand(command string, client *proto.CommandClient) error {
+	options, err := ParseExecOptions(command)
+	if err != nil {
+		NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("exec: %v\n", err)}
+		return err
 	}
 
 	dataChan := commandclient.MakeExecutableDataChan()
@@ -47,21 +131,236 @@ func RunExecutableCommand(command string, client *proto.CommandClient) error {
 	}()
 
 	// The following line could be expanded to use the return item as the return code of the command and return that back to the user
-	commandclient.RunExecutable(execpath, args, &dataChan, client)
+	commandclient.RunExecutableWithOptions(options, &dataChan, client)
 	completed <- struct{}{}
 	return nil
 }
 
+type transferOptions struct {
+	recursive bool
+	transfer  *commandclient.TransferOptions
+	include   []string
+	exclude   []string
+	src       string
+	dest      string
+}
+
+func parseTransferOptions(name string, command string) (*transferOptions, error) {
+	var include, exclude listFlag
+	cmdSet := flag.NewFlagSet("TransferCommandSet", flag.ContinueOnError)
+	cmdSet.SetOutput(io.Discard)
+	recursive := cmdSet.Bool("r", false, "transfer a whole directory tree")
+	compress := cmdSet.String("compress", "", "compress the file with zstd or gzip on the wire")
+	cmdSet.Var(&include, "include", "only transfer files matching this glob (repeatable, needs -r)")
+	cmdSet.Var(&exclude, "exclude", "skip entries matching this glob (repeatable, needs -r)")
+	if err := cmdSet.Parse(strings.Fields(command)); err != nil {
+		return nil, err
+	}
+
+	if cmdSet.NArg() != 2 {
+		return nil, fmt.Errorf("usage: %s [-r] [-include glob] [-exclude glob] source destination", name)
+	}
+	if !*recursive && (len(include) > 0 || len(exclude) > 0) {
+		return nil, errors.New("-include and -exclude only apply with -r")
+	}
+	if *recursive && *compress != "" {
+		return nil, errors.New("-compress does not apply with -r")
+	}
+	compression, err := codec.Parse(*compress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transferOptions{
+		recursive: *recursive,
+		transfer:  &commandclient.TransferOptions{Compression: compression},
+		include:   include,
+		exclude:   exclude,
+		src:       cmdSet.Arg(0),
+		dest:      cmdSet.Arg(1),
+	}, nil
+}
+
+// Mention how much compression saved, if it was used.
+func describeFile(result *commandclient.TransferResult) string {
+	if result.Wire == result.Transferred {
+		return ""
+	}
+	return fmt.Sprintf(": %d bytes sent as %d, %.1fx compression", result.Transferred, result.Wire, result.Ratio())
+}
+
+func describeTree(summary *proto.TreeSummary) string {
+	return fmt.Sprintf("%d files, %d directories and %d symlinks, %d bytes", summary.GetFiles(), summary.GetDirs(), summary.GetSymlinks(), summary.GetBytes())
+}
+
+// Upload a local file, or a directory tree with -r, to the server.
+func UploadCommand(command string, client *proto.CommandClient) error {
+	options, err := parseTransferOptions("upload", command)
+	if err != nil {
+		NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("upload: %v\n", err)}
+		return err
+	}
+
+	var result string
+	var cancelled bool
+	if options.recursive {
+		var summary *proto.TreeSummary
+		cancelled, err = trackTransfer("upload", options, client, func(transfer *commandclient.TransferOptions) (err error) {
+			summary, err = commandclient.TreeUploadWithOptions(options.src, options.dest, options.include, options.exclude, transfer, client)
+			return err
+		})
+		if cancelled {
+			NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("Cancelled upload of %s\n", options.src)}
+			return err
+		}
+		if err == nil {
+			result = fmt.Sprintf("Uploaded %s to %s: %s\n", options.src, options.dest, describeTree(summary))
+		}
+	} else {
+		var transferred *commandclient.TransferResult
+		cancelled, err = trackTransfer("upload", options, client, func(transfer *commandclient.TransferOptions) (err error) {
+			transferred, err = commandclient.FileUploadWithOptions(options.src, options.dest, transfer, client)
+			return err
+		})
+		if cancelled {
+			NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("Cancelled upload of %s, run it again to resume\n", options.src)}
+			return err
+		}
+		if err == nil {
+			result = fmt.Sprintf("Uploaded %s to %s%s\n", options.src, options.dest, describeFile(transferred))
+		}
+	}
+	if err != nil {
+		NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("upload: %v\n", status.Convert(err).Message())}
+		return err
+	}
+
+	NotificationChan <- RunExecutableUpdate{appstring: result}
+	return nil
+}
+
+// Download a file, or a directory tree with -r, from the server.
+func DownloadCommand(command string, client *proto.CommandClient) error {
+	options, err := parseTransferOptions("download", command)
+	if err != nil {
+		NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("download: %v\n", err)}
+		return err
+	}
+
+	var result string
+	var cancelled bool
+	if options.recursive {
+		var summary *proto.TreeSummary
+		cancelled, err = trackTransfer("download", options, client, func(transfer *commandclient.TransferOptions) (err error) {
+			summary, err = commandclient.TreeDownloadWithOptions(options.src, options.dest, options.include, options.exclude, transfer, client)
+			return err
+		})
+		if cancelled {
+			NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("Cancelled download of %s\n", options.src)}
+			return err
+		}
+		if err == nil {
+			result = fmt.Sprintf("Downloaded %s to %s: %s\n", options.src, options.dest, describeTree(summary))
+		}
+	} else {
+		var transferred *commandclient.TransferResult
+		cancelled, err = trackTransfer("download", options, client, func(transfer *commandclient.TransferOptions) (err error) {
+			transferred, err = commandclient.FileDownloadWithOptions(options.src, options.dest, transfer, client)
+			return err
+		})
+		if cancelled {
+			NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("Cancelled download of %s, run it again to resume\n", options.src)}
+			return err
+		}
+		if err == nil {
+			result = fmt.Sprintf("Downloaded %s to %s%s\n", options.src, options.dest, describeFile(transferred))
+		}
+	}
+	if err != nil {
+		NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("download: %v\n", status.Convert(err).Message())}
+		return err
+	}
+
+	NotificationChan <- RunExecutableUpdate{appstring: result}
+	return nil
+}
+
+var hashAlgorithms = map[string]proto.HashAlgorithm{
+	"sha256":  proto.HashAlgorithm_HASH_SHA256,
+	"sha1":    proto.HashAlgorithm_HASH_SHA1,
+	"md5":     proto.HashAlgorithm_HASH_MD5,
+	"blake2b": proto.HashAlgorithm_HASH_BLAKE2B,
+}
+
+func parseHashOptions(command string) (string, []proto.HashAlgorithm, error) {
+	cmdSet := flag.NewFlagSet("HashCommandSet", flag.ContinueOnError)
+	cmdSet.SetOutput(io.Discard)
+	names := cmdSet.String("a", "sha256", "comma separated algorithms out of sha256, sha1, md5 and blake2b")
+	if err := cmdSet.Parse(strings.Fields(command)); err != nil {
+		return "", nil, err
+	}
+
+	if cmdSet.NArg() != 1 {
+		return "", nil, errors.New("usage: hash [-a sha256,sha1,md5,blake2b] path")
+	}
+
+	var algorithms []proto.HashAlgorithm
+	for _, name := range strings.Split(*names, ",") {
+		algorithm, ok := hashAlgorithms[strings.ToLower(name)]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown hash algorithm %q", name)
+		}
+		algorithms = append(algorithms, algorithm)
+	}
+
+	return cmdSet.Arg(0), algorithms, nil
+}
+
+// Print hashes like sha256sum does for a single algorithm, and in the tagged BSD style when there are several.
+func HashFileCommand(command string, client *proto.CommandClient) error {
+	path, algorithms, err := parseHashOptions(command)
+	if err != nil {
+		NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("hash: %v\n", err)}
+		return err
+	}
+
+	results, err := commandclient.HashFile(path, algorithms, client)
+	if err != nil {
+		NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("hash: %v\n", status.Convert(err).Message())}
+		return err
+	}
+
+	var out strings.Builder
+	for _, result := range results {
+		for _, digest := range result.GetDigests() {
+			if len(algorithms) == 1 {
+				fmt.Fprintf(&out, "%x  %s\n", digest.GetSum(), result.GetPath())
+			} else {
+				name := strings.TrimPrefix(digest.GetAlgorithm().String(), "HASH_")
+				fmt.Fprintf(&out, "%s (%s) = %x\n", name, result.GetPath(), digest.GetSum())
+			}
+		}
+	}
+	NotificationChan <- RunExecutableUpdate{appstring: out.String()}
+	return nil
+}
+
 func ExecuteCommand(command string, args string, client *proto.CommandClient) error {
 	switch command {
 	case "exec":
 		return RunExecutableCommand(args, client)
-	case "upload":
-		// TODO file upload: Awaiting progress bar in new window that will keep track of all of the commands that have been run by a client
+	case "jobs":
+		NotificationChan <- JobsReq{}
 		return nil
-	case "download":
-		// TODO file download: Awaiting progress bar in new window that will keep track of all of the commands that have been run by the client
+	case "files":
+		NotificationChan <- FilesReq{}
 		return nil
+	case "hash":
+		return HashFileCommand(args, client)
+	case "upload":
+		return UploadCommand(args, client)
+	case "download":
+		return DownloadCommand(args, client)
 	default:
 		// For now, just append the invalid to the history as a RunExecutableUpdate
 		NotificationChan <- RunExecutableUpdate{appstring: fmt.Sprintf("Unrecognized command %s\n", command)}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// Send the new size of the local terminal every time it changes until stop is closed.
+func watchResize(out *os.File, resize chan *proto.WindowSize, stop chan struct{}) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGWINCH)
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-sigs:
+			size, err := windowSize(out)
+			if err != nil {
+				continue
+			}
+			select {
+			case resize <- size:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
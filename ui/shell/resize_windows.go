@@ -0,0 +1,35 @@
+//go:build windows
+
+package shell
+
+import (
+	"os"
+	"time"
+
+	"github.com/apoindevster/bitwarp/proto"
+)
+
+// Windows has no SIGWINCH, so poll the size of the local terminal and send it whenever it changes until stop is closed.
+func watchResize(out *os.File, resize chan *proto.WindowSize, stop chan struct{}) {
+	last, _ := windowSize(out)
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			size, err := windowSize(out)
+			if err != nil || (last != nil && size.GetRows() == last.GetRows() && size.GetCols() == last.GetCols()) {
+				continue
+			}
+			last = size
+			select {
+			case resize <- size:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package shell
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/apoindevster/bitwarp/proto"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,10 +18,17 @@ type Model struct {
 	Conn      *proto.CommandClient
 	err       error
 	history   *[]string
+	height    int
+
+	// File transfers in flight over any connection, each with a progress bar while its connection is shown.
+	transfers []*transfer
+	bar       progress.Model
 }
 
 var NotificationChan chan tea.Msg
 
+var transferHelp = lipgloss.NewStyle().Faint(true)
+
 func New(notif chan tea.Msg) Model {
 	vp := viewport.New(0, 0)
 
@@ -35,6 +44,7 @@ func New(notif chan tea.Msg) Model {
 		textInput: ti,
 		Conn:      nil,
 		err:       nil,
+		bar:       newBar(),
 	}
 }
 
@@ -46,6 +56,22 @@ func (m *Model) SetCon(conn *proto.CommandClient, history *[]string) {
 	m.Conn = conn
 	m.history = history
 	m.viewPort.SetContent(strings.Join(*m.history, "\n"))
+	m.resize()
+}
+
+// Refresh shows what was added to the history from outside the shell, such as the output of a broadcast.
+func (m *Model) Refresh() {
+	m.viewPort.SetContent(strings.Join(*m.history, ""))
+	m.viewPort.GotoBottom()
+}
+
+// Leave room under the history for the text input and the progress bars of the transfers being shown.
+func (m *Model) resize() {
+	transfers := 0
+	if view := m.transfersView(); view != "" {
+		transfers = lipgloss.Height(view)
+	}
+	m.viewPort.Height = max(m.height-lipgloss.Height(m.textInput.View())-transfers, 0)
 }
 
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
@@ -56,7 +82,13 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		switch msg.Type {
 		case tea.KeyEnter:
 			command, args, found := strings.Cut(m.textInput.Value(), " ")
-			if found {
+			if command == "attach" {
+				// Attaching takes over the whole terminal, so it has to go through BubbleTea rather than a goroutine.
+				*m.history = append(*m.history, m.textInput.Value()+"\n")
+				m.viewPort.SetContent(strings.Join(*m.history, "\n"))
+				m.textInput.Reset()
+				return m, Attach(args, m.Conn)
+			} else if found {
 				// TODO: Might want to check to make sure that m.conn is not nil
 				go ExecuteCommand(command, args, m.Conn)
 			} else if m.textInput.Value() != "" {
@@ -69,17 +101,39 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, nil
 		case tea.KeyCtrlC:
 			return m, tea.Quit
+		case tea.KeyCtrlX:
+			m.cancelTransfer()
+			return m, nil
 		}
 	case tea.WindowSizeMsg:
 		m.viewPort.Width = msg.Width
-		m.viewPort.Height = msg.Height - lipgloss.Height(m.textInput.View())
+		m.height = msg.Height
 		m.textInput.Width = msg.Width
+		m.resize()
 	case RunExecutableUpdate:
 		// The goroutine that executes the commands passes this message type back to the app so we can display it here.
 		*m.history = append(*m.history, msg.appstring)
 		m.viewPort.SetContent(strings.Join(*m.history, ""))
 		m.viewPort.GotoBottom()
 		return m, nil
+	case TransferStarted:
+		m.transferStarted(msg)
+		return m, nil
+	case TransferProgress:
+		m.transferProgress(msg)
+		return m, nil
+	case TransferFinished:
+		m.transferFinished(msg)
+		return m, nil
+	case AttachFinished:
+		if msg.err != nil {
+			*m.history = append(*m.history, fmt.Sprintf("attach %s failed: %v\n", msg.command, msg.err))
+		} else {
+			*m.history = append(*m.history, fmt.Sprintf("attach %s exited with code %d\n", msg.command, msg.code))
+		}
+		m.viewPort.SetContent(strings.Join(*m.history, ""))
+		m.viewPort.GotoBottom()
+		return m, nil
 	case error:
 		m.err = msg
 		return m, nil
@@ -93,5 +147,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 }
 
 func (m Model) View() string {
+	if transfers := m.transfersView(); transfers != "" {
+		return m.viewPort.View() + "\n" + transfers + "\n" + m.textInput.View()
+	}
 	return m.viewPort.View() + "\n" + m.textInput.View()
 }
@@ -0,0 +1,162 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/apoindevster/bitwarp/commandclient"
+	"github.com/apoindevster/bitwarp/proto"
+	"github.com/apoindevster/bitwarp/ui/units"
+	"github.com/charmbracelet/bubbles/progress"
+)
+
+// The following Types are the possible custom tea.Msg types
+// objects of these types get propagated back up to NotificationChan while a file is transferred
+type TransferStarted struct {
+	id     int64
+	name   string
+	conn   *proto.CommandClient
+	cancel context.CancelFunc
+}
+type TransferProgress struct {
+	id    int64
+	done  int64
+	total int64
+}
+type TransferFinished struct {
+	id int64
+}
+
+// End
+
+var transferIDs atomic.Int64
+
+// A file or tree transfer still in flight, shown as a progress bar under the history of the connection it runs over.
+type transfer struct {
+	id     int64
+	name   string
+	conn   *proto.CommandClient
+	cancel context.CancelFunc
+
+	done  int64
+	total int64
+	// Bytes in place and when the first progress report came in, so resumed bytes do not count towards the throughput.
+	firstDone int64
+	firstSeen time.Time
+}
+
+// Run a transfer with a progress bar in the shell that can be cancelled with ctrl+x. run is handed options.transfer with
+// its Context and Progress set. It reports whether the transfer was cancelled, in which case the error is the
+// cancellation.
+func trackTransfer(name string, options *transferOptions, client *proto.CommandClient, run func(*commandclient.TransferOptions) error) (bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	id := transferIDs.Add(1)
+	NotificationChan <- TransferStarted{id: id, name: fmt.Sprintf("%s %s → %s", name, options.src, options.dest), conn: client, cancel: cancel}
+	defer func() { NotificationChan <- TransferFinished{id: id} }()
+
+	last := time.Time{}
+	options.transfer.Context = ctx
+	options.transfer.Progress = func(done int64, total int64) {
+		if now := time.Now(); now.Sub(last) >= units.ProgressInterval || done == total {
+			last = now
+			NotificationChan <- TransferProgress{id: id, done: done, total: total}
+		}
+	}
+
+	err := run(options.transfer)
+	return err != nil && ctx.Err() != nil, err
+}
+
+func (m *Model) transferStarted(msg TransferStarted) {
+	m.transfers = append(m.transfers, &transfer{id: msg.id, name: msg.name, conn: msg.conn, cancel: msg.cancel})
+	m.resize()
+}
+
+func (m *Model) transferProgress(msg TransferProgress) {
+	for _, t := range m.transfers {
+		if t.id == msg.id {
+			if t.firstSeen.IsZero() {
+				t.firstDone, t.firstSeen = msg.done, time.Now()
+			}
+			t.done, t.total = msg.done, msg.total
+			return
+		}
+	}
+}
+
+func (m *Model) transferFinished(msg TransferFinished) {
+	for i, t := range m.transfers {
+		if t.id == msg.id {
+			m.transfers = append(m.transfers[:i], m.transfers[i+1:]...)
+			break
+		}
+	}
+	m.resize()
+}
+
+// Transfers running over the connection the shell is showing, oldest first.
+func (m *Model) shownTransfers() []*transfer {
+	var shown []*transfer
+	for _, t := range m.transfers {
+		if t.conn == m.Conn {
+			shown = append(shown, t)
+		}
+	}
+	return shown
+}
+
+// Cancel the most recently started transfer of the connection being shown. It reports false if there is none.
+func (m *Model) cancelTransfer() bool {
+	shown := m.shownTransfers()
+	if len(shown) == 0 {
+		return false
+	}
+	shown[len(shown)-1].cancel()
+	return true
+}
+
+func (m Model) transfersView() string {
+	shown := m.shownTransfers()
+	if len(shown) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for _, t := range shown {
+		if t.firstSeen.IsZero() {
+			lines = append(lines, t.name+" starting...")
+			continue
+		}
+		if t.total == 0 {
+			// A directory tree, whose size is not known until it has all been copied.
+			line := fmt.Sprintf("%s %s so far", t.name, units.Bytes(t.done))
+			if elapsed := time.Since(t.firstSeen).Seconds(); elapsed > 0 && t.done > t.firstDone {
+				line += fmt.Sprintf(" %s/s", units.Bytes(int64(float64(t.done-t.firstDone)/elapsed)))
+			}
+			lines = append(lines, line)
+			continue
+		}
+		fraction := 0.0
+		if t.total > 0 {
+			fraction = float64(t.done) / float64(t.total)
+		}
+		line := fmt.Sprintf("%s %s %s / %s", t.name, m.bar.ViewAs(fraction), units.Bytes(t.done), units.Bytes(t.total))
+		if elapsed := time.Since(t.firstSeen).Seconds(); elapsed > 0 && t.done > t.firstDone {
+			rate := float64(t.done-t.firstDone) / elapsed
+			eta := time.Duration(float64(t.total-t.done) / rate * float64(time.Second))
+			line += fmt.Sprintf(" %s/s, %s left", units.Bytes(int64(rate)), eta.Round(time.Second))
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, transferHelp.Render("ctrl+x cancels the latest transfer"))
+	return strings.Join(lines, "\n")
+}
+
+func newBar() progress.Model {
+	return progress.New(progress.WithDefaultGradient(), progress.WithWidth(30))
+}
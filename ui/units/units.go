@@ -0,0 +1,23 @@
+package units
+
+import (
+	"fmt"
+	"time"
+)
+
+// How often a running transfer reports its progress to the ui.
+const ProgressInterval = 100 * time.Millisecond
+
+// Bytes formats n as a size in B, KiB, MiB or GiB.
+func Bytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}